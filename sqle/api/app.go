@@ -78,6 +78,7 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config config.SqleConfi
 		v1Router.POST("/instances", v1.CreateInstance, AdminUserAllowed())
 		v1Router.DELETE("/instances/:instance_name/", v1.DeleteInstance, AdminUserAllowed())
 		v1Router.PATCH("/instances/:instance_name/", v1.UpdateInstance, AdminUserAllowed())
+		v1Router.POST("/instances/:instance_name/recycle_bin_objects/:object_id/restore", v1.RestoreRecycleBinObject, AdminUserAllowed())
 
 		// rule template
 		v1Router.POST("/rule_templates", v1.CreateRuleTemplate, AdminUserAllowed())
@@ -96,6 +97,9 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config config.SqleConfi
 		// workflow
 		v1Router.POST("/workflows/cancel", v1.BatchCancelWorkflows, AdminUserAllowed())
 
+		// task
+		v1Router.GET("/tasks/queue", v1.GetTaskQueue, AdminUserAllowed())
+
 		// audit whitelist
 		v1Router.GET("/audit_whitelist", v1.GetSqlWhitelist, AdminUserAllowed())
 		v1Router.POST("/audit_whitelist", v1.CreateAuditWhitelist, AdminUserAllowed())
@@ -109,6 +113,7 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config config.SqleConfi
 		v1Router.PATCH("/configurations/smtp", v1.UpdateSMTPConfiguration, AdminUserAllowed())
 		v1Router.GET("/configurations/system_variables", v1.GetSystemVariables, AdminUserAllowed())
 		v1Router.PATCH("/configurations/system_variables", v1.UpdateSystemVariables, AdminUserAllowed())
+		v1Router.POST("/configurations/drivers/reload", v1.ReloadDrivers, AdminUserAllowed())
 	}
 
 	// user
@@ -125,6 +130,7 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config config.SqleConfi
 	v1Router.GET("/instances/:instance_name/schemas", v1.GetInstanceSchemas)
 	v1Router.GET("/instance_tips", v1.GetInstanceTips)
 	v1Router.GET("/instances/:instance_name/rules", v1.GetInstanceRules)
+	v1Router.GET("/instances/:instance_name/recycle_bin_objects", v1.GetRecycleBinObjects)
 
 	// rule template
 	v1Router.GET("/rule_templates", v1.GetRuleTemplates)
@@ -141,6 +147,13 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config config.SqleConfi
 	v1Router.POST("/workflows/:workflow_id/steps/:workflow_step_id/approve", v1.ApproveWorkflow)
 	v1Router.POST("/workflows/:workflow_id/steps/:workflow_step_id/reject", v1.RejectWorkflow)
 	v1Router.POST("/workflows/:workflow_id/cancel", v1.CancelWorkflow)
+	v1Router.POST("/workflows/:workflow_id/task/cancel", v1.CancelWorkflowTask)
+	v1Router.POST("/workflows/:workflow_id/task/resume", v1.ResumeWorkflowTask)
+	v1Router.POST("/workflows/:workflow_id/task/retry", v1.RetryWorkflowTask)
+	v1Router.POST("/workflows/:workflow_id/task/rollback", v1.RollbackWorkflowTask)
+	v1Router.POST("/workflows/:workflow_id/task/command", v1.SendWorkflowTaskCommand)
+	v1Router.PATCH("/workflows/:workflow_id/task/rollback_sqls/:rollback_sql_id/", v1.EditWorkflowRollbackSQL)
+	v1Router.PUT("/workflows/:workflow_id/schedule", v1.ScheduleWorkflow)
 	v1Router.PATCH("/workflows/:workflow_id/", v1.UpdateWorkflow)
 
 	// task
@@ -150,6 +163,8 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config config.SqleConfi
 	v1Router.GET("/tasks/audits/:task_id/sql_report", v1.DownloadTaskSQLReportFile)
 	v1Router.GET("/tasks/audits/:task_id/sql_file", v1.DownloadTaskSQLFile)
 	v1Router.GET("/tasks/audits/:task_id/sql_content", v1.GetAuditTaskSQLContent)
+	v1Router.POST("/tasks/audits/:task_id/cancel", v1.CancelTask)
+	v1Router.GET("/tasks/audits/:task_id/rollback_sql_edit_records", v1.GetRollbackSQLEditRecords)
 
 	// dashboard
 	v1Router.GET("/dashboard", v1.Dashboard)
@@ -366,6 +366,8 @@ type AuditPlanReportSQLResV1 struct {
 	LastReceiveText      string `json:"audit_plan_report_sql_last_receive_text" example:"select * from t1 where id = 1"`
 	LastReceiveTimestamp string `json:"audit_plan_report_sql_last_receive_timestamp" example:"RFC3339"`
 	AuditResult          string `json:"audit_plan_report_sql_audit_result" example:"same format as task audit result"`
+
+	AuditResults []*AuditResultResV1 `json:"audit_plan_report_sql_audit_results"`
 }
 
 // @Summary 获取指定审核计划的SQL审核详情
@@ -416,6 +418,7 @@ func GetAuditPlanReportSQLs(c echo.Context) error {
 			LastReceiveText:      auditPlanReportSQL.LastReceiveText,
 			LastReceiveTimestamp: auditPlanReportSQL.LastReceiveTimestamp,
 			AuditResult:          auditPlanReportSQL.AuditResult,
+			AuditResults:         convertAuditResultsToRes(auditPlanReportSQL.AuditResults),
 		})
 	}
 	return c.JSON(http.StatusOK, &GetAuditPlanReportSQLsResV1{
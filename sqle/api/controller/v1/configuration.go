@@ -3,10 +3,12 @@ package v1
 import (
 	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/actiontech/sqle/sqle/api/controller"
 	"github.com/actiontech/sqle/sqle/driver"
 	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
 
 	"github.com/labstack/echo/v4"
 )
@@ -273,20 +275,96 @@ type GetDriversResV1 struct {
 }
 
 type DriversResV1 struct {
-	Drivers []string `json:"driver_name_list"`
+	Drivers    []string      `json:"driver_name_list"`
+	DriverList []DriverResV1 `json:"driver_list"`
+}
+
+type DriverResV1 struct {
+	Name string `json:"driver_name"`
+	// Version is empty for the driver built in SQLe.
+	Version string `json:"version"`
+	// ProtocolVersion is 0 for the driver built in SQLe.
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities" enums:"rollback,explain,online_ddl,offline_audit,transaction"`
 }
 
 // GetDrivers get support Driver list.
 // @Summary 获取当前 server 支持的审核类型
-// @Description get drivers
+// @Description get drivers, with the version and capabilities of each driver
 // @Id getDriversV1
 // @Tags configuration
 // @Security ApiKeyAuth
 // @Success 200 {object} v1.GetDriversResV1
 // @router /v1/configurations/drivers [get]
 func GetDrivers(c echo.Context) error {
+	data := DriversResV1{
+		Drivers:    driver.AllDrivers(),
+		DriverList: []DriverResV1{},
+	}
+	allMetas := driver.AllDriverMetas()
+	sort.Strings(data.Drivers)
+	for _, name := range data.Drivers {
+		d := DriverResV1{Name: name, Capabilities: []string{}}
+		if metas := allMetas[name]; metas != nil {
+			d.Version = metas.Version
+			d.ProtocolVersion = metas.ProtocolVersion
+			for _, capability := range metas.Capabilities {
+				d.Capabilities = append(d.Capabilities, string(capability))
+			}
+		}
+		data.DriverList = append(data.DriverList, d)
+	}
 	return c.JSON(http.StatusOK, &GetDriversResV1{
 		BaseRes: controller.NewBaseReq(nil),
-		Data:    DriversResV1{Drivers: driver.AllDrivers()},
+		Data:    data,
+	})
+}
+
+type ReloadDriversResV1 struct {
+	controller.BaseRes
+	Data ReloadDriversResDataV1 `json:"data"`
+}
+
+type ReloadDriversResDataV1 struct {
+	LoadedDrivers   []string            `json:"loaded_driver_name_list"`
+	UpgradedDrivers []string            `json:"upgraded_driver_name_list"`
+	UnloadedDrivers []string            `json:"unloaded_driver_name_list"`
+	FailedPlugins   []FailedPluginResV1 `json:"failed_plugin_list"`
+}
+
+type FailedPluginResV1 struct {
+	PluginPath string `json:"plugin_path"`
+	Error      string `json:"error"`
+}
+
+// ReloadDrivers reload plugins at plugin path.
+// @Summary 重新加载插件
+// @Description reload driver plugins, load new plugins, upgrade changed plugins and unload removed plugins
+// @Id reloadDriversV1
+// @Tags configuration
+// @Security ApiKeyAuth
+// @Success 200 {object} v1.ReloadDriversResV1
+// @router /v1/configurations/drivers/reload [post]
+func ReloadDrivers(c echo.Context) error {
+	result, err := server.ReloadPlugins(true)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	data := ReloadDriversResDataV1{
+		LoadedDrivers:   result.Loaded,
+		UpgradedDrivers: result.Upgraded,
+		UnloadedDrivers: result.Unloaded,
+		FailedPlugins:   []FailedPluginResV1{},
+	}
+	for path, err := range result.Failed {
+		data.FailedPlugins = append(data.FailedPlugins, FailedPluginResV1{
+			PluginPath: path,
+			Error:      err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, &ReloadDriversResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
 	})
 }
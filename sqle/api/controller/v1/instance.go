@@ -30,6 +30,45 @@ type CreateInstanceReqV1 struct {
 	WorkflowTemplateName string   `json:"workflow_template_name" form:"workflow_template_name"`
 	RuleTemplates        []string `json:"rule_template_name_list" form:"rule_template_name_list"`
 	Roles                []string `json:"role_name_list" form:"role_name_list"`
+	// MaintenancePeriods is the time windows in which SQLs are allowed to be executed.
+	MaintenancePeriods []*MaintenancePeriodV1 `json:"maintenance_period_list" form:"maintenance_period_list"`
+}
+
+type MaintenancePeriodV1 struct {
+	StartHour   int `json:"start_hour" example:"22"`
+	StartMinute int `json:"start_minute" example:"0"`
+	EndHour     int `json:"end_hour" example:"2"`
+	EndMinute   int `json:"end_minute" example:"30"`
+}
+
+func convertMaintenancePeriodsToModel(periods []*MaintenancePeriodV1) (model.Periods, error) {
+	ps := make(model.Periods, 0, len(periods))
+	for _, period := range periods {
+		p := &model.Period{
+			StartHour:   period.StartHour,
+			StartMinute: period.StartMinute,
+			EndHour:     period.EndHour,
+			EndMinute:   period.EndMinute,
+		}
+		if !p.IsValid() {
+			return nil, errors.New(errors.DataInvalid, fmt.Errorf("maintenance period %s is invalid", p))
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+func convertMaintenancePeriodsToRes(ps model.Periods) []*MaintenancePeriodV1 {
+	periods := make([]*MaintenancePeriodV1, 0, len(ps))
+	for _, p := range ps {
+		periods = append(periods, &MaintenancePeriodV1{
+			StartHour:   p.StartHour,
+			StartMinute: p.StartMinute,
+			EndHour:     p.EndHour,
+			EndMinute:   p.EndMinute,
+		})
+	}
+	return periods
 }
 
 // CreateInstance create instance
@@ -59,6 +98,10 @@ func CreateInstance(c echo.Context) error {
 	if req.DBType == "" {
 		req.DBType = driver.DriverTypeMySQL
 	}
+	maintenancePeriods, err := convertMaintenancePeriodsToModel(req.MaintenancePeriods)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
 	instance := &model.Instance{
 		DbType:   req.DBType,
 		Name:     req.Name,
@@ -67,6 +110,8 @@ func CreateInstance(c echo.Context) error {
 		Port:     req.Port,
 		Password: req.Password,
 		Desc:     req.Desc,
+
+		MaintenancePeriods: maintenancePeriods,
 	}
 	// set default workflow template
 	if req.WorkflowTemplateName == "" {
@@ -144,15 +189,16 @@ func checkCurrentUserCanAccessInstance(c echo.Context, instance *model.Instance)
 }
 
 type InstanceResV1 struct {
-	Name                 string   `json:"instance_name"`
-	DBType               string   `json:"db_type" example:"mysql"`
-	Host                 string   `json:"db_host" example:"10.10.10.10"`
-	Port                 string   `json:"db_port" example:"3306"`
-	User                 string   `json:"db_user" example:"root"`
-	Desc                 string   `json:"desc" example:"this is a instance"`
-	WorkflowTemplateName string   `json:"workflow_template_name,omitempty"`
-	RuleTemplates        []string `json:"rule_template_name_list,omitempty"`
-	Roles                []string `json:"role_name_list,omitempty"`
+	Name                 string                 `json:"instance_name"`
+	DBType               string                 `json:"db_type" example:"mysql"`
+	Host                 string                 `json:"db_host" example:"10.10.10.10"`
+	Port                 string                 `json:"db_port" example:"3306"`
+	User                 string                 `json:"db_user" example:"root"`
+	Desc                 string                 `json:"desc" example:"this is a instance"`
+	WorkflowTemplateName string                 `json:"workflow_template_name,omitempty"`
+	RuleTemplates        []string               `json:"rule_template_name_list,omitempty"`
+	Roles                []string               `json:"role_name_list,omitempty"`
+	MaintenancePeriods   []*MaintenancePeriodV1 `json:"maintenance_period_list,omitempty"`
 }
 
 type GetInstanceResV1 struct {
@@ -168,6 +214,8 @@ func convertInstanceToRes(instance *model.Instance) InstanceResV1 {
 		User:   instance.User,
 		Desc:   instance.Desc,
 		DBType: instance.DbType,
+
+		MaintenancePeriods: convertMaintenancePeriodsToRes(instance.MaintenancePeriods),
 	}
 	if instance.WorkflowTemplate != nil {
 		instanceResV1.WorkflowTemplateName = instance.WorkflowTemplate.Name
@@ -274,6 +322,9 @@ type UpdateInstanceReqV1 struct {
 	WorkflowTemplateName *string  `json:"workflow_template_name" form:"workflow_template_name"`
 	RuleTemplates        []string `json:"rule_template_name_list" form:"rule_template_name_list"`
 	Roles                []string `json:"role_name_list" form:"role_name_list"`
+	// MaintenancePeriods is the time windows in which SQLs are allowed to be executed,
+	// empty list means no limitation.
+	MaintenancePeriods []*MaintenancePeriodV1 `json:"maintenance_period_list" form:"maintenance_period_list"`
 }
 
 // UpdateInstance update instance
@@ -328,6 +379,13 @@ func UpdateInstance(c echo.Context) error {
 	if req.User != nil {
 		updateMap["db_user"] = *req.User
 	}
+	if req.MaintenancePeriods != nil {
+		maintenancePeriods, err := convertMaintenancePeriodsToModel(req.MaintenancePeriods)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		updateMap["maintenance_periods"] = maintenancePeriods
+	}
 	if req.Password != nil {
 		password, err := utils.AesEncrypt(*req.Password)
 		if err != nil {
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetRecycleBinObjectsResV1 struct {
+	controller.BaseRes
+	Data []*RecycleBinObjectResV1 `json:"data"`
+}
+
+type RecycleBinObjectResV1 struct {
+	Id        uint       `json:"id"`
+	TaskId    uint       `json:"task_id"`
+	Schema    string     `json:"schema"`
+	Object    string     `json:"object"`
+	DroppedAt *time.Time `json:"dropped_at"`
+	ExpiredAt *time.Time `json:"expired_at"`
+}
+
+// @Summary 获取实例回收站中的对象列表
+// @Description get the dropped objects in recycle bin of instance
+// @Id getRecycleBinObjectsV1
+// @Tags instance
+// @Security ApiKeyAuth
+// @Param instance_name path string true "instance name"
+// @Success 200 {object} v1.GetRecycleBinObjectsResV1
+// @router /v1/instances/{instance_name}/recycle_bin_objects [get]
+func GetRecycleBinObjects(c echo.Context) error {
+	s := model.GetStorage()
+	instance, exist, err := s.GetInstanceByName(c.Param("instance_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, instanceNoAccessError)
+	}
+	err = checkCurrentUserCanAccessInstance(c, instance)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	objects, err := s.GetRecycleBinObjectsByInstanceId(instance.ID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	objectsRes := make([]*RecycleBinObjectResV1, 0, len(objects))
+	for _, object := range objects {
+		droppedAt, expiredAt := object.CreatedAt, object.ExpiredAt
+		objectsRes = append(objectsRes, &RecycleBinObjectResV1{
+			Id:        object.ID,
+			TaskId:    object.TaskId,
+			Schema:    object.Schema,
+			Object:    object.RecycleBinObject,
+			DroppedAt: &droppedAt,
+			ExpiredAt: &expiredAt,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetRecycleBinObjectsResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    objectsRes,
+	})
+}
+
+// @Summary 从实例回收站恢复对象
+// @Description restore the dropped object from recycle bin of instance
+// @Id restoreRecycleBinObjectV1
+// @Tags instance
+// @Security ApiKeyAuth
+// @Param instance_name path string true "instance name"
+// @Param object_id path string true "recycle bin object id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/instances/{instance_name}/recycle_bin_objects/{object_id}/restore [post]
+func RestoreRecycleBinObject(c echo.Context) error {
+	s := model.GetStorage()
+	instance, exist, err := s.GetInstanceByName(c.Param("instance_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, instanceNotExistError)
+	}
+	object, exist, err := s.GetRecycleBinObject(instance.ID, c.Param("object_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataNotExist,
+			fmt.Errorf("recycle bin object is not exist")))
+	}
+	err = server.GetSqled().RestoreRecycleBinObject(log.NewEntry(), instance, object)
+	return controller.JSONBaseErrorReq(c, err)
+}
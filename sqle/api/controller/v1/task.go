@@ -13,6 +13,7 @@ import (
 	"github.com/actiontech/sqle/sqle/api/controller"
 	"github.com/actiontech/sqle/sqle/errors"
 	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/misc"
 	"github.com/actiontech/sqle/sqle/model"
 	"github.com/actiontech/sqle/sqle/server"
 
@@ -26,6 +27,11 @@ type CreateAuditTaskReqV1 struct {
 	InstanceName   string `json:"instance_name" form:"instance_name" example:"inst_1" valid:"required"`
 	InstanceSchema string `json:"instance_schema" form:"instance_schema" example:"db1"`
 	Sql            string `json:"sql" example:"alter table tb1 drop columns c1"`
+	// Async returns the task without waiting for the audit, the progress of the
+	// audit can be got by GetTask.
+	Async bool `json:"async" form:"async"`
+	// Notify sends email to the creator when the async audit is finished.
+	Notify bool `json:"notify" form:"notify"`
 }
 
 type GetAuditTaskResV1 struct {
@@ -38,8 +44,12 @@ type AuditTaskResV1 struct {
 	InstanceName   string  `json:"instance_name"`
 	InstanceSchema string  `json:"instance_schema" example:"db1"`
 	PassRate       float64 `json:"pass_rate"`
-	Status         string  `json:"status" enums:"initialized,audited,executing,exec_success,exec_failed"`
+	Status         string  `json:"status" enums:"initialized,auditing,audit_failed,audited,executing,exec_success,exec_failed,exec_interrupted,exec_cancelled"`
 	SQLSource      string  `json:"sql_source" enums:"form_data,sql_file,mybatis_xml_file,audit_plan"`
+
+	// AuditedSQLCount and TotalSQLCount are the progress of the audit.
+	AuditedSQLCount uint `json:"audited_sql_count"`
+	TotalSQLCount   uint `json:"total_sql_count"`
 }
 
 func convertTaskToRes(task *model.Task) *AuditTaskResV1 {
@@ -50,6 +60,8 @@ func convertTaskToRes(task *model.Task) *AuditTaskResV1 {
 		PassRate:       task.PassRate,
 		Status:         task.Status,
 		SQLSource:      task.SQLSource,
+
+		AuditedSQLCount: task.AuditedSQLCount,
 	}
 }
 
@@ -88,6 +100,7 @@ func getSQLFromFile(c echo.Context) (string, string, error) {
 // @Description 1. formData[sql]: sql content;
 // @Description 2. file[input_sql_file]: it is a sql file;
 // @Description 3. file[input_mybatis_xml_file]: it is mybatis xml file, sql will be parsed from it.
+// @Description The task is returned after it's audited, or returned immediately if async is true, then the audit progress can be got by getAuditTaskV1.
 // @Accept mpfd
 // @Produce json
 // @Tags task
@@ -98,6 +111,8 @@ func getSQLFromFile(c echo.Context) (string, string, error) {
 // @Param sql formData string false "sqls for audit"
 // @Param input_sql_file formData file false "input SQL file"
 // @Param input_mybatis_xml_file formData file false "input mybatis XML file"
+// @Param async formData boolean false "return without waiting for the audit"
+// @Param notify formData boolean false "send email to the creator when the async audit is finished"
 // @Success 200 {object} v1.GetAuditTaskResV1
 // @router /v1/tasks/audits [post]
 func CreateAndAuditTask(c echo.Context) error {
@@ -175,13 +190,29 @@ func CreateAndAuditTask(c echo.Context) error {
 		return controller.JSONBaseErrorReq(c, err)
 	}
 	task.Instance = instance
-	task, err = server.GetSqled().AddTaskWaitResult(fmt.Sprintf("%d", task.ID), server.ActionTypeAudit)
-	if err != nil {
-		return controller.JSONBaseErrorReq(c, err)
+	if req.Async {
+		var notify func(task *model.Task, err error)
+		if req.Notify {
+			notify = func(task *model.Task, err error) {
+				if err := misc.SendAuditTaskEmailIfConfigureSMTP(user.Email, task, err); err != nil {
+					log.NewEntry().Errorf("notify the audit of task %d is finished error: %v", task.ID, err)
+				}
+			}
+		}
+		if err := server.GetSqled().AddTaskWithCallback(fmt.Sprintf("%d", task.ID), server.ActionTypeAudit, notify); err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+	} else {
+		task, err = server.GetSqled().AddTaskWaitResult(fmt.Sprintf("%d", task.ID), server.ActionTypeAudit)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
 	}
+	taskRes := convertTaskToRes(task)
+	taskRes.TotalSQLCount = uint(len(nodes))
 	return c.JSON(http.StatusOK, &GetAuditTaskResV1{
 		BaseRes: controller.NewBaseReq(nil),
-		Data:    convertTaskToRes(task),
+		Data:    taskRes,
 	})
 }
 
@@ -236,16 +267,22 @@ func GetTask(c echo.Context) error {
 	if err != nil {
 		return controller.JSONBaseErrorReq(c, err)
 	}
+	taskRes := convertTaskToRes(task)
+	taskRes.TotalSQLCount, err = s.GetTaskSQLCount(task.ID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
 
 	return c.JSON(http.StatusOK, &GetAuditTaskResV1{
 		BaseRes: controller.NewBaseReq(nil),
-		Data:    convertTaskToRes(task),
+		Data:    taskRes,
 	})
 }
 
 type GetAuditTaskSQLsReqV1 struct {
 	FilterExecStatus  string `json:"filter_exec_status" query:"filter_exec_status"`
 	FilterAuditStatus string `json:"filter_audit_status" query:"filter_audit_status"`
+	FilterRuleName    string `json:"filter_audit_rule_name" query:"filter_audit_rule_name"`
 	NoDuplicate       bool   `json:"no_duplicate" query:"no_duplicate"`
 	PageIndex         uint32 `json:"page_index" query:"page_index" valid:"required"`
 	PageSize          uint32 `json:"page_size" query:"page_size" valid:"required"`
@@ -258,14 +295,41 @@ type GetAuditTaskSQLsResV1 struct {
 }
 
 type AuditTaskSQLResV1 struct {
-	Number      uint   `json:"number"`
-	ExecSQL     string `json:"exec_sql"`
-	AuditResult string `json:"audit_result"`
-	AuditLevel  string `json:"audit_level"`
-	AuditStatus string `json:"audit_status"`
-	ExecResult  string `json:"exec_result"`
-	ExecStatus  string `json:"exec_status"`
-	RollbackSQL string `json:"rollback_sql,omitempty"`
+	Number       uint                `json:"number"`
+	ExecSQL      string              `json:"exec_sql"`
+	AuditResult  string              `json:"audit_result"`
+	AuditResults []*AuditResultResV1 `json:"audit_results"`
+	AuditLevel   string              `json:"audit_level"`
+	AuditStatus  string              `json:"audit_status"`
+	ExecResult   string              `json:"exec_result"`
+	ExecStatus   string              `json:"exec_status"`
+	ExecProgress string              `json:"exec_progress,omitempty"`
+	RollbackSQL  string              `json:"rollback_sql,omitempty"`
+
+	RollbackSQLId         uint   `json:"rollback_sql_id,omitempty"`
+	RollbackSQLExecStatus string `json:"rollback_sql_exec_status,omitempty"`
+}
+
+type AuditResultResV1 struct {
+	RuleName string `json:"rule_name"`
+	Category string `json:"category"`
+	Level    string `json:"level" enums:"normal,notice,warn,error"`
+	Message  string `json:"message"`
+	Prefix   string `json:"prefix,omitempty" example:"[osc]"`
+}
+
+func convertAuditResultsToRes(results model.AuditResults) []*AuditResultResV1 {
+	res := make([]*AuditResultResV1, 0, len(results))
+	for _, result := range results {
+		res = append(res, &AuditResultResV1{
+			RuleName: result.RuleName,
+			Category: result.Category,
+			Level:    result.Level,
+			Message:  result.Message,
+			Prefix:   result.Prefix,
+		})
+	}
+	return res
 }
 
 // @Summary 获取指定审核任务的SQLs信息
@@ -274,8 +338,9 @@ type AuditTaskSQLResV1 struct {
 // @Id getAuditTaskSQLsV1
 // @Security ApiKeyAuth
 // @Param task_id path string true "task id"
-// @Param filter_exec_status query string false "filter: exec status of task sql" Enums(initialized,doing,succeeded,failed)
+// @Param filter_exec_status query string false "filter: exec status of task sql" Enums(initialized,doing,succeeded,failed,interrupted,cancelled)
 // @Param filter_audit_status query string false "filter: audit status of task sql" Enums(initialized,doing,finished)
+// @Param filter_audit_rule_name query string false "filter: name of the rule which task sql violated"
 // @Param no_duplicate query boolean false "select unique (fingerprint and audit result) for task sql"
 // @Param page_index query string false "page index"
 // @Param page_size query string false "page size"
@@ -305,12 +370,13 @@ func GetTaskSQLs(c echo.Context) error {
 		offset = req.PageSize * (req.PageIndex - 1)
 	}
 	data := map[string]interface{}{
-		"task_id":             taskId,
-		"filter_exec_status":  req.FilterExecStatus,
-		"filter_audit_status": req.FilterAuditStatus,
-		"no_duplicate":        req.NoDuplicate,
-		"limit":               req.PageSize,
-		"offset":              offset,
+		"task_id":                taskId,
+		"filter_exec_status":     req.FilterExecStatus,
+		"filter_audit_status":    req.FilterAuditStatus,
+		"filter_audit_rule_name": req.FilterRuleName,
+		"no_duplicate":           req.NoDuplicate,
+		"limit":                  req.PageSize,
+		"offset":                 offset,
 	}
 
 	taskSQLs, count, err := s.GetTaskSQLsByReq(data)
@@ -321,14 +387,19 @@ func GetTaskSQLs(c echo.Context) error {
 	taskSQLsRes := make([]*AuditTaskSQLResV1, 0, len(taskSQLs))
 	for _, taskSQL := range taskSQLs {
 		taskSQLRes := &AuditTaskSQLResV1{
-			Number:      taskSQL.Number,
-			ExecSQL:     taskSQL.ExecSQL,
-			AuditResult: taskSQL.AuditResult,
-			AuditLevel:  taskSQL.AuditLevel,
-			AuditStatus: taskSQL.AuditStatus,
-			ExecResult:  taskSQL.ExecResult,
-			ExecStatus:  taskSQL.ExecStatus,
-			RollbackSQL: taskSQL.RollbackSQL.String,
+			Number:       taskSQL.Number,
+			ExecSQL:      taskSQL.ExecSQL,
+			AuditResult:  taskSQL.AuditResult,
+			AuditResults: convertAuditResultsToRes(taskSQL.AuditResults),
+			AuditLevel:   taskSQL.AuditLevel,
+			AuditStatus:  taskSQL.AuditStatus,
+			ExecResult:   taskSQL.ExecResult,
+			ExecStatus:   taskSQL.ExecStatus,
+			ExecProgress: taskSQL.ExecProgress.String,
+			RollbackSQL:  taskSQL.RollbackSQL.String,
+
+			RollbackSQLId:         uint(taskSQL.RollbackSQLId.Int64),
+			RollbackSQLExecStatus: taskSQL.RollbackSQLExecStatus.String,
 		}
 		taskSQLsRes = append(taskSQLsRes, taskSQLRes)
 	}
@@ -383,11 +454,12 @@ func DownloadTaskSQLReportFile(c echo.Context) error {
 	buff := &bytes.Buffer{}
 	buff.WriteString("\xEF\xBB\xBF") // 写入UTF-8 BOM
 	cw := csv.NewWriter(buff)
-	cw.Write([]string{"序号", "SQL", "SQL审核状态", "SQL审核结果", "SQL执行状态", "SQL执行结果", "SQL对应的回滚语句"})
+	cw.Write([]string{"序号", "SQL", "SQL审核状态", "SQL审核结果", "SQL触发规则", "SQL执行状态", "SQL执行结果", "SQL对应的回滚语句"})
 	for _, td := range taskSQLsDetail {
 		taskSql := &model.ExecuteSQL{
-			AuditResult: td.AuditResult,
-			AuditStatus: td.AuditStatus,
+			AuditResult:  td.AuditResult,
+			AuditResults: td.AuditResults,
+			AuditStatus:  td.AuditStatus,
 		}
 		taskSql.ExecStatus = td.ExecStatus
 		cw.Write([]string{
@@ -395,6 +467,7 @@ func DownloadTaskSQLReportFile(c echo.Context) error {
 			td.ExecSQL,
 			taskSql.GetAuditStatusDesc(),
 			taskSql.GetAuditResultDesc(),
+			taskSql.GetAuditRuleNamesDesc(),
 			taskSql.GetExecStatusDesc(),
 			td.ExecResult,
 			td.RollbackSQL.String,
@@ -484,3 +557,149 @@ func GetAuditTaskSQLContent(c echo.Context) error {
 		},
 	})
 }
+
+type GetTaskQueueResV1 struct {
+	controller.BaseRes
+	Data *TaskQueueResV1 `json:"data"`
+}
+
+type TaskQueueResV1 struct {
+	PendingAuditNum   int                       `json:"pending_audit_num"`
+	RunningAuditNum   int                       `json:"running_audit_num"`
+	PendingExecuteNum int                       `json:"pending_execute_num"`
+	RunningExecuteNum int                       `json:"running_execute_num"`
+	MaxWorkers        int                       `json:"max_workers"`
+	MaxAuditWorkers   int                       `json:"max_audit_workers"`
+	Instances         []*InstanceTaskQueueResV1 `json:"instances"`
+}
+
+type InstanceTaskQueueResV1 struct {
+	InstanceName string `json:"instance_name"`
+	PendingNum   int    `json:"pending_num"`
+	RunningNum   int    `json:"running_num"`
+}
+
+// @Summary 获取任务队列状态
+// @Description get the number of pending and running task actions
+// @Tags task
+// @Id getTaskQueueV1
+// @Security ApiKeyAuth
+// @Success 200 {object} v1.GetTaskQueueResV1
+// @router /v1/tasks/queue [get]
+func GetTaskQueue(c echo.Context) error {
+	status := server.GetSqled().QueueStatus()
+	data := &TaskQueueResV1{
+		PendingAuditNum:   status.PendingAuditNum,
+		RunningAuditNum:   status.RunningAuditNum,
+		PendingExecuteNum: status.PendingExecuteNum,
+		RunningExecuteNum: status.RunningExecuteNum,
+		MaxWorkers:        status.MaxWorkers,
+		MaxAuditWorkers:   status.MaxAuditWorkers,
+		Instances:         make([]*InstanceTaskQueueResV1, 0, len(status.Instances)),
+	}
+	for _, inst := range status.Instances {
+		data.Instances = append(data.Instances, &InstanceTaskQueueResV1{
+			InstanceName: inst.InstanceName,
+			PendingNum:   inst.PendingNum,
+			RunningNum:   inst.RunningNum,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetTaskQueueResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+// @Summary 取消Sql审核任务的执行
+// @Description cancel the running or waiting action of task, the executing SQL will be killed; only the creator of task and admin can cancel it
+// @Tags task
+// @Id cancelAuditTaskV1
+// @Security ApiKeyAuth
+// @Param task_id path string true "task id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/tasks/audits/{task_id}/cancel [post]
+func CancelTask(c echo.Context) error {
+	s := model.GetStorage()
+	taskId := c.Param("task_id")
+	task, exist, err := s.GetTaskById(taskId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, TaskNoAccessError)
+	}
+	err = checkCurrentUserCanAccessTask(c, task)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	user, err := controller.GetCurrentUser(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !(user.ID == task.CreateUserId || user.Name == model.DefaultAdminUser) {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataNotExist,
+			fmt.Errorf("you are not allow to operate the task")))
+	}
+	return controller.JSONBaseErrorReq(c, server.GetSqled().CancelTask(taskId))
+}
+
+type GetRollbackSQLEditRecordsResV1 struct {
+	controller.BaseRes
+	Data []*RollbackSQLEditRecordResV1 `json:"data"`
+}
+
+type RollbackSQLEditRecordResV1 struct {
+	RollbackSQLId uint      `json:"rollback_sql_id"`
+	OriginSQL     string    `json:"origin_sql"`
+	SQL           string    `json:"sql"`
+	Reason        string    `json:"reason"`
+	UserName      string    `json:"user_name"`
+	EditTime      time.Time `json:"edit_time"`
+}
+
+// @Summary 获取Sql审核任务回滚SQL的修改记录
+// @Description get the edit records of rollback SQLs of task
+// @Tags task
+// @Id getRollbackSQLEditRecordsV1
+// @Security ApiKeyAuth
+// @Param task_id path string true "task id"
+// @Success 200 {object} v1.GetRollbackSQLEditRecordsResV1
+// @router /v1/tasks/audits/{task_id}/rollback_sql_edit_records [get]
+func GetRollbackSQLEditRecords(c echo.Context) error {
+	s := model.GetStorage()
+	taskId := c.Param("task_id")
+	task, exist, err := s.GetTaskById(taskId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, TaskNoAccessError)
+	}
+	err = checkCurrentUserCanAccessTask(c, task)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	records, err := s.GetRollbackSQLEditRecordsByTaskId(taskId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*RollbackSQLEditRecordResV1, 0, len(records))
+	for _, record := range records {
+		recordRes := &RollbackSQLEditRecordResV1{
+			RollbackSQLId: record.RollbackSQLId,
+			OriginSQL:     record.OriginContent,
+			SQL:           record.Content,
+			Reason:        record.Reason,
+			EditTime:      record.CreatedAt,
+		}
+		if record.User != nil {
+			recordRes.UserName = record.User.Name
+		}
+		data = append(data, recordRes)
+	}
+	return c.JSON(http.StatusOK, &GetRollbackSQLEditRecordsResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
@@ -0,0 +1,18 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditTaskResV1_progress(t *testing.T) {
+	// the progress fields always appear together, even if the task has no SQL.
+	b, err := json.Marshal(&AuditTaskResV1{})
+	assert.NoError(t, err)
+	res := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(b, &res))
+	assert.Contains(t, res, "audited_sql_count")
+	assert.Contains(t, res, "total_sql_count")
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/driver"
 	"github.com/actiontech/sqle/sqle/errors"
 	"github.com/actiontech/sqle/sqle/log"
 	"github.com/actiontech/sqle/sqle/misc"
@@ -22,6 +23,11 @@ var WorkflowNoAccessError = errors.New(errors.DataNotExist, fmt.Errorf("worrkflo
 var ForbidMyBatisXMLTaskError = errors.New(errors.DataConflict,
 	fmt.Errorf("the task for audit mybatis xml file is not allow to create workflow"))
 
+// ForbidNotAuditedTaskError is returned if the task which is audited async is used
+// before the audit is finished.
+var ForbidNotAuditedTaskError = errors.New(errors.DataConflict,
+	fmt.Errorf("the task is not audited, it's not allow to create workflow"))
+
 type GetWorkflowTemplateResV1 struct {
 	controller.BaseRes
 	Data *WorkflowTemplateDetailResV1 `json:"data"`
@@ -493,6 +499,10 @@ func CreateWorkflow(c echo.Context) error {
 		return controller.JSONBaseErrorReq(c, ForbidMyBatisXMLTaskError)
 	}
 
+	if task.Status != model.TaskStatusAudited {
+		return controller.JSONBaseErrorReq(c, ForbidNotAuditedTaskError)
+	}
+
 	_, exist, err = s.GetWorkflowRecordByTaskId(req.TaskId)
 	if err != nil {
 		return controller.JSONBaseErrorReq(c, err)
@@ -552,6 +562,9 @@ type WorkflowRecordResV1 struct {
 	TaskId            uint                 `json:"task_id"`
 	CurrentStepNumber uint                 `json:"current_step_number,omitempty"`
 	Status            string               `json:"status" enums:"on_process,finished,rejected,canceled"`
+	ScheduleTime      *time.Time           `json:"schedule_time,omitempty"`
+	ScheduleError     string               `json:"schedule_error,omitempty"`
+	ScheduleRetryTime *time.Time           `json:"schedule_retry_time,omitempty"`
 	Steps             []*WorkflowStepResV1 `json:"workflow_step_list,omitempty"`
 }
 
@@ -563,7 +576,7 @@ type WorkflowStepResV1 struct {
 	Users         []string   `json:"assignee_user_name_list,omitempty"`
 	OperationUser string     `json:"operation_user_name,omitempty"`
 	OperationTime *time.Time `json:"operation_time,omitempty"`
-	State         string     `json:"state,omitempty" enums:"initialized,approved,rejected"`
+	State         string     `json:"state,omitempty" enums:"initialized,approved,rejected,resumed,retried,rollbacked"`
 	Reason        string     `json:"reason,omitempty"`
 }
 
@@ -647,9 +660,12 @@ func convertWorkflowRecordToRes(workflow *model.Workflow,
 		step.Number = number
 	}
 	return &WorkflowRecordResV1{
-		TaskId: record.TaskId,
-		Status: record.Status,
-		Steps:  steps,
+		TaskId:            record.TaskId,
+		Status:            record.Status,
+		ScheduleTime:      record.ScheduledAt,
+		ScheduleError:     record.ScheduleError,
+		ScheduleRetryTime: record.ScheduleRetryAt,
+		Steps:             steps,
 	}
 }
 
@@ -722,7 +738,7 @@ type GetWorkflowsReqV1 struct {
 	FilterCurrentStepType             string `json:"filter_current_step_type" query:"filter_current_step_type" valid:"omitempty,oneof=sql_review sql_execute"`
 	FilterStatus                      string `json:"filter_status" query:"filter_status" valid:"omitempty,oneof=on_process finished rejected canceled"`
 	FilterCurrentStepAssigneeUserName string `json:"filter_current_step_assignee_user_name" query:"filter_current_step_assignee_user_name"`
-	FilterTaskStatus                  string `json:"filter_task_status" query:"filter_task_status" valid:"omitempty,oneof=initialized audited executing exec_succeeded exec_failed"`
+	FilterTaskStatus                  string `json:"filter_task_status" query:"filter_task_status" valid:"omitempty,oneof=initialized audited executing exec_succeeded exec_failed exec_interrupted exec_cancelled"`
 	FilterTaskInstanceName            string `json:"filter_task_instance_name" query:"filter_task_instance_name"`
 	PageIndex                         uint32 `json:"page_index" query:"page_index" valid:"required"`
 	PageSize                          uint32 `json:"page_size" query:"page_size" valid:"required"`
@@ -738,7 +754,7 @@ type WorkflowDetailResV1 struct {
 	Id                      uint       `json:"workflow_id"`
 	Subject                 string     `json:"subject"`
 	Desc                    string     `json:"desc"`
-	TaskStatus              string     `json:"task_status" enums:"initialized,audited,executing,exec_succeeded,exec_failed"`
+	TaskStatus              string     `json:"task_status" enums:"initialized,audited,executing,exec_succeeded,exec_failed,exec_interrupted,exec_cancelled"`
 	TaskPassRate            float64    `json:"task_pass_rate"`
 	TaskInstance            string     `json:"task_instance_name"`
 	TaskInstanceSchema      string     `json:"task_instance_schema"`
@@ -761,7 +777,7 @@ type WorkflowDetailResV1 struct {
 // @Param filter_current_step_type query string false "filter current step type" Enums(sql_review, sql_execute)
 // @Param filter_status query string false "filter workflow status" Enums(on_process, finished, rejected, canceled)
 // @Param filter_current_step_assignee_user_name query string false "filter current step assignee user name"
-// @Param filter_task_status query string false "filter task status" Enums(initialized, audited, executing, exec_succeeded, exec_failed)
+// @Param filter_task_status query string false "filter task status" Enums(initialized, audited, executing, exec_succeeded, exec_failed, exec_interrupted, exec_cancelled)
 // @Param filter_task_instance_name query string false "filter instance name"
 // @Param page_index query uint32 false "page index"
 // @Param page_size query uint32 false "size of per page"
@@ -891,6 +907,20 @@ func ApproveWorkflow(c echo.Context) error {
 			fmt.Errorf("you are not allow to operate the workflow")))
 	}
 
+	// the task is executed at once after approving if it's not scheduled,
+	// so it's not allowed out of the maintenance periods of instance.
+	if workflow.CurrentStep().Template.Typ == model.WorkflowStepTypeSQLExecute && workflow.Record.ScheduledAt == nil {
+		task, exist, err := s.GetTaskById(fmt.Sprintf("%d", workflow.Record.TaskId))
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		if exist && task.Instance != nil {
+			if err := checkInstanceInMaintenancePeriods(task.Instance, time.Now()); err != nil {
+				return controller.JSONBaseErrorReq(c, err)
+			}
+		}
+	}
+
 	currentStep := workflow.CurrentStep()
 	currentStep.State = model.WorkflowStepStateApprove
 	now := time.Now()
@@ -914,7 +944,8 @@ func ApproveWorkflow(c echo.Context) error {
 		log.Logger().Errorf("after approve workflow, send email error: %v", err)
 	}
 
-	if currentStep.Template.Typ == model.WorkflowStepTypeSQLExecute {
+	// the scheduled task is executed by workflow scheduler.
+	if currentStep.Template.Typ == model.WorkflowStepTypeSQLExecute && workflow.Record.ScheduledAt == nil {
 		taskId := fmt.Sprintf("%d", workflow.Record.TaskId)
 		task, exist, err := s.GetTaskDetailById(taskId)
 		if err != nil {
@@ -1136,6 +1167,400 @@ func checkCancelWorkflow(id string) (*model.Workflow, error) {
 	return workflow, nil
 }
 
+// @Summary 取消工单的SQL上线
+// @Description cancel the running SQL execution of workflow, the executing SQL will be killed
+// @Tags workflow
+// @Id cancelWorkflowTaskV1
+// @Security ApiKeyAuth
+// @Param workflow_id path string true "workflow id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/workflows/{workflow_id}/task/cancel [post]
+func CancelWorkflowTask(c echo.Context) error {
+	workflowId := c.Param("workflow_id")
+	id, err := FormatStringToInt(workflowId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	err = checkCurrentUserCanAccessWorkflow(c, &model.Workflow{
+		Model: model.Model{ID: uint(id)},
+	})
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	workflow, exist, err := model.GetStorage().GetWorkflowDetailById(workflowId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, WorkflowNoAccessError)
+	}
+
+	user, err := controller.GetCurrentUser(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !(user.ID == workflow.CreateUserId || user.Name == model.DefaultAdminUser) {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataNotExist,
+			fmt.Errorf("you are not allow to operate the workflow")))
+	}
+
+	taskId := fmt.Sprintf("%d", workflow.Record.TaskId)
+	return controller.JSONBaseErrorReq(c, server.GetSqled().CancelTask(taskId))
+}
+
+type ScheduleWorkflowReqV1 struct {
+	ScheduleTime *time.Time `json:"schedule_time"`
+}
+
+// @Summary 设置工单定时上线
+// @Description schedule the SQL execution of workflow, the task is executed at the schedule time after the workflow is approved; cancel the schedule if schedule time is empty
+// @Tags workflow
+// @Id scheduleWorkflowV1
+// @Security ApiKeyAuth
+// @Param workflow_id path string true "workflow id"
+// @param instance body v1.ScheduleWorkflowReqV1 true "schedule workflow request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/workflows/{workflow_id}/schedule [put]
+func ScheduleWorkflow(c echo.Context) error {
+	req := new(ScheduleWorkflowReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	workflowId := c.Param("workflow_id")
+	id, err := FormatStringToInt(workflowId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	err = checkCurrentUserCanAccessWorkflow(c, &model.Workflow{
+		Model: model.Model{ID: uint(id)},
+	})
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	s := model.GetStorage()
+	workflow, exist, err := s.GetWorkflowDetailById(workflowId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, WorkflowNoAccessError)
+	}
+	if workflow.Record.Status != model.WorkflowStatusRunning {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+			fmt.Errorf("workflow status is %s, not allow operate it", workflow.Record.Status)))
+	}
+	currentStep := workflow.CurrentStep()
+	if currentStep == nil {
+		return controller.JSONBaseErrorReq(c, errors.New(
+			errors.DataInvalid, fmt.Errorf("workflow current step not found")))
+	}
+	if currentStep.Template.Typ != model.WorkflowStepTypeSQLExecute {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+			fmt.Errorf("workflow current step is not sql execute step")))
+	}
+
+	user, err := controller.GetCurrentUser(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !workflow.IsOperationUser(user) {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataNotExist,
+			fmt.Errorf("you are not allow to operate the workflow")))
+	}
+
+	if req.ScheduleTime != nil {
+		if req.ScheduleTime.Before(time.Now()) {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+				fmt.Errorf("schedule time must be after now")))
+		}
+		task, exist, err := s.GetTaskById(fmt.Sprintf("%d", workflow.Record.TaskId))
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		if !exist {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataNotExist, fmt.Errorf("task is not exist")))
+		}
+		if task.Instance == nil {
+			return controller.JSONBaseErrorReq(c, instanceNotExistError)
+		}
+		if err := checkInstanceInMaintenancePeriods(task.Instance, *req.ScheduleTime); err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+	}
+
+	return controller.JSONBaseErrorReq(c, s.UpdateWorkflowSchedule(workflow, user.ID, req.ScheduleTime))
+}
+
+func checkInstanceInMaintenancePeriods(instance *model.Instance, t time.Time) error {
+	if !instance.MaintenancePeriods.IsWithinScope(t) {
+		return errors.New(errors.DataInvalid, fmt.Errorf("%s is out of the maintenance periods %s of instance %s",
+			t.Format("2006-01-02 15:04:05"), instance.MaintenancePeriods, instance.Name))
+	}
+	return nil
+}
+
+// checkFinishedWorkflowTaskOperation checks whether the operation of stepState is
+// allowed on the task of finished workflow at t. The resume and retry must be in the
+// maintenance periods of instance, but the rollback is allowed at any time, so the
+// bad execution can be rollbacked in emergency.
+func checkFinishedWorkflowTaskOperation(instance *model.Instance, stepState string, t time.Time) error {
+	if stepState == model.WorkflowStepStateRollback {
+		return nil
+	}
+	return checkInstanceInMaintenancePeriods(instance, t)
+}
+
+type ReExecuteWorkflowTaskReqV1 struct {
+	Reason string `json:"reason" form:"reason" valid:"required"`
+}
+
+// @Summary 从第一条未执行的SQL继续上线
+// @Description resume the failed SQL execution of workflow, the failed SQLs are skipped
+// @Tags workflow
+// @Id resumeWorkflowTaskV1
+// @Security ApiKeyAuth
+// @Param workflow_id path string true "workflow id"
+// @param instance body v1.ReExecuteWorkflowTaskReqV1 true "resume workflow task request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/workflows/{workflow_id}/task/resume [post]
+func ResumeWorkflowTask(c echo.Context) error {
+	return reExecuteWorkflowTask(c, server.ActionTypeResumeExecute, model.WorkflowStepStateResume)
+}
+
+// @Summary 重新执行失败的SQL并继续上线
+// @Description retry the failed or cancelled SQLs and execute the SQLs which have not been executed, the interrupted SQLs are skipped
+// @Tags workflow
+// @Id retryWorkflowTaskV1
+// @Security ApiKeyAuth
+// @Param workflow_id path string true "workflow id"
+// @param instance body v1.ReExecuteWorkflowTaskReqV1 true "retry workflow task request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/workflows/{workflow_id}/task/retry [post]
+func RetryWorkflowTask(c echo.Context) error {
+	return reExecuteWorkflowTask(c, server.ActionTypeRetryExecute, model.WorkflowStepStateRetry)
+}
+
+// reExecuteWorkflowTask resumes or retries the failed execution of the finished workflow,
+// and appends a step to the workflow record to record who does it and why.
+func reExecuteWorkflowTask(c echo.Context, actionType int, stepState string) error {
+	req := new(ReExecuteWorkflowTaskReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	return operateFinishedWorkflowTask(c, stepState, req.Reason, func(taskId string) error {
+		return server.GetSqled().AddTask(taskId, actionType)
+	})
+}
+
+type RollbackWorkflowTaskReqV1 struct {
+	Reason         string `json:"reason" form:"reason" valid:"required"`
+	RollbackSQLIds []uint `json:"rollback_sql_ids" form:"rollback_sql_ids"`
+}
+
+// @Summary 回滚工单的SQL上线
+// @Description rollback the SQL execution of workflow, only the chosen rollback SQLs are executed if rollback_sql_ids is not empty
+// @Tags workflow
+// @Id rollbackWorkflowTaskV1
+// @Security ApiKeyAuth
+// @Param workflow_id path string true "workflow id"
+// @param instance body v1.RollbackWorkflowTaskReqV1 true "rollback workflow task request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/workflows/{workflow_id}/task/rollback [post]
+func RollbackWorkflowTask(c echo.Context) error {
+	req := new(RollbackWorkflowTaskReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	return operateFinishedWorkflowTask(c, model.WorkflowStepStateRollback, req.Reason, func(taskId string) error {
+		return server.GetSqled().AddRollbackTask(taskId, req.RollbackSQLIds)
+	})
+}
+
+type SendWorkflowTaskCommandReqV1 struct {
+	Command string `json:"command" form:"command" example:"throttle" enums:"throttle,unthrottle,postpone_cut_over,cut_over,abort" valid:"required,oneof=throttle unthrottle postpone_cut_over cut_over abort"`
+}
+
+// @Summary 控制工单正在上线的SQL
+// @Description send command to the executing SQL of workflow, e.g. throttle/unthrottle the online DDL, postpone the cut-over until it's confirmed by cut_over, or abort it
+// @Tags workflow
+// @Id sendWorkflowTaskCommandV1
+// @Security ApiKeyAuth
+// @Param workflow_id path string true "workflow id"
+// @param instance body v1.SendWorkflowTaskCommandReqV1 true "send command to workflow task request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/workflows/{workflow_id}/task/command [post]
+func SendWorkflowTaskCommand(c echo.Context) error {
+	req := new(SendWorkflowTaskCommandReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	workflow, user, err := getWorkflowForExecuteUser(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	taskId := fmt.Sprintf("%d", workflow.Record.TaskId)
+	err = server.GetSqled().SendExecCommand(taskId, driver.ExecCommand(req.Command))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	log.NewEntry().WithField("task_id", taskId).Infof("user %s sent command %s", user.Name, req.Command)
+	return controller.JSONBaseErrorReq(c, nil)
+}
+
+// getWorkflowForExecuteUser returns the workflow which has sql execute step, and the
+// current user must be one of the execute users.
+func getWorkflowForExecuteUser(c echo.Context) (*model.Workflow, *model.User, error) {
+	workflowId := c.Param("workflow_id")
+	id, err := FormatStringToInt(workflowId)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = checkCurrentUserCanAccessWorkflow(c, &model.Workflow{
+		Model: model.Model{ID: uint(id)},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workflow, exist, err := model.GetStorage().GetWorkflowDetailById(workflowId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exist {
+		return nil, nil, WorkflowNoAccessError
+	}
+	finalStep := workflow.FinalStep()
+	if finalStep.Template.Typ != model.WorkflowStepTypeSQLExecute {
+		return nil, nil, errors.New(errors.DataInvalid, fmt.Errorf("workflow has no sql execute step"))
+	}
+
+	user, err := controller.GetCurrentUser(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	isExecuteUser := user.Name == model.DefaultAdminUser
+	for _, assUser := range finalStep.Template.Users {
+		if user.ID == assUser.ID {
+			isExecuteUser = true
+		}
+	}
+	if !isExecuteUser {
+		return nil, nil, errors.New(errors.DataNotExist, fmt.Errorf("you are not allow to operate the workflow"))
+	}
+	return workflow, user, nil
+}
+
+// operateFinishedWorkflowTask adds the action by addTask on the task of the finished
+// workflow, and appends a step to the workflow record to record who does it and why.
+func operateFinishedWorkflowTask(c echo.Context, stepState, reason string, addTask func(taskId string) error) error {
+	workflow, user, err := getWorkflowForExecuteUser(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if workflow.Record.Status != model.WorkflowStatusFinish {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+			fmt.Errorf("workflow status is %s, not allow operate it", workflow.Record.Status)))
+	}
+
+	s := model.GetStorage()
+	taskId := fmt.Sprintf("%d", workflow.Record.TaskId)
+	task, exist, err := s.GetTaskById(taskId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataNotExist, fmt.Errorf("task is not exist")))
+	}
+	if task.Instance == nil {
+		return controller.JSONBaseErrorReq(c, instanceNotExistError)
+	}
+	if err := checkFinishedWorkflowTaskOperation(task.Instance, stepState, time.Now()); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	// if instance is not connectable, exec sql must be failed again.
+	d, err := newDriverWithoutAudit(log.NewEntry(), task.Instance, "")
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	defer d.Close(context.TODO())
+	if err := d.Ping(context.TODO()); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	if err := addTask(taskId); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	now := time.Now()
+	step := &model.WorkflowStep{
+		OperationUserId:        user.ID,
+		OperateAt:              &now,
+		WorkflowId:             workflow.ID,
+		WorkflowRecordId:       workflow.Record.ID,
+		WorkflowStepTemplateId: workflow.FinalStep().WorkflowStepTemplateId,
+		State:                  stepState,
+		Reason:                 reason,
+	}
+	return controller.JSONBaseErrorReq(c, s.Save(step))
+}
+
+type EditWorkflowRollbackSQLReqV1 struct {
+	SQL    string `json:"sql" form:"sql" valid:"required"`
+	Reason string `json:"reason" form:"reason" valid:"required"`
+}
+
+// @Summary 修改工单的回滚SQL
+// @Description edit the rollback SQL generated by sqle before it is executed, the edit is recorded
+// @Tags workflow
+// @Id editWorkflowRollbackSQLV1
+// @Security ApiKeyAuth
+// @Param workflow_id path string true "workflow id"
+// @Param rollback_sql_id path string true "rollback sql id"
+// @param instance body v1.EditWorkflowRollbackSQLReqV1 true "edit rollback sql request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/workflows/{workflow_id}/task/rollback_sqls/{rollback_sql_id}/ [patch]
+func EditWorkflowRollbackSQL(c echo.Context) error {
+	req := new(EditWorkflowRollbackSQLReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	workflow, user, err := getWorkflowForExecuteUser(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	s := model.GetStorage()
+	taskId := fmt.Sprintf("%d", workflow.Record.TaskId)
+	rollbackSQL, exist, err := s.GetRollbackSQLById(taskId, c.Param("rollback_sql_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataNotExist, fmt.Errorf("rollback sql is not exist")))
+	}
+	if rollbackSQL.Content == req.SQL {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("rollback sql is not changed")))
+	}
+	// the running action has loaded the rollback SQL, the edit will not take effect.
+	if server.GetSqled().HasTask(taskId) {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.TaskRunning, fmt.Errorf("task is running")))
+	}
+
+	edited, err := s.EditRollbackSQL(rollbackSQL, req.SQL, user.ID, req.Reason)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !edited {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+			fmt.Errorf("rollback sql has been executed, not allow edit it")))
+	}
+	return controller.JSONBaseErrorReq(c, nil)
+}
+
 type UpdateWorkflowReqV1 struct {
 	TaskId string `json:"task_id" form:"task_id" valid:"required"`
 }
@@ -1199,6 +1624,10 @@ func UpdateWorkflow(c echo.Context) error {
 		return controller.JSONBaseErrorReq(c, ForbidMyBatisXMLTaskError)
 	}
 
+	if task.Status != model.TaskStatusAudited {
+		return controller.JSONBaseErrorReq(c, ForbidNotAuditedTaskError)
+	}
+
 	_, exist, err = s.GetWorkflowRecordByTaskId(req.TaskId)
 	if err != nil {
 		return controller.JSONBaseErrorReq(c, err)
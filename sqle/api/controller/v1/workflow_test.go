@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkFinishedWorkflowTaskOperation(t *testing.T) {
+	instance := &model.Instance{
+		Name: "inst",
+		MaintenancePeriods: model.Periods{
+			{StartHour: 1, StartMinute: 0, EndHour: 3, EndMinute: 0},
+		},
+	}
+	inPeriod := time.Date(2021, 1, 1, 2, 0, 0, 0, time.Local)
+	outOfPeriod := time.Date(2021, 1, 1, 12, 0, 0, 0, time.Local)
+
+	for _, stepState := range []string{model.WorkflowStepStateResume, model.WorkflowStepStateRetry} {
+		assert.NoError(t, checkFinishedWorkflowTaskOperation(instance, stepState, inPeriod))
+		assert.Error(t, checkFinishedWorkflowTaskOperation(instance, stepState, outOfPeriod))
+	}
+
+	// the rollback is allowed out of the maintenance periods.
+	assert.NoError(t, checkFinishedWorkflowTaskOperation(instance, model.WorkflowStepStateRollback, inPeriod))
+	assert.NoError(t, checkFinishedWorkflowTaskOperation(instance, model.WorkflowStepStateRollback, outOfPeriod))
+}
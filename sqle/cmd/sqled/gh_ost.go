@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/onlineddl"
+
+	"github.com/spf13/cobra"
+)
+
+// ghostCmd runs the migration of gh-ost for sqled, it's started by sqled as subprocess.
+func ghostCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    onlineddl.GhostProcessCommand,
+		Short:  "run the online DDL migration of gh-ost for sqled",
+		Hidden: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := onlineddl.RunGhostProcess(os.Stdin, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+}
@@ -30,6 +30,10 @@ var httpsEnable bool
 var certFilePath string
 var keyFilePath string
 var pluginPath string
+var maxTaskWorkers int
+var maxAuditTaskWorkers int
+var maxExecTasksPerInstance int
+var pluginProcesses int
 
 func main() {
 	var rootCmd = &cobra.Command{
@@ -57,8 +61,13 @@ func main() {
 	rootCmd.Flags().StringVarP(&certFilePath, "cert-file-path", "", "", "https cert file path")
 	rootCmd.Flags().StringVarP(&keyFilePath, "key-file-path", "", "", "https key file path")
 	rootCmd.Flags().StringVarP(&pluginPath, "plugin-path", "", "", "plugin path")
+	rootCmd.Flags().IntVarP(&maxTaskWorkers, "max-task-workers", "", 32, "max number of task actions running at the same time")
+	rootCmd.Flags().IntVarP(&maxAuditTaskWorkers, "max-audit-task-workers", "", 16, "max number of audit task actions running at the same time")
+	rootCmd.Flags().IntVarP(&maxExecTasksPerInstance, "max-exec-tasks-per-instance", "", 1, "max number of execute and rollback task actions running on one instance at the same time")
+	rootCmd.Flags().IntVarP(&pluginProcesses, "plugin-processes", "", 1, "number of long-lived processes of each plugin")
 
 	rootCmd.AddCommand(genSecretPasswordCmd())
+	rootCmd.AddCommand(ghostCmd())
 	rootCmd.Execute()
 }
 
@@ -103,6 +112,11 @@ func run(cmd *cobra.Command, _ []string) error {
 					CertFilePath:     certFilePath,
 					KeyFilePath:      keyFilePath,
 					PluginPath:       pluginPath,
+
+					MaxTaskWorkers:          maxTaskWorkers,
+					MaxAuditTaskWorkers:     maxAuditTaskWorkers,
+					MaxExecTasksPerInstance: maxExecTasksPerInstance,
+					PluginProcesses:         pluginProcesses,
 				},
 				DBCnf: config.DatabaseConfig{
 					MysqlCnf: config.MysqlConfig{
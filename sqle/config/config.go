@@ -18,6 +18,18 @@ type SqleConfig struct {
 	DebugLog         bool   `yaml:"debug_log"`
 	LogPath          string `yaml:"log_path"`
 	PluginPath       string `yaml:"plugin_path"`
+
+	// MaxTaskWorkers limits the number of task actions which run at the same time.
+	MaxTaskWorkers int `yaml:"max_task_workers"`
+	// MaxAuditTaskWorkers limits the number of audit actions which run at the same time,
+	// it's a part of MaxTaskWorkers.
+	MaxAuditTaskWorkers int `yaml:"max_audit_task_workers"`
+	// MaxExecTasksPerInstance limits the number of execute and rollback actions which
+	// run on the same instance at the same time, 1 means they are serialized.
+	MaxExecTasksPerInstance int `yaml:"max_exec_tasks_per_instance"`
+	// PluginProcesses is the number of long-lived processes of each plugin, the
+	// drivers of plugin are opened as sessions on them.
+	PluginProcesses int `yaml:"plugin_processes"`
 }
 
 type DatabaseConfig struct {
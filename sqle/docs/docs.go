@@ -610,7 +610,7 @@ var doc = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "get drivers",
+                "description": "get drivers, with the version and capabilities of each driver",
                 "tags": [
                     "configuration"
                 ],
@@ -626,6 +626,29 @@ var doc = `{
                 }
             }
         },
+        "/v1/configurations/drivers/reload": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "reload driver plugins, load new plugins, upgrade changed plugins and unload removed plugins",
+                "tags": [
+                    "configuration"
+                ],
+                "summary": "重新加载插件",
+                "operationId": "reloadDriversV1",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReloadDriversResV1"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/configurations/ldap": {
             "get": {
                 "security": [
@@ -1147,6 +1170,77 @@ var doc = `{
                 }
             }
         },
+        "/v1/instances/{instance_name}/recycle_bin_objects": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the dropped objects in recycle bin of instance",
+                "tags": [
+                    "instance"
+                ],
+                "summary": "获取实例回收站中的对象列表",
+                "operationId": "getRecycleBinObjectsV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "instance name",
+                        "name": "instance_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetRecycleBinObjectsResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/instances/{instance_name}/recycle_bin_objects/{object_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "restore the dropped object from recycle bin of instance",
+                "tags": [
+                    "instance"
+                ],
+                "summary": "从实例回收站恢复对象",
+                "operationId": "restoreRecycleBinObjectV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "instance name",
+                        "name": "instance_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "recycle bin object id",
+                        "name": "object_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/instances/{instance_name}/rules": {
             "get": {
                 "security": [
@@ -1737,7 +1831,7 @@ var doc = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "create and audit a task, you can upload sql content in three ways, any one can be used, but only one is effective.\n1. formData[sql]: sql content;\n2. file[input_sql_file]: it is a sql file;\n3. file[input_mybatis_xml_file]: it is mybatis xml file, sql will be parsed from it.",
+                "description": "create and audit a task, you can upload sql content in three ways, any one can be used, but only one is effective.\n1. formData[sql]: sql content;\n2. file[input_sql_file]: it is a sql file;\n3. file[input_mybatis_xml_file]: it is mybatis xml file, sql will be parsed from it.\nThe task is returned after it's audited, or returned immediately if async is true, then the audit progress can be got by getAuditTaskV1.",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -1780,6 +1874,18 @@ var doc = `{
                         "description": "input mybatis XML file",
                         "name": "input_mybatis_xml_file",
                         "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "return without waiting for the audit",
+                        "name": "async",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "send email to the creator when the async audit is finished",
+                        "name": "notify",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -1824,6 +1930,70 @@ var doc = `{
                 }
             }
         },
+        "/v1/tasks/audits/{task_id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "cancel the running or waiting action of task, the executing SQL will be killed; only the creator of task and admin can cancel it",
+                "tags": [
+                    "task"
+                ],
+                "summary": "取消Sql审核任务的执行",
+                "operationId": "cancelAuditTaskV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tasks/audits/{task_id}/rollback_sql_edit_records": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the edit records of rollback SQLs of task",
+                "tags": [
+                    "task"
+                ],
+                "summary": "获取Sql审核任务回滚SQL的修改记录",
+                "operationId": "getRollbackSQLEditRecordsV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetRollbackSQLEditRecordsResV1"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/tasks/audits/{task_id}/sql_content": {
             "get": {
                 "security": [
@@ -1952,7 +2122,9 @@ var doc = `{
                             "initialized",
                             "doing",
                             "succeeded",
-                            "failed"
+                            "failed",
+                            "interrupted",
+                            "cancelled"
                         ],
                         "type": "string",
                         "description": "filter: exec status of task sql",
@@ -1970,6 +2142,12 @@ var doc = `{
                         "name": "filter_audit_status",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "filter: name of the rule which task sql violated",
+                        "name": "filter_audit_rule_name",
+                        "in": "query"
+                    },
                     {
                         "type": "boolean",
                         "description": "select unique (fingerprint and audit result) for task sql",
@@ -1999,6 +2177,29 @@ var doc = `{
                 }
             }
         },
+        "/v1/tasks/queue": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the number of pending and running task actions",
+                "tags": [
+                    "task"
+                ],
+                "summary": "获取任务队列状态",
+                "operationId": "getTaskQueueV1",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetTaskQueueResV1"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/user": {
             "get": {
                 "security": [
@@ -2647,7 +2848,9 @@ var doc = `{
                             "audited",
                             "executing",
                             "exec_succeeded",
-                            "exec_failed"
+                            "exec_failed",
+                            "exec_interrupted",
+                            "exec_cancelled"
                         ],
                         "type": "string",
                         "description": "filter task status",
@@ -2864,6 +3067,47 @@ var doc = `{
                 }
             }
         },
+        "/v1/workflows/{workflow_id}/schedule": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "schedule the SQL execution of workflow, the task is executed at the schedule time after the workflow is approved; cancel the schedule if schedule time is empty",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "设置工单定时上线",
+                "operationId": "scheduleWorkflowV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "schedule workflow request",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ScheduleWorkflowReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/workflows/{workflow_id}/steps/{workflow_step_id}/approve": {
             "post": {
                 "security": [
@@ -2950,43 +3194,293 @@ var doc = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "controller.BaseRes": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 0
-                },
-                "message": {
-                    "type": "string",
-                    "example": "ok"
-                }
-            }
         },
-        "v1.AuditPlanReportResV1": {
-            "type": "object",
-            "properties": {
-                "audit_plan_report_id": {
-                    "type": "string",
-                    "example": "1"
-                },
-                "audit_plan_report_timestamp": {
-                    "type": "string",
-                    "example": "RFC3339"
+        "/v1/workflows/{workflow_id}/task/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "cancel the running SQL execution of workflow, the executing SQL will be killed",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "取消工单的SQL上线",
+                "operationId": "cancelWorkflowTaskV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
                 }
             }
         },
-        "v1.AuditPlanReportSQLResV1": {
-            "type": "object",
-            "properties": {
-                "audit_plan_report_sql_audit_result": {
-                    "type": "string",
-                    "example": "same format as task audit result"
-                },
-                "audit_plan_report_sql_fingerprint": {
+        "/v1/workflows/{workflow_id}/task/command": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "send command to the executing SQL of workflow, e.g. throttle/unthrottle the online DDL, postpone the cut-over until it's confirmed by cut_over, or abort it",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "控制工单正在上线的SQL",
+                "operationId": "sendWorkflowTaskCommandV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "send command to workflow task request",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SendWorkflowTaskCommandReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/workflows/{workflow_id}/task/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "resume the failed SQL execution of workflow, the failed SQLs are skipped",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "从第一条未执行的SQL继续上线",
+                "operationId": "resumeWorkflowTaskV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "resume workflow task request",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReExecuteWorkflowTaskReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/workflows/{workflow_id}/task/retry": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "retry the failed or cancelled SQLs and execute the SQLs which have not been executed, the interrupted SQLs are skipped",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "重新执行失败的SQL并继续上线",
+                "operationId": "retryWorkflowTaskV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "retry workflow task request",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReExecuteWorkflowTaskReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/workflows/{workflow_id}/task/rollback": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "rollback the SQL execution of workflow, only the chosen rollback SQLs are executed if rollback_sql_ids is not empty",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "回滚工单的SQL上线",
+                "operationId": "rollbackWorkflowTaskV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "rollback workflow task request",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RollbackWorkflowTaskReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/workflows/{workflow_id}/task/rollback_sqls/{rollback_sql_id}/": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "edit the rollback SQL generated by sqle before it is executed, the edit is recorded",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "修改工单的回滚SQL",
+                "operationId": "editWorkflowRollbackSQLV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "rollback sql id",
+                        "name": "rollback_sql_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "edit rollback sql request",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.EditWorkflowRollbackSQLReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controller.BaseRes": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "v1.AuditPlanReportResV1": {
+            "type": "object",
+            "properties": {
+                "audit_plan_report_id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "audit_plan_report_timestamp": {
+                    "type": "string",
+                    "example": "RFC3339"
+                }
+            }
+        },
+        "v1.AuditPlanReportSQLResV1": {
+            "type": "object",
+            "properties": {
+                "audit_plan_report_sql_audit_result": {
+                    "type": "string",
+                    "example": "same format as task audit result"
+                },
+                "audit_plan_report_sql_audit_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.AuditResultResV1"
+                    }
+                },
+                "audit_plan_report_sql_fingerprint": {
                     "type": "string",
                     "example": "select * from t1 where id = ?"
                 },
@@ -3071,9 +3565,40 @@ var doc = `{
                 }
             }
         },
+        "v1.AuditResultResV1": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "notice",
+                        "warn",
+                        "error"
+                    ]
+                },
+                "message": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "[osc]"
+                },
+                "rule_name": {
+                    "type": "string"
+                }
+            }
+        },
         "v1.AuditTaskResV1": {
             "type": "object",
             "properties": {
+                "audited_sql_count": {
+                    "description": "AuditedSQLCount and TotalSQLCount are the progress of the audit.",
+                    "type": "integer"
+                },
                 "instance_name": {
                     "type": "string"
                 },
@@ -3097,14 +3622,21 @@ var doc = `{
                     "type": "string",
                     "enum": [
                         "initialized",
+                        "auditing",
+                        "audit_failed",
                         "audited",
                         "executing",
                         "exec_success",
-                        "exec_failed"
+                        "exec_failed",
+                        "exec_interrupted",
+                        "exec_cancelled"
                     ]
                 },
                 "task_id": {
                     "type": "integer"
+                },
+                "total_sql_count": {
+                    "type": "integer"
                 }
             }
         },
@@ -3126,9 +3658,18 @@ var doc = `{
                 "audit_result": {
                     "type": "string"
                 },
+                "audit_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.AuditResultResV1"
+                    }
+                },
                 "audit_status": {
                     "type": "string"
                 },
+                "exec_progress": {
+                    "type": "string"
+                },
                 "exec_result": {
                     "type": "string"
                 },
@@ -3143,6 +3684,12 @@ var doc = `{
                 },
                 "rollback_sql": {
                     "type": "string"
+                },
+                "rollback_sql_exec_status": {
+                    "type": "string"
+                },
+                "rollback_sql_id": {
+                    "type": "integer"
                 }
             }
         },
@@ -3268,6 +3815,13 @@ var doc = `{
                     "type": "string",
                     "example": "test"
                 },
+                "maintenance_period_list": {
+                    "description": "MaintenancePeriods is the time windows in which SQLs are allowed to be executed.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.MaintenancePeriodV1"
+                    }
+                },
                 "role_name_list": {
                     "type": "array",
                     "items": {
@@ -3403,9 +3957,44 @@ var doc = `{
                 }
             }
         },
+        "v1.DriverResV1": {
+            "type": "object",
+            "properties": {
+                "capabilities": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "rollback",
+                            "explain",
+                            "online_ddl",
+                            "offline_audit",
+                            "transaction"
+                        ]
+                    }
+                },
+                "driver_name": {
+                    "type": "string"
+                },
+                "protocol_version": {
+                    "description": "ProtocolVersion is 0 for the driver built in SQLe.",
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "Version is empty for the driver built in SQLe.",
+                    "type": "string"
+                }
+            }
+        },
         "v1.DriversResV1": {
             "type": "object",
             "properties": {
+                "driver_list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.DriverResV1"
+                    }
+                },
                 "driver_name_list": {
                     "type": "array",
                     "items": {
@@ -3414,6 +4003,28 @@ var doc = `{
                 }
             }
         },
+        "v1.EditWorkflowRollbackSQLReqV1": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "sql": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.FailedPluginResV1": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "plugin_path": {
+                    "type": "string"
+                }
+            }
+        },
         "v1.FullSyncAuditPlanSQLsReqV1": {
             "type": "object",
             "properties": {
@@ -3776,6 +4387,25 @@ var doc = `{
                 }
             }
         },
+        "v1.GetRecycleBinObjectsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecycleBinObjectResV1"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetRoleTipsResV1": {
             "type": "object",
             "properties": {
@@ -3817,6 +4447,25 @@ var doc = `{
                 }
             }
         },
+        "v1.GetRollbackSQLEditRecordsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RollbackSQLEditRecordResV1"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetRuleTemplateResV1": {
             "type": "object",
             "properties": {
@@ -3928,6 +4577,23 @@ var doc = `{
                 }
             }
         },
+        "v1.GetTaskQueueResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.TaskQueueResV1"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetUserDetailResV1": {
             "type": "object",
             "properties": {
@@ -4137,6 +4803,12 @@ var doc = `{
                 "instance_name": {
                     "type": "string"
                 },
+                "maintenance_period_list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.MaintenancePeriodV1"
+                    }
+                },
                 "role_name_list": {
                     "type": "array",
                     "items": {
@@ -4165,6 +4837,20 @@ var doc = `{
                 }
             }
         },
+        "v1.InstanceTaskQueueResV1": {
+            "type": "object",
+            "properties": {
+                "instance_name": {
+                    "type": "string"
+                },
+                "pending_num": {
+                    "type": "integer"
+                },
+                "running_num": {
+                    "type": "integer"
+                }
+            }
+        },
         "v1.InstanceTipResV1": {
             "type": "object",
             "properties": {
@@ -4231,6 +4917,27 @@ var doc = `{
                 }
             }
         },
+        "v1.MaintenancePeriodV1": {
+            "type": "object",
+            "properties": {
+                "end_hour": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "end_minute": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "start_hour": {
+                    "type": "integer",
+                    "example": 22
+                },
+                "start_minute": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
         "v1.PartialSyncAuditPlanSQLsReqV1": {
             "type": "object",
             "properties": {
@@ -4242,6 +4949,37 @@ var doc = `{
                 }
             }
         },
+        "v1.ReExecuteWorkflowTaskReqV1": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.RecycleBinObjectResV1": {
+            "type": "object",
+            "properties": {
+                "dropped_at": {
+                    "type": "string"
+                },
+                "expired_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "object": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "v1.RejectWorkflowReqV1": {
             "type": "object",
             "properties": {
@@ -4250,6 +4988,52 @@ var doc = `{
                 }
             }
         },
+        "v1.ReloadDriversResDataV1": {
+            "type": "object",
+            "properties": {
+                "failed_plugin_list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.FailedPluginResV1"
+                    }
+                },
+                "loaded_driver_name_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unloaded_driver_name_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "upgraded_driver_name_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.ReloadDriversResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.ReloadDriversResDataV1"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.RoleResV1": {
             "type": "object",
             "properties": {
@@ -4281,6 +5065,43 @@ var doc = `{
                 }
             }
         },
+        "v1.RollbackSQLEditRecordResV1": {
+            "type": "object",
+            "properties": {
+                "edit_time": {
+                    "type": "string"
+                },
+                "origin_sql": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "rollback_sql_id": {
+                    "type": "integer"
+                },
+                "sql": {
+                    "type": "string"
+                },
+                "user_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.RollbackWorkflowTaskReqV1": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "rollback_sql_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
         "v1.RuleReqV1": {
             "type": "object",
             "properties": {
@@ -4401,6 +5222,30 @@ var doc = `{
                 }
             }
         },
+        "v1.ScheduleWorkflowReqV1": {
+            "type": "object",
+            "properties": {
+                "schedule_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.SendWorkflowTaskCommandReqV1": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string",
+                    "enum": [
+                        "throttle",
+                        "unthrottle",
+                        "postpone_cut_over",
+                        "cut_over",
+                        "abort"
+                    ],
+                    "example": "throttle"
+                }
+            }
+        },
         "v1.SystemVariablesResV1": {
             "type": "object",
             "properties": {
@@ -4409,6 +5254,35 @@ var doc = `{
                 }
             }
         },
+        "v1.TaskQueueResV1": {
+            "type": "object",
+            "properties": {
+                "instances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.InstanceTaskQueueResV1"
+                    }
+                },
+                "max_audit_workers": {
+                    "type": "integer"
+                },
+                "max_workers": {
+                    "type": "integer"
+                },
+                "pending_audit_num": {
+                    "type": "integer"
+                },
+                "pending_execute_num": {
+                    "type": "integer"
+                },
+                "running_audit_num": {
+                    "type": "integer"
+                },
+                "running_execute_num": {
+                    "type": "integer"
+                }
+            }
+        },
         "v1.TriggerAuditPlanResV1": {
             "type": "object",
             "properties": {
@@ -4510,6 +5384,13 @@ var doc = `{
                     "type": "string",
                     "example": "this is a test instance"
                 },
+                "maintenance_period_list": {
+                    "description": "MaintenancePeriods is the time windows in which SQLs are allowed to be executed,\nempty list means no limitation.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.MaintenancePeriodV1"
+                    }
+                },
                 "role_name_list": {
                     "type": "array",
                     "items": {
@@ -4817,7 +5698,9 @@ var doc = `{
                         "audited",
                         "executing",
                         "exec_succeeded",
-                        "exec_failed"
+                        "exec_failed",
+                        "exec_interrupted",
+                        "exec_cancelled"
                     ]
                 },
                 "workflow_id": {
@@ -4831,6 +5714,15 @@ var doc = `{
                 "current_step_number": {
                     "type": "integer"
                 },
+                "schedule_error": {
+                    "type": "string"
+                },
+                "schedule_retry_time": {
+                    "type": "string"
+                },
+                "schedule_time": {
+                    "type": "string"
+                },
                 "status": {
                     "type": "string",
                     "enum": [
@@ -4927,7 +5819,10 @@ var doc = `{
                     "enum": [
                         "initialized",
                         "approved",
-                        "rejected"
+                        "rejected",
+                        "resumed",
+                        "retried",
+                        "rollbacked"
                     ]
                 },
                 "type": {
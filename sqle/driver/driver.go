@@ -4,10 +4,10 @@ import (
 	"context"
 	"database/sql/driver"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -21,6 +21,9 @@ var (
 	// rules store audit rules for each driver.
 	rules   map[string][]*Rule
 	rulesMu sync.RWMutex
+
+	// metas store version and capabilities for each driver, it's guarded by driversMu.
+	metas = make(map[string]*Metas)
 )
 
 const (
@@ -114,21 +117,54 @@ func NewConfig(dsn *DSN, rules []*Rule) (*Config, error) {
 	}, nil
 }
 
+// Capability is a feature which may not be supported by all drivers.
+type Capability string
+
+const (
+	// CapabilityRollback is generating rollback SQL by GenRollbackSQL.
+	CapabilityRollback Capability = "rollback"
+	// CapabilityExplain is explaining SQL by the database.
+	CapabilityExplain Capability = "explain"
+	// CapabilityOnlineDDL is executing DDL by online DDL tool.
+	CapabilityOnlineDDL Capability = "online_ddl"
+	// CapabilityOfflineAudit is auditing SQL without database, the DSN of Config is nil.
+	CapabilityOfflineAudit Capability = "offline_audit"
+	// CapabilityTransaction is executing SQLs in transaction by Tx.
+	CapabilityTransaction Capability = "transaction"
+)
+
+// Metas is the version and capabilities of driver.
+type Metas struct {
+	// Version is the version of plugin, it's empty for the driver built in SQLe.
+	Version string
+	// ProtocolVersion is the plugin protocol negotiated with the plugin, it's 0
+	// for the driver built in SQLe.
+	ProtocolVersion int
+	Capabilities    []Capability
+}
+
+// HasCapability returns true if the driver supports the capability.
+func (m *Metas) HasCapability(c Capability) bool {
+	for _, capability := range m.Capabilities {
+		if capability == c {
+			return true
+		}
+	}
+	return false
+}
+
 // handler is a template which Driver plugin should provide such function signature.
 type handler func(log *logrus.Entry, c *Config) (Driver, error)
 
 // Register like sql.Register.
 //
 // Register makes a database driver available by the provided driver name.
-// Driver's initialize handler and audit rules register by Register.
-func Register(name string, h handler, rs []*Rule) {
-	_, exist := drivers[name]
-	if exist {
-		panic("duplicated driver name")
-	}
-
+// Driver's initialize handler, audit rules and metas register by Register. If
+// the name is registered, the driver is replaced, e.g. the plugin is upgraded.
+func Register(name string, h handler, rs []*Rule, m *Metas) {
 	driversMu.Lock()
 	drivers[name] = h
+	metas[name] = m
 	driversMu.Unlock()
 
 	rulesMu.Lock()
@@ -139,6 +175,26 @@ func Register(name string, h handler, rs []*Rule) {
 	rulesMu.Unlock()
 }
 
+// Unregister makes the driver registered by Register unavailable, the drivers
+// which are opened are not affected.
+func Unregister(name string) {
+	driversMu.Lock()
+	delete(drivers, name)
+	delete(metas, name)
+	driversMu.Unlock()
+
+	rulesMu.Lock()
+	delete(rules, name)
+	rulesMu.Unlock()
+}
+
+func isDriverRegistered(name string) bool {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	_, exist := drivers[name]
+	return exist
+}
+
 type ErrDriverNotSupported struct {
 	DriverTyp string
 }
@@ -163,12 +219,30 @@ func NewDriver(log *logrus.Entry, dbType string, cfg *Config) (Driver, error) {
 func AllRules() map[string][]*Rule {
 	rulesMu.RLock()
 	defer rulesMu.RUnlock()
-	return rules
+
+	// the rules may be changed by reloading plugins, so a copy is returned.
+	allRules := make(map[string][]*Rule, len(rules))
+	for name, rs := range rules {
+		allRules[name] = rs
+	}
+	return allRules
+}
+
+// AllDriverMetas returns the metas of all drivers, the key is driver name.
+func AllDriverMetas() map[string]*Metas {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	allMetas := make(map[string]*Metas, len(metas))
+	for name, m := range metas {
+		allMetas[name] = m
+	}
+	return allMetas
 }
 
 func AllDrivers() []string {
-	rulesMu.RLock()
-	defer rulesMu.RUnlock()
+	driversMu.RLock()
+	defer driversMu.RUnlock()
 
 	driverNames := make([]string, 0, len(drivers))
 	for n := range drivers {
@@ -213,10 +287,179 @@ type Driver interface {
 	// driver should keep SQL context during it's lifecycle.
 	Audit(ctx context.Context, sql string) (*AuditResult, error)
 
+	// AuditBatch audit sqls in order, it's same as calling Audit with each SQL, but
+	// the driver over gRPC audits them in one round-trip. The results are in the same
+	// order as sqls.
+	AuditBatch(ctx context.Context, sqls []string) ([]*AuditResult, error)
+
 	// GenRollbackSQL generate sql's rollback SQL.
 	GenRollbackSQL(ctx context.Context, sql string) (string, string, error)
 }
 
+// ProgressFunc is called by Driver to report the progress of the query which is
+// executing by Exec or Tx, index is the index of the query in queries of Tx, it's
+// always 0 for Exec.
+type ProgressFunc func(index int, progress string)
+
+type progressKey struct{}
+
+// WithProgress returns a copy of ctx with fn, Driver reports the progress of the
+// executing query to fn if it's supported.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress reports the progress of the query to the ProgressFunc in ctx,
+// it does nothing if there is no ProgressFunc.
+func ReportProgress(ctx context.Context, index int, progress string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(index, progress)
+	}
+}
+
+// ExecRollback is the rollback of the query which is decided by Driver during the
+// execution, e.g. the old table is kept by online DDL, and the query is rollbacked
+// by renaming it back.
+type ExecRollback struct {
+	// RollbackSQL replaces the rollback SQL generated by GenRollbackSQL.
+	RollbackSQL string
+
+	// CleanSQL cleans the data kept for rollback after Retention, it's empty if
+	// nothing needs to be cleaned.
+	CleanSQL  string
+	Retention time.Duration
+
+	// StartBinlog and EndBinlog are the binlog range in which the query is executed,
+	// they are set if RollbackSQL is generated from the binlog.
+	StartBinlog *BinlogPos
+	EndBinlog   *BinlogPos
+
+	// RecycleBinObject is the name of the dropped object which is moved to the recycle
+	// bin instead, it's restored by RollbackSQL and purged by CleanSQL.
+	RecycleBinObject string
+}
+
+// BinlogPos is the position of the binlog.
+type BinlogPos struct {
+	File string
+	Pos  int64
+}
+
+// ExecRollbackFunc is called by Driver to report the ExecRollback of the query
+// which is executed by Exec or Tx, index is same as ProgressFunc.
+type ExecRollbackFunc func(index int, rollback *ExecRollback)
+
+type execRollbackKey struct{}
+
+// WithExecRollback returns a copy of ctx with fn, Driver reports the ExecRollback of
+// the executed query to fn.
+func WithExecRollback(ctx context.Context, fn ExecRollbackFunc) context.Context {
+	return context.WithValue(ctx, execRollbackKey{}, fn)
+}
+
+// ReportExecRollback reports the ExecRollback of the query to the ExecRollbackFunc
+// in ctx, it does nothing if there is no ExecRollbackFunc.
+func ReportExecRollback(ctx context.Context, index int, rollback *ExecRollback) {
+	if fn, ok := ctx.Value(execRollbackKey{}).(ExecRollbackFunc); ok && fn != nil {
+		fn(index, rollback)
+	}
+}
+
+// RollbackBatchFunc is called by Driver to report a batch of the rollback SQL during
+// GenRollbackSQL, so the rollback SQL of the DML which changes many rows is not built
+// in memory at once. The batches are saved as separate rollback SQLs in order, and the
+// rollback SQL returned by GenRollbackSQL is saved after them if it's not empty.
+type RollbackBatchFunc func(rollbackSQL string)
+
+type rollbackBatchKey struct{}
+
+// WithRollbackBatch returns a copy of ctx with fn, Driver reports the batches of the
+// rollback SQL to fn.
+func WithRollbackBatch(ctx context.Context, fn RollbackBatchFunc) context.Context {
+	return context.WithValue(ctx, rollbackBatchKey{}, fn)
+}
+
+// GetRollbackBatch returns the RollbackBatchFunc in ctx, it's nil if there is no
+// RollbackBatchFunc, then the rollback SQL is returned by GenRollbackSQL at once.
+func GetRollbackBatch(ctx context.Context) RollbackBatchFunc {
+	fn, _ := ctx.Value(rollbackBatchKey{}).(RollbackBatchFunc)
+	return fn
+}
+
+type rawExecKey struct{}
+
+// WithRawExec returns a copy of ctx in which the queries are executed as they are by
+// Exec or Tx, without the checks and the rollback protections of Driver, e.g. moving
+// the dropped tables to recycle bin. It's used to execute the rollback SQLs and the
+// clean SQLs, whose rollback is not kept.
+func WithRawExec(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawExecKey{}, true)
+}
+
+// IsRawExec returns true if the queries are executed as they are in ctx.
+func IsRawExec(ctx context.Context) bool {
+	raw, _ := ctx.Value(rawExecKey{}).(bool)
+	return raw
+}
+
+// ExecCommand is sent by user to control the query which is executing, such as
+// throttling the online DDL.
+type ExecCommand string
+
+const (
+	ExecCommandThrottle        ExecCommand = "throttle"
+	ExecCommandUnthrottle      ExecCommand = "unthrottle"
+	ExecCommandPostponeCutOver ExecCommand = "postpone_cut_over"
+	ExecCommandCutOver         ExecCommand = "cut_over"
+	ExecCommandAbort           ExecCommand = "abort"
+)
+
+var ErrExecCommandNotSupported = errors.New("no executing query supports the command")
+
+// ExecController delivers the commands to the query which is executing. Driver
+// handles the commands by HandleExecCommand if the query can be controlled.
+type ExecController struct {
+	mu      sync.Mutex
+	handler func(cmd ExecCommand) error
+}
+
+// Send sends cmd to the executing query, it returns ErrExecCommandNotSupported if
+// there is no query handling the commands.
+func (c *ExecController) Send(cmd ExecCommand) error {
+	c.mu.Lock()
+	handler := c.handler
+	c.mu.Unlock()
+	if handler == nil {
+		return ErrExecCommandNotSupported
+	}
+	return handler(cmd)
+}
+
+type execControllerKey struct{}
+
+// WithExecController returns a copy of ctx with c, the commands sent to c are
+// handled by Driver if it's supported.
+func WithExecController(ctx context.Context, c *ExecController) context.Context {
+	return context.WithValue(ctx, execControllerKey{}, c)
+}
+
+// HandleExecCommand sets fn to handle the commands sent to the ExecController in
+// ctx, the returned function unsets it and must be called after the query is done.
+func HandleExecCommand(ctx context.Context, fn func(cmd ExecCommand) error) (unset func()) {
+	c, ok := ctx.Value(execControllerKey{}).(*ExecController)
+	if !ok || c == nil {
+		return func() {}
+	}
+	c.mu.Lock()
+	c.handler = fn
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		c.handler = nil
+		c.mu.Unlock()
+	}
+}
+
 // Registerer is the interface that all SQLe plugins must support.
 type Registerer interface {
 	// Name returns plugin name.
@@ -226,6 +469,19 @@ type Registerer interface {
 	Rules() []*Rule
 }
 
+// MetasRegisterer is the interface that the plugin reports its version and
+// capabilities by. The plugin only implements Registerer is treated as the
+// plugin built before capability.
+type MetasRegisterer interface {
+	Registerer
+
+	// Version returns plugin version.
+	Version() string
+
+	// Capabilities returns the features that plugin supported.
+	Capabilities() []Capability
+}
+
 // Node is a interface which unify SQL ast tree. It produce by Driver.Parse.
 type Node struct {
 	// Text is the raw SQL text of Node.
@@ -250,17 +506,24 @@ type Node struct {
 // }
 
 type AuditResult struct {
-	results []*auditResult
+	results []*AuditResultItem
 }
 
-type auditResult struct {
-	level   RuleLevel
-	message string
+// AuditResultItem is one finding of audit. RuleName and Category are empty
+// when the finding is not produced by a rule, e.g. the SQL is invalid.
+type AuditResultItem struct {
+	RuleName string
+	Category string
+	Level    RuleLevel
+	Message  string
+	// Prefix replaces the level prefix of Message in AuditResult.Message if it's
+	// set, e.g. the "[osc]" of pt-osc hint.
+	Prefix string
 }
 
 func NewInspectResults() *AuditResult {
 	return &AuditResult{
-		results: []*auditResult{},
+		results: []*AuditResultItem{},
 	}
 }
 
@@ -268,8 +531,8 @@ func NewInspectResults() *AuditResult {
 func (rs *AuditResult) Level() RuleLevel {
 	level := RuleLevelNormal
 	for _, curr := range rs.results {
-		if ruleLevelMap[curr.level] > ruleLevelMap[level] {
-			level = curr.level
+		if ruleLevelMap[curr.Level] > ruleLevelMap[level] {
+			level = curr.Level
 		}
 	}
 	return level
@@ -278,27 +541,41 @@ func (rs *AuditResult) Level() RuleLevel {
 func (rs *AuditResult) Message() string {
 	messages := make([]string, len(rs.results))
 	for n, result := range rs.results {
-		var message string
-		match, _ := regexp.MatchString(fmt.Sprintf(`^\[%s|%s|%s|%s|%s\]`,
-			RuleLevelError, RuleLevelWarn, RuleLevelNotice, RuleLevelNormal, "osc"),
-			result.message)
-		if match {
-			message = result.message
-		} else {
-			message = fmt.Sprintf("[%s]%s", result.level, result.message)
+		prefix := result.Prefix
+		if prefix == "" {
+			prefix = fmt.Sprintf("[%s]", result.Level)
 		}
-		messages[n] = message
+		messages[n] = prefix + result.Message
 	}
 	return strings.Join(messages, "\n")
 }
 
+// Items returns all findings in the order they are added.
+func (rs *AuditResult) Items() []*AuditResultItem {
+	return rs.results
+}
+
 func (rs *AuditResult) Add(level RuleLevel, message string, args ...interface{}) {
-	if level == "" || message == "" {
-		return
-	}
+	rs.AddItem(&AuditResultItem{
+		Level:   level,
+		Message: fmt.Sprintf(message, args...),
+	})
+}
 
-	rs.results = append(rs.results, &auditResult{
-		level:   level,
-		message: fmt.Sprintf(message, args...),
+// AddWithRule adds a finding produced by the rule, the level of the finding
+// is the level of the rule.
+func (rs *AuditResult) AddWithRule(rule *Rule, message string, args ...interface{}) {
+	rs.AddItem(&AuditResultItem{
+		RuleName: rule.Name,
+		Category: rule.Category,
+		Level:    rule.Level,
+		Message:  fmt.Sprintf(message, args...),
 	})
 }
+
+func (rs *AuditResult) AddItem(item *AuditResultItem) {
+	if item == nil || item.Level == "" || item.Message == "" {
+		return
+	}
+	rs.results = append(rs.results, item)
+}
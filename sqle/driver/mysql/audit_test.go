@@ -163,6 +163,12 @@ func DefaultMysqlInspect() *Inspect {
 			DDLOSCMinSize:      16,
 			DDLGhostMinSize:    16,
 			DMLRollbackMaxRows: 1000,
+
+			DDLCheckMaxTrxTime:              -1,
+			DDLCheckMaxReplicaLag:           -1,
+			DMLRollbackBinlogMaxRows:        -1,
+			DMLRollbackBackupRetentionHours: -1,
+			DDLRecycleBinRetentionDays:      -1,
 		},
 	}
 }
@@ -0,0 +1,266 @@
+package mysql
+
+import (
+	"context"
+	_driver "database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pkg/errors"
+)
+
+// dmlBackup is the backup table of the rows changed by the UPDATE or DELETE, whose
+// rollback SQL is not generated from the queried records because the rows are more
+// than DMLRollbackMaxRows. The rows are copied to the backup table before the DML is
+// executed, and the DML is rollbacked by restoring the rows from the backup table.
+type dmlBackup struct {
+	i *Inspect
+	// index is the index of the DML in the queries executed by Exec or Tx.
+	index int
+
+	// table and backupTable are quoted name with schema.
+	table       string
+	backupTable string
+	// schema and backupName are the unquoted name of backup table.
+	schema     string
+	backupName string
+	// selectSQL selects the rows changed by the DML.
+	selectSQL string
+}
+
+// dmlBackups are the backups of the queries executed together.
+type dmlBackups []*dmlBackup
+
+// getDMLBackup returns nil if the rows changed by the query are not backed up. Only the
+// single table UPDATE/DELETE without sub query on the table which has primary key is
+// backed up, and the UPDATE must not change the primary key.
+func (i *Inspect) getDMLBackup(index int, query string) (*dmlBackup, error) {
+	if i.cnf.DMLRollbackBackupRetentionHours < 0 || i.cnf.DMLRollbackMaxRows < 0 {
+		return nil, nil
+	}
+	node, err := parseOneSql(query)
+	if err != nil {
+		return nil, nil
+	}
+
+	backup := &dmlBackup{i: i, index: index}
+	var (
+		tableSources []*ast.TableSource
+		where        ast.ExprNode
+		order        *ast.OrderByClause
+		limit        *ast.Limit
+	)
+	switch stmt := node.(type) {
+	case *ast.DeleteStmt:
+		if stmt.IsMultiTable {
+			return nil, nil
+		}
+		tableSources = getTableSources(stmt.TableRefs.TableRefs)
+		where, order, limit = stmt.Where, stmt.Order, stmt.Limit
+	case *ast.UpdateStmt:
+		tableSources = getTableSources(stmt.TableRefs.TableRefs)
+		where, order, limit = stmt.Where, stmt.Order, stmt.Limit
+	default:
+		return nil, nil
+	}
+	if len(tableSources) != 1 || whereStmtHasSubQuery(where) {
+		return nil, nil
+	}
+	table, ok := tableSources[0].Source.(*ast.TableName)
+	if !ok {
+		return nil, nil
+	}
+	tableAlias := tableSources[0].AsName.String()
+
+	createTableStmt, exist, err := i.getCreateTableStmt(table)
+	if err != nil || !exist {
+		return nil, err
+	}
+	pkColumnsName, hasPk, err := i.getPrimaryKey(createTableStmt)
+	if err != nil || !hasPk {
+		return nil, err
+	}
+	if stmt, ok := node.(*ast.UpdateStmt); ok && updatePrimaryKey(stmt, pkColumnsName) {
+		return nil, nil
+	}
+
+	var max = i.cnf.DMLRollbackMaxRows
+	limitCount, err := getLimitCount(limit, max+1)
+	if err != nil {
+		return nil, err
+	}
+	if limitCount <= max {
+		return nil, nil
+	}
+	count, err := i.getRecordCount(table, tableAlias, where, order, limitCount)
+	if err != nil {
+		return nil, err
+	}
+	if count <= max {
+		return nil, nil
+	}
+
+	// all rows are backed up if the DML has no limit.
+	limitCount, err = getLimitCount(limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	backup.schema = i.getSchemaName(table)
+	backup.backupName = backupTableName(table.Name.String(), index)
+	backup.table = getTableNameWithQuote(newTableName(backup.schema, table.Name.String()))
+	backup.backupTable = getTableNameWithQuote(newTableName(backup.schema, backup.backupName))
+	backup.selectSQL = strings.TrimSuffix(i.generateGetRecordsSql("*", table, tableAlias, where, order, limitCount), ";")
+	return backup, nil
+}
+
+// backupTableName returns the timestamped name of backup table, which is no longer
+// than 64 characters.
+func backupTableName(table string, index int) string {
+	suffix := fmt.Sprintf("_%s_%d_bak", time.Now().Format("20060102150405"), index)
+	if len(table)+len(suffix)+1 > 64 {
+		table = table[:64-len(suffix)-1]
+	}
+	return fmt.Sprintf("_%s%s", table, suffix)
+}
+
+// backupDMLs creates the backup tables of the rows changed by the queries before they
+// are executed, the index of the first query is offset. The rows are copied to the
+// backup tables by transact or copyRows. The backup tables which are created are
+// dropped if any backup is failed.
+func (i *Inspect) backupDMLs(ctx context.Context, offset int, queries ...string) (dmlBackups, error) {
+	backups := dmlBackups{}
+	if driver.IsRawExec(ctx) {
+		return backups, nil
+	}
+	for idx, query := range queries {
+		backup, err := i.getDMLBackup(offset+idx, query)
+		if err != nil {
+			backups.drop()
+			return nil, errors.Wrap(err, "check whether backup the rows changed by DML or not")
+		}
+		if backup == nil {
+			continue
+		}
+		if err := backup.create(ctx); err != nil {
+			backup.drop()
+			backups.drop()
+			return nil, errors.Wrapf(err, "create backup table %s", backup.backupTable)
+		}
+		backups = append(backups, backup)
+	}
+	return backups, nil
+}
+
+func (b *dmlBackup) create(ctx context.Context) error {
+	conn, err := b.i.getDbConn()
+	if err != nil {
+		return err
+	}
+	_, err = conn.Db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s LIKE %s", b.backupTable, b.table))
+	return err
+}
+
+// copySQL copies the rows changed by the DML to the backup table with locking read, so
+// the rows can't be changed by other sessions until the DML is executed in the same
+// transaction.
+func (b *dmlBackup) copySQL() string {
+	return fmt.Sprintf("INSERT INTO %s %s FOR UPDATE", b.backupTable, b.selectSQL)
+}
+
+func (b *dmlBackup) reportCopied(ctx context.Context, result _driver.Result) {
+	rows, _ := result.RowsAffected()
+	driver.ReportProgress(ctx, b.index, fmt.Sprintf("%d rows are backed up to %s", rows, b.backupTable))
+}
+
+// transact executes the queries in one transaction, the rows changed by the query are
+// copied to its backup table right before the query in the transaction. It returns the
+// results of queries.
+func (bs dmlBackups) transact(ctx context.Context, conn *Executor, offset int, queries ...string) (
+	[]_driver.Result, error) {
+	backups := map[int]*dmlBackup{}
+	for _, b := range bs {
+		backups[b.index-offset] = b
+	}
+	txQueries := []string{}
+	for idx, query := range queries {
+		if b, ok := backups[idx]; ok {
+			txQueries = append(txQueries, b.copySQL())
+		}
+		txQueries = append(txQueries, query)
+	}
+	txResults, err := conn.Db.TransactContext(ctx, txQueries...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []_driver.Result{}
+	for idx := range queries {
+		if b, ok := backups[idx]; ok {
+			b.reportCopied(ctx, txResults[0])
+			txResults = txResults[1:]
+		}
+		results = append(results, txResults[0])
+		txResults = txResults[1:]
+	}
+	return results, nil
+}
+
+// copyRows copies the rows before the chunked DML is executed. The chunks are not
+// executed in the transaction of copying, the rows changed by other sessions after
+// copied are overwritten by the rollback.
+func (bs dmlBackups) copyRows(ctx context.Context) error {
+	for _, b := range bs {
+		conn, err := b.i.getDbConn()
+		if err != nil {
+			bs.drop()
+			return err
+		}
+		result, err := conn.Db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s %s", b.backupTable, b.selectSQL))
+		if err != nil {
+			bs.drop()
+			return errors.Wrapf(err, "backup the rows changed by DML to %s", b.backupTable)
+		}
+		b.reportCopied(ctx, result)
+	}
+	return nil
+}
+
+func (b *dmlBackup) drop() {
+	conn, err := b.i.getDbConn()
+	if err == nil {
+		_, err = conn.Db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", b.backupTable))
+	}
+	if err != nil {
+		b.i.log.Errorf("drop backup table %s error: %v", b.backupTable, err)
+	}
+}
+
+func (bs dmlBackups) drop() {
+	for _, b := range bs {
+		b.drop()
+	}
+}
+
+// finish reports the rollback SQLs which restore the rows from the backup tables after
+// the queries are executed, the backup tables are dropped after the retention. They are
+// dropped at once if the queries are failed, execErr must be nil if the failed queries
+// may change some rows, e.g. the chunked DML.
+func (bs dmlBackups) finish(ctx context.Context, execErr error) {
+	if execErr != nil {
+		bs.drop()
+		return
+	}
+	for _, b := range bs {
+		// the UPDATE doesn't change the primary key, so both the updated rows and the
+		// deleted rows are restored by replacing.
+		driver.ReportExecRollback(ctx, b.index, &driver.ExecRollback{
+			RollbackSQL: fmt.Sprintf("REPLACE INTO %s SELECT * FROM %s;", b.table, b.backupTable),
+			CleanSQL:    fmt.Sprintf("DROP TABLE IF EXISTS %s", b.backupTable),
+			Retention:   time.Duration(b.i.cnf.DMLRollbackBackupRetentionHours) * time.Hour,
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBackupTestInspect(t *testing.T) (*Inspect, sqlmock.Sqlmock) {
+	i := DefaultMysqlInspect()
+	i.cnf.DMLRollbackMaxRows = 10
+	i.cnf.DMLRollbackBackupRetentionHours = 72
+	return i, mockInspectDbConn(t, i)
+}
+
+func TestInspect_getDMLBackup(t *testing.T) {
+	tests := []struct {
+		query  string
+		count  string
+		backup bool
+		sql    string
+	}{
+		{"delete from exist_db.exist_tb_1 where v1 = 'a'", "11", true,
+			"SELECT * FROM `exist_db`.`exist_tb_1` WHERE `v1` = \"a\""},
+		{"update exist_db.exist_tb_1 as t set v2 = 'b' where v1 = 'a' order by v1 limit 100", "11", true,
+			"SELECT * FROM `exist_db`.`exist_tb_1` AS t WHERE `v1` = \"a\" ORDER BY `v1` LIMIT 100"},
+		{"delete from exist_db.exist_tb_1 where v1 = 'a'", "10", false, ""},
+	}
+	for _, tt := range tests {
+		i, mock := newBackupTestInspect(t)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) as count FROM `exist_db`.`exist_tb_1`")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+		backup, err := i.getDMLBackup(0, tt.query)
+		assert.NoError(t, err, tt.query)
+		assert.Equal(t, tt.backup, backup != nil, tt.query)
+		if backup != nil {
+			assert.Equal(t, tt.sql, backup.selectSQL)
+			assert.Equal(t, "`exist_db`.`exist_tb_1`", backup.table)
+			assert.Regexp(t, "^`exist_db`.`_exist_tb_1_[0-9]{14}_0_bak`$", backup.backupTable)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet(), tt.query)
+	}
+
+	// the rows are not counted.
+	for _, query := range []string{
+		"delete from exist_db.exist_tb_1 where v1 = 'a' limit 10",
+		"update exist_db.exist_tb_1 set id = 1 where v1 = 'a'",
+		"delete exist_db.exist_tb_1 from exist_db.exist_tb_1 join exist_db.exist_tb_2 on exist_tb_1.id = exist_tb_2.id",
+		"delete from exist_db.exist_tb_1 where id in (select id from exist_db.exist_tb_2)",
+		"insert into exist_db.exist_tb_1 values(1, 'a', 'b')",
+	} {
+		i, mock := newBackupTestInspect(t)
+		backup, err := i.getDMLBackup(0, query)
+		assert.NoError(t, err, query)
+		assert.Nil(t, backup, query)
+		assert.NoError(t, mock.ExpectationsWereMet(), query)
+	}
+
+	// backup is disabled.
+	i, _ := newBackupTestInspect(t)
+	i.cnf.DMLRollbackBackupRetentionHours = -1
+	backup, err := i.getDMLBackup(0, "delete from exist_db.exist_tb_1 where v1 = 'a'")
+	assert.NoError(t, err)
+	assert.Nil(t, backup)
+}
+
+func TestBackupTableName(t *testing.T) {
+	assert.Regexp(t, "^_t1_[0-9]{14}_2_bak$", backupTableName("t1", 2))
+	name := backupTableName(fmt.Sprintf("%064d", 0), 2)
+	assert.Len(t, name, 64)
+}
+
+func TestInspect_Exec_backup(t *testing.T) {
+	query := "delete from exist_db.exist_tb_1 where v1 = 'a'"
+	i, mock := newBackupTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) as count FROM `exist_db`.`exist_tb_1`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("11"))
+	mock.ExpectExec("CREATE TABLE `exist_db`.`_exist_tb_1_[0-9]{14}_0_bak` LIKE `exist_db`.`exist_tb_1`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// the rows are backed up with locking read in the transaction of DML.
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `exist_db`.`_exist_tb_1_[0-9]{14}_0_bak` " +
+		regexp.QuoteMeta("SELECT * FROM `exist_db`.`exist_tb_1` WHERE `v1` = \"a\" FOR UPDATE")).
+		WillReturnResult(sqlmock.NewResult(0, 20))
+	mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 20))
+	mock.ExpectCommit()
+
+	var rollback *driver.ExecRollback
+	var progress []string
+	ctx := driver.WithExecRollback(context.Background(), func(index int, r *driver.ExecRollback) {
+		assert.Equal(t, 0, index)
+		rollback = r
+	})
+	ctx = driver.WithProgress(ctx, func(index int, p string) {
+		progress = append(progress, p)
+	})
+	_, err := i.Exec(ctx, query)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Len(t, progress, 1)
+	assert.Regexp(t, "^20 rows are backed up to `exist_db`.`_exist_tb_1_[0-9]{14}_0_bak`$", progress[0])
+	assert.NotNil(t, rollback)
+	assert.Regexp(t, "^REPLACE INTO `exist_db`.`exist_tb_1` SELECT \\* FROM `exist_db`.`_exist_tb_1_[0-9]{14}_0_bak`;$",
+		rollback.RollbackSQL)
+	assert.Regexp(t, "^DROP TABLE IF EXISTS `exist_db`.`_exist_tb_1_[0-9]{14}_0_bak`$", rollback.CleanSQL)
+	assert.Equal(t, 72*time.Hour, rollback.Retention)
+
+	// the backup table is dropped if the DML is failed.
+	i, mock = newBackupTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) as count FROM `exist_db`.`exist_tb_1`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("11"))
+	mock.ExpectExec("CREATE TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(0, 20))
+	mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(fmt.Errorf("lock wait timeout"))
+	mock.ExpectRollback()
+	mock.ExpectExec("DROP TABLE IF EXISTS `exist_db`.`_exist_tb_1_[0-9]{14}_0_bak`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rollback = nil
+	_, err = i.Exec(ctx, query)
+	assert.Error(t, err)
+	assert.Nil(t, rollback)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInspect_Tx_backup(t *testing.T) {
+	queries := []string{
+		"insert into exist_db.exist_tb_1 values(1, 'a', 'b')",
+		"delete from exist_db.exist_tb_1 where v1 = 'a'",
+	}
+	i, mock := newBackupTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) as count FROM `exist_db`.`exist_tb_1`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("11"))
+	mock.ExpectExec("CREATE TABLE `exist_db`.`_exist_tb_1_[0-9]{14}_1_bak`").WillReturnResult(sqlmock.NewResult(0, 0))
+	// the rows are backed up right before the DELETE, after the INSERT.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(queries[0])).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `exist_db`.`_exist_tb_1_[0-9]{14}_1_bak` .* FOR UPDATE").
+		WillReturnResult(sqlmock.NewResult(0, 21))
+	mock.ExpectExec(regexp.QuoteMeta(queries[1])).WillReturnResult(sqlmock.NewResult(0, 21))
+	mock.ExpectCommit()
+
+	var progress []string
+	ctx := driver.WithProgress(context.Background(), func(index int, p string) {
+		assert.Equal(t, 1, index)
+		progress = append(progress, p)
+	})
+	results, err := i.Tx(ctx, queries...)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Len(t, results, 2)
+	rows, _ := results[0].RowsAffected()
+	assert.Equal(t, int64(1), rows)
+	rows, _ = results[1].RowsAffected()
+	assert.Equal(t, int64(21), rows)
+	assert.Len(t, progress, 1)
+	assert.Regexp(t, "^21 rows are backed up to", progress[0])
+}
+
+func TestInspect_Exec_chunkedBackup(t *testing.T) {
+	query := "delete from exist_db.exist_tb_1 where v1 = 'a'"
+	i := newChunkTestInspect(1000, query)
+	i.cnf.DMLRollbackMaxRows = 10
+	i.cnf.DMLRollbackBackupRetentionHours = 72
+	mock := mockInspectDbConn(t, i)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) as count FROM `exist_db`.`exist_tb_1`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("11"))
+	mock.ExpectExec("CREATE TABLE `exist_db`.`_exist_tb_1_[0-9]{14}_0_bak`").WillReturnResult(sqlmock.NewResult(0, 0))
+	// the rows are backed up before the chunks, which are not in the same transaction.
+	mock.ExpectExec("INSERT INTO `exist_db`.`_exist_tb_1_[0-9]{14}_0_bak` " +
+		regexp.QuoteMeta("SELECT * FROM `exist_db`.`exist_tb_1` WHERE `v1` = \"a\"") + "$").
+		WillReturnResult(sqlmock.NewResult(0, 20))
+	mock.ExpectQuery("SELECT MIN").WillReturnError(fmt.Errorf("lost connection"))
+
+	// the backup is kept for the chunks committed before failure.
+	var rollback *driver.ExecRollback
+	ctx := driver.WithExecRollback(context.Background(), func(index int, r *driver.ExecRollback) {
+		rollback = r
+	})
+	_, err := i.Exec(ctx, query)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NotNil(t, rollback)
+}
+
+func TestInspect_exceedMaxRowsRollbackReason(t *testing.T) {
+	query := "delete from exist_db.exist_tb_1 where v1 = 'a'"
+	i := DefaultMysqlInspect()
+	reason, err := i.exceedMaxRowsRollbackReason(false, query)
+	assert.NoError(t, err)
+	assert.Equal(t, NotSupportExceedMaxRowsRollback, reason)
+	i.cnf.DMLRollbackBackupRetentionHours = 72
+	reason, err = i.exceedMaxRowsRollbackReason(false, query)
+	assert.NoError(t, err)
+	assert.Equal(t, ExceedMaxRowsRollbackWithBackup, reason)
+	reason, err = i.exceedMaxRowsRollbackReason(true, query)
+	assert.NoError(t, err)
+	assert.Equal(t, NotSupportExceedMaxRowsRollback, reason)
+
+	// the DML executed in chunks is not backed up in its transaction.
+	i = newChunkTestInspect(1000, query)
+	i.cnf.DMLRollbackBackupRetentionHours = 72
+	reason, err = i.exceedMaxRowsRollbackReason(false, query)
+	assert.NoError(t, err)
+	assert.Equal(t, ExceedMaxRowsRollbackWithChunkedBackup, reason)
+}
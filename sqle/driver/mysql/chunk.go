@@ -0,0 +1,264 @@
+package mysql
+
+import (
+	"context"
+	_driver "database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/opcode"
+	"github.com/pkg/errors"
+)
+
+var (
+	// replicaLagCheckInterval is the interval to check replication lag again when
+	// the chunked DML is throttled.
+	replicaLagCheckInterval = time.Second
+
+	// replicaLagMaxErrors is the max number of successive errors of checking
+	// replication lag, the chunked DML is stopped after that, rather than being
+	// throttled forever.
+	replicaLagMaxErrors = 60
+)
+
+// chunkedDML is an UPDATE or DELETE statement which is executed in chunks by the
+// range of primary key, every chunk is committed alone, so the large DML doesn't
+// hold the locks and block the replicas for a long time.
+type chunkedDML struct {
+	stmt  ast.StmtNode
+	where ast.ExprNode
+
+	// table and pk are quoted name of table and primary key.
+	table string
+	pk    string
+}
+
+// getChunkedDML returns nil if the query is not executed in chunks. Only the single
+// table UPDATE/DELETE without ORDER BY and LIMIT on the table which has a integer
+// primary key is executed in chunks, and its estimated affected rows must be more
+// than the config value.
+func (i *Inspect) getChunkedDML(query string) (*chunkedDML, error) {
+	if i.cnf.DMLChunkMinRows < 0 || i.cnf.DMLChunkSize <= 0 {
+		return nil, nil
+	}
+	node, err := parseOneSql(query)
+	if err != nil {
+		// the SQL which is not supported by parser is executed as it is.
+		return nil, nil
+	}
+
+	dml := &chunkedDML{stmt: node}
+	var tables []*ast.TableName
+	switch stmt := node.(type) {
+	case *ast.DeleteStmt:
+		if stmt.IsMultiTable || stmt.Order != nil || stmt.Limit != nil {
+			return nil, nil
+		}
+		tables = getTables(stmt.TableRefs.TableRefs)
+		dml.where = stmt.Where
+	case *ast.UpdateStmt:
+		if stmt.Order != nil || stmt.Limit != nil {
+			return nil, nil
+		}
+		tables = getTables(stmt.TableRefs.TableRefs)
+		dml.where = stmt.Where
+	default:
+		return nil, nil
+	}
+	if len(tables) != 1 {
+		return nil, nil
+	}
+
+	createTableStmt, exist, err := i.getCreateTableStmt(tables[0])
+	if err != nil || !exist {
+		return nil, err
+	}
+	pkColumnsName, hasPk, err := i.getPrimaryKey(createTableStmt)
+	if err != nil || !hasPk || len(pkColumnsName) != 1 {
+		return nil, err
+	}
+	for _, col := range createTableStmt.Cols {
+		if _, ok := pkColumnsName[col.Name.Name.L]; !ok {
+			continue
+		}
+		switch col.Tp.Tp {
+		case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+			dml.pk = fmt.Sprintf("`%s`", strings.Replace(col.Name.Name.O, "`", "``", -1))
+		}
+	}
+	if dml.pk == "" {
+		return nil, nil
+	}
+	// the rows whose primary key is changed may be moved into the range of a later
+	// chunk and updated again.
+	if stmt, ok := node.(*ast.UpdateStmt); ok {
+		for _, assign := range stmt.List {
+			if _, ok := pkColumnsName[assign.Column.Name.L]; ok {
+				return nil, nil
+			}
+		}
+	}
+	dml.table = getTableNameWithQuote(tables[0])
+
+	records, err := i.getExecutionPlan(query)
+	if err != nil {
+		// EXPLAIN UPDATE/DELETE is not supported before MySQL 5.6.
+		i.log.Warnf("get execution plan error, DML is not executed in chunks: %v", err)
+		return nil, nil
+	}
+	if len(records) == 0 || records[0].Rows <= i.cnf.DMLChunkMinRows {
+		return nil, nil
+	}
+	return dml, nil
+}
+
+// chunkSQL returns the SQL which is restricted to the primary key range of the chunk,
+// the lower bound is excluded if lowerOp is ">".
+func (d *chunkedDML) chunkSQL(lowerOp, lower, upper string) (string, error) {
+	node, err := parseOneSql(fmt.Sprintf("SELECT 1 FROM %s WHERE %s %s %s AND %s <= %s",
+		d.table, d.pk, lowerOp, lower, d.pk, upper))
+	if err != nil {
+		return "", err
+	}
+	where := node.(*ast.SelectStmt).Where
+	if d.where != nil {
+		where = &ast.BinaryOperationExpr{Op: opcode.LogicAnd, L: &ast.ParenthesesExpr{Expr: d.where}, R: where}
+	}
+
+	switch stmt := d.stmt.(type) {
+	case *ast.DeleteStmt:
+		stmt.Where = where
+		defer func() { stmt.Where = d.where }()
+	case *ast.UpdateStmt:
+		stmt.Where = where
+		defer func() { stmt.Where = d.where }()
+	}
+	return restoreToSqlWithFlag(format.DefaultRestoreFlags, d.stmt)
+}
+
+// execChunkedDML executes the DML chunk by chunk, it sleeps between chunks and waits for
+// the replicas to catch up if the replication lag exceeds the config value. The progress
+// is reported after every chunk. The chunks which have been executed are not rollbacked
+// if it returns error.
+func (i *Inspect) execChunkedDML(ctx context.Context, index int, dml *chunkedDML) (_driver.Result, error) {
+	conn, err := i.getDbConn()
+	if err != nil {
+		return nil, err
+	}
+	bounds, err := conn.Db.Query(fmt.Sprintf("SELECT MIN(%s) AS min_value, MAX(%s) AS max_value FROM %s",
+		dml.pk, dml.pk, dml.table))
+	if err != nil {
+		return nil, err
+	}
+	if len(bounds) != 1 || !bounds[0]["min_value"].Valid {
+		// the table is empty.
+		return _driver.RowsAffected(0), nil
+	}
+	min, max := bounds[0]["min_value"].String, bounds[0]["max_value"].String
+
+	var lagChecker *replicaLagChecker
+	if i.cnf.DMLChunkMaxReplicaLag >= 0 {
+		if lagChecker, err = i.newReplicaLagChecker(); err != nil {
+			return nil, errors.Wrap(err, "check replication lag")
+		}
+		defer lagChecker.Close()
+	}
+
+	var affected int64
+	lowerOp, lower := ">=", min
+	for chunk := 1; ; chunk++ {
+		if err := i.waitForReplicas(ctx, index, lagChecker); err != nil {
+			return _driver.RowsAffected(affected), err
+		}
+
+		upper := max
+		records, err := conn.Db.Query(fmt.Sprintf("SELECT %s AS value FROM %s WHERE %s %s %s AND %s <= %s ORDER BY %s LIMIT 1 OFFSET %d",
+			dml.pk, dml.table, dml.pk, lowerOp, lower, dml.pk, max, dml.pk, i.cnf.DMLChunkSize-1))
+		if err != nil {
+			return _driver.RowsAffected(affected), err
+		}
+		if len(records) == 1 {
+			upper = records[0]["value"].String
+		}
+
+		query, err := dml.chunkSQL(lowerOp, lower, upper)
+		if err != nil {
+			return _driver.RowsAffected(affected), err
+		}
+		result, err := conn.Db.ExecContext(ctx, query)
+		if err != nil {
+			return _driver.RowsAffected(affected), errors.Wrapf(err, "execute chunk %d", chunk)
+		}
+		rowAffects, _ := result.RowsAffected()
+		affected += rowAffects
+		driver.ReportProgress(ctx, index, fmt.Sprintf("chunk %d is done, primary key %s/%s (%s), %d rows affected",
+			chunk, upper, max, chunkPercent(min, max, upper), affected))
+
+		if upper == max {
+			break
+		}
+		lowerOp, lower = ">", upper
+
+		select {
+		case <-ctx.Done():
+			return _driver.RowsAffected(affected), ctx.Err()
+		case <-time.After(time.Duration(i.cnf.DMLChunkSleepMs) * time.Millisecond):
+		}
+	}
+	return _driver.RowsAffected(affected), nil
+}
+
+// waitForReplicas blocks until the replication lag is less than the config value. It
+// returns error if the replication lag fails to be checked replicaLagMaxErrors times
+// in succession.
+func (i *Inspect) waitForReplicas(ctx context.Context, index int, lagChecker *replicaLagChecker) error {
+	if lagChecker == nil {
+		return nil
+	}
+	errCount := 0
+	for {
+		lag, replica, err := lagChecker.MaxLag()
+		if err == nil && lag <= i.cnf.DMLChunkMaxReplicaLag {
+			return nil
+		}
+		var reason string
+		if err != nil {
+			errCount++
+			if errCount >= replicaLagMaxErrors {
+				return errors.Wrapf(err, "check replication lag failed %d times", errCount)
+			}
+			reason = err.Error()
+		} else {
+			errCount = 0
+			reason = fmt.Sprintf("replication lag of replica %s is %ds", replica, lag)
+		}
+		driver.ReportProgress(ctx, index, fmt.Sprintf("throttled, %s", reason))
+		i.log.Warnf("chunked DML is throttled, %s", reason)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(replicaLagCheckInterval):
+		}
+	}
+}
+
+func chunkPercent(min, max, upper string) string {
+	minValue, err1 := strconv.ParseFloat(min, 64)
+	maxValue, err2 := strconv.ParseFloat(max, 64)
+	upperValue, err3 := strconv.ParseFloat(upper, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return "-"
+	}
+	if maxValue == minValue {
+		return "100.00%"
+	}
+	return fmt.Sprintf("%.2f%%", (upperValue-minValue)/(maxValue-minValue)*100)
+}
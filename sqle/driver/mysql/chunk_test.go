@@ -0,0 +1,190 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockInspectDbConn replaces the connection of Inspect with sqlmock.
+func mockInspectDbConn(t *testing.T, i *Inspect) sqlmock.Sqlmock {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	conn, err := db.Conn(context.Background())
+	assert.NoError(t, err)
+	i.dbConn = &Executor{Db: &BaseConn{log: log.NewEntry(), db: db, conn: conn}}
+	i.isConnected = true
+	return mock
+}
+
+func newChunkTestInspect(rows int64, queries ...string) *Inspect {
+	i := DefaultMysqlInspect()
+	i.cnf.DMLChunkMinRows = 100
+	i.cnf.DMLChunkSize = 10
+	i.cnf.DMLChunkSleepMs = 0
+	i.cnf.DMLChunkMaxReplicaLag = -1
+	for _, query := range queries {
+		i.Ctx.AddExecutionPlan(query, []*ExplainRecord{{Rows: rows}})
+	}
+	return i
+}
+
+func TestInspect_getChunkedDML(t *testing.T) {
+	tests := []struct {
+		query   string
+		rows    int64
+		chunked bool
+	}{
+		{"delete from exist_db.exist_tb_1 where v1 = 'a'", 1000, true},
+		{"update exist_db.exist_tb_1 set v2 = 'b' where v1 = 'a'", 1000, true},
+		{"delete from exist_db.exist_tb_1", 1000, true},
+		{"delete from exist_db.exist_tb_1 where v1 = 'a'", 100, false},
+		{"delete from exist_db.exist_tb_1 where v1 = 'a' limit 10", 1000, false},
+		{"update exist_db.exist_tb_1 set v2 = 'b' where v1 = 'a' order by id", 1000, false},
+		{"update exist_db.exist_tb_1 set id = id + 1000 where v1 = 'a'", 1000, false},
+		{"update exist_db.exist_tb_1 set v2 = 'b', exist_tb_1.ID = 1 where v1 = 'a'", 1000, false},
+		{"delete exist_db.exist_tb_1 from exist_db.exist_tb_1 join exist_db.exist_tb_2 on exist_tb_1.id = exist_tb_2.id", 1000, false},
+		{"insert into exist_db.exist_tb_1 values(1, 'a', 'b')", 1000, false},
+	}
+	for _, tt := range tests {
+		i := newChunkTestInspect(tt.rows, tt.query)
+		dml, err := i.getChunkedDML(tt.query)
+		assert.NoError(t, err, tt.query)
+		assert.Equal(t, tt.chunked, dml != nil, tt.query)
+	}
+
+	// chunked DML is disabled by default.
+	i := newChunkTestInspect(1000, "delete from exist_db.exist_tb_1")
+	i.cnf.DMLChunkMinRows = -1
+	dml, err := i.getChunkedDML("delete from exist_db.exist_tb_1")
+	assert.NoError(t, err)
+	assert.Nil(t, dml)
+}
+
+func TestChunkedDML_chunkSQL(t *testing.T) {
+	query := "update exist_db.exist_tb_1 set v2 = 'b' where v1 = 'a' or v2 is null"
+	dml, err := newChunkTestInspect(1000, query).getChunkedDML(query)
+	assert.NoError(t, err)
+
+	sql, err := dml.chunkSQL(">=", "1", "10")
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE `exist_db`.`exist_tb_1` SET `v2`='b' WHERE (`v1`='a' OR `v2` IS NULL) AND `id`>=1 AND `id`<=10", sql)
+
+	sql, err = dml.chunkSQL(">", "10", "20")
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE `exist_db`.`exist_tb_1` SET `v2`='b' WHERE (`v1`='a' OR `v2` IS NULL) AND `id`>10 AND `id`<=20", sql)
+
+	query = "delete from exist_db.exist_tb_1"
+	dml, err = newChunkTestInspect(1000, query).getChunkedDML(query)
+	assert.NoError(t, err)
+	sql, err = dml.chunkSQL(">=", "1", "10")
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM `exist_db`.`exist_tb_1` WHERE `id`>=1 AND `id`<=10", sql)
+}
+
+func TestInspect_Exec_chunked(t *testing.T) {
+	query := "delete from exist_db.exist_tb_1 where v1 = 'a'"
+	i := newChunkTestInspect(1000, query)
+
+	mock := mockInspectDbConn(t, i)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT MIN(`id`) AS min_value, MAX(`id`) AS max_value FROM `exist_db`.`exist_tb_1`")).
+		WillReturnRows(sqlmock.NewRows([]string{"min_value", "max_value"}).AddRow("1", "25"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` AS value FROM `exist_db`.`exist_tb_1` WHERE `id` >= 1 AND `id` <= 25 ORDER BY `id` LIMIT 1 OFFSET 9")).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("10"))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `exist_db`.`exist_tb_1` WHERE (`v1`='a') AND `id`>=1 AND `id`<=10")).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` AS value FROM `exist_db`.`exist_tb_1` WHERE `id` > 10 AND `id` <= 25 ORDER BY `id` LIMIT 1 OFFSET 9")).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("20"))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `exist_db`.`exist_tb_1` WHERE (`v1`='a') AND `id`>10 AND `id`<=20")).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` AS value FROM `exist_db`.`exist_tb_1` WHERE `id` > 20 AND `id` <= 25 ORDER BY `id` LIMIT 1 OFFSET 9")).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `exist_db`.`exist_tb_1` WHERE (`v1`='a') AND `id`>20 AND `id`<=25")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var progress []string
+	ctx := driver.WithProgress(context.Background(), func(index int, p string) {
+		assert.Equal(t, 0, index)
+		progress = append(progress, p)
+	})
+	result, err := i.Exec(ctx, query)
+	assert.NoError(t, err)
+	rowAffects, _ := result.RowsAffected()
+	assert.Equal(t, int64(9), rowAffects)
+	assert.Equal(t, []string{
+		"chunk 1 is done, primary key 10/25 (37.50%), 5 rows affected",
+		"chunk 2 is done, primary key 20/25 (79.17%), 8 rows affected",
+		"chunk 3 is done, primary key 25/25 (100.00%), 9 rows affected",
+	}, progress)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInspect_Tx_chunked(t *testing.T) {
+	query := "delete from exist_db.exist_tb_1 where v1 = 'a'"
+	other := "delete from exist_db.exist_tb_1 where v1 = 'b'"
+	i := newChunkTestInspect(1000, query)
+	mock := mockInspectDbConn(t, i)
+
+	// the DML is not executed in chunks in the transaction with other queries.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 9))
+	mock.ExpectExec(regexp.QuoteMeta(other)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	results, err := i.Tx(context.Background(), query, other)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// the DML is executed in chunks if it's the only query.
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT MIN(`id`) AS min_value, MAX(`id`) AS max_value FROM `exist_db`.`exist_tb_1`")).
+		WillReturnRows(sqlmock.NewRows([]string{"min_value", "max_value"}).AddRow("1", "5"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` AS value FROM `exist_db`.`exist_tb_1` WHERE `id` >= 1 AND `id` <= 5 ORDER BY `id` LIMIT 1 OFFSET 9")).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `exist_db`.`exist_tb_1` WHERE (`v1`='a') AND `id`>=1 AND `id`<=5")).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	results, err = i.Tx(context.Background(), query)
+	assert.NoError(t, err)
+	rowAffects, _ := results[0].RowsAffected()
+	assert.Equal(t, int64(3), rowAffects)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInspect_waitForReplicas(t *testing.T) {
+	i := newChunkTestInspect(1000)
+	i.cnf.DMLChunkMaxReplicaLag = 10
+
+	replica := DefaultMysqlInspect()
+	mock := mockInspectDbConn(t, replica)
+	lagChecker := &replicaLagChecker{
+		log:      log.NewEntry(),
+		replicas: map[string]*Executor{"127.0.0.1:3307": replica.dbConn},
+	}
+	defer func(interval time.Duration, maxErrors int) {
+		replicaLagCheckInterval, replicaLagMaxErrors = interval, maxErrors
+	}(replicaLagCheckInterval, replicaLagMaxErrors)
+	replicaLagCheckInterval, replicaLagMaxErrors = time.Millisecond, 3
+
+	// the errors are not in succession.
+	mock.ExpectQuery("SHOW SLAVE STATUS").WillReturnError(fmt.Errorf("connection refused"))
+	mock.ExpectQuery("SHOW SLAVE STATUS").WillReturnError(fmt.Errorf("connection refused"))
+	mock.ExpectQuery("SHOW SLAVE STATUS").WillReturnRows(sqlmock.NewRows([]string{"Seconds_Behind_Master"}).AddRow("20"))
+	mock.ExpectQuery("SHOW SLAVE STATUS").WillReturnError(fmt.Errorf("connection refused"))
+	mock.ExpectQuery("SHOW SLAVE STATUS").WillReturnRows(sqlmock.NewRows([]string{"Seconds_Behind_Master"}).AddRow("5"))
+	assert.NoError(t, i.waitForReplicas(context.Background(), 0, lagChecker))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	for n := 0; n < 3; n++ {
+		mock.ExpectQuery("SHOW SLAVE STATUS").WillReturnError(fmt.Errorf("connection refused"))
+	}
+	err := i.waitForReplicas(context.Background(), 0, lagChecker)
+	assert.EqualError(t, err, "check replication lag failed 3 times: connection refused")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
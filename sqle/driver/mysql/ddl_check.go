@@ -0,0 +1,198 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pkg/errors"
+)
+
+const (
+	DDLCheckActionWait  = "wait"
+	DDLCheckActionAbort = "abort"
+	DDLCheckActionWarn  = "warn"
+)
+
+// ddlCheckInterval is the interval to check again when the action is wait.
+var ddlCheckInterval = 5 * time.Second
+
+// checkBeforeDDL checks the long transactions and queries which hold the metadata
+// lock of the changed tables, and the replication lag before executing the DDL which
+// changes the table. If the check is not passed, it waits until passed, returns error
+// or only warns, it depends on the config.
+func (i *Inspect) checkBeforeDDL(ctx context.Context, query string) error {
+	if i.cnf.DDLCheckMaxTrxTime < 0 && i.cnf.DDLCheckMaxReplicaLag < 0 {
+		return nil
+	}
+	node, err := parseOneSql(query)
+	if err != nil {
+		return nil
+	}
+	var tables []*ast.TableName
+	switch stmt := node.(type) {
+	case *ast.AlterTableStmt:
+		tables = []*ast.TableName{stmt.Table}
+	case *ast.CreateIndexStmt:
+		tables = []*ast.TableName{stmt.Table}
+	case *ast.DropIndexStmt:
+		tables = []*ast.TableName{stmt.Table}
+	case *ast.DropTableStmt:
+		tables = stmt.Tables
+	case *ast.TruncateTableStmt:
+		tables = []*ast.TableName{stmt.Table}
+	case *ast.RenameTableStmt:
+		for _, t2t := range stmt.TableToTables {
+			tables = append(tables, t2t.OldTable)
+		}
+	default:
+		return nil
+	}
+
+	trxChecker := &ddlTrxChecker{tables: tables}
+	if i.cnf.DDLCheckMaxTrxTime >= 0 {
+		if trxChecker.mdlInstrumented, err = i.isMDLInstrumented(); err != nil {
+			return errors.Wrap(err, "check before DDL")
+		}
+		if !trxChecker.mdlInstrumented {
+			i.log.Warnf("metadata lock of performance_schema is not instrumented, " +
+				"transactions and queries on all tables are checked before DDL")
+		}
+	}
+
+	var lagChecker *replicaLagChecker
+	if i.cnf.DDLCheckMaxReplicaLag >= 0 {
+		if lagChecker, err = i.newReplicaLagChecker(); err != nil {
+			return errors.Wrap(err, "check replication lag before DDL")
+		}
+		defer lagChecker.Close()
+	}
+
+	deadline := time.Now().Add(time.Duration(i.cnf.DDLCheckWaitTimeout) * time.Second)
+	for {
+		reasons, err := i.getDDLCheckFailedReasons(trxChecker, lagChecker)
+		if err != nil {
+			return errors.Wrap(err, "check before DDL")
+		}
+		if len(reasons) == 0 {
+			return nil
+		}
+		reason := strings.Join(reasons, "; ")
+
+		switch i.cnf.DDLCheckAction {
+		case DDLCheckActionWarn:
+			i.log.Warnf("check before DDL is not passed, DDL is still executed: %s", reason)
+			driver.ReportProgress(ctx, 0, fmt.Sprintf("check before DDL is not passed: %s", reason))
+			return nil
+		case DDLCheckActionAbort:
+			return fmt.Errorf("check before DDL is not passed: %s", reason)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("check before DDL is not passed after waiting %ds: %s",
+				i.cnf.DDLCheckWaitTimeout, reason)
+		}
+		i.log.Infof("wait for check before DDL: %s", reason)
+		driver.ReportProgress(ctx, 0, fmt.Sprintf("wait for check before DDL: %s", reason))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ddlCheckInterval):
+		}
+	}
+}
+
+// ddlTrxChecker checks the long transactions and queries on the tables changed by DDL.
+type ddlTrxChecker struct {
+	tables []*ast.TableName
+	// mdlInstrumented is false if the metadata lock of performance_schema is not
+	// instrumented, then the transactions and queries on all tables are checked.
+	mdlInstrumented bool
+}
+
+// isMDLInstrumented returns true if the metadata locks can be got from
+// performance_schema.metadata_locks.
+func (i *Inspect) isMDLInstrumented() (bool, error) {
+	conn, err := i.getDbConn()
+	if err != nil {
+		return false, err
+	}
+	result, err := conn.Db.Query(`SELECT ENABLED AS enabled FROM performance_schema.setup_instruments
+WHERE NAME = 'wait/lock/metadata/sql/mdl'`)
+	if err != nil {
+		return false, err
+	}
+	return len(result) > 0 && result[0]["enabled"].String == "YES", nil
+}
+
+// lockFilter returns the join and condition which filter the threads holding or
+// waiting the metadata lock of the tables, the thread id is the column of threadId.
+func (c *ddlTrxChecker) lockFilter(i *Inspect, threadId string) (join, cond string, args []interface{}) {
+	if !c.mdlInstrumented {
+		return "", "", nil
+	}
+	tables := make([]string, 0, len(c.tables))
+	for _, table := range c.tables {
+		tables = append(tables, "(?, ?)")
+		args = append(args, i.getSchemaName(table), table.Name.O)
+	}
+	join = fmt.Sprintf(`
+JOIN performance_schema.threads AS t ON t.PROCESSLIST_ID = %s
+JOIN performance_schema.metadata_locks AS ml ON ml.OWNER_THREAD_ID = t.THREAD_ID`, threadId)
+	cond = fmt.Sprintf(`
+AND ml.OBJECT_TYPE = 'TABLE' AND (ml.OBJECT_SCHEMA, ml.OBJECT_NAME) IN (%s)`, strings.Join(tables, ", "))
+	return join, cond, args
+}
+
+// getDDLCheckFailedReasons returns the reasons why the DDL should not be executed now.
+func (i *Inspect) getDDLCheckFailedReasons(trxChecker *ddlTrxChecker, lagChecker *replicaLagChecker) ([]string, error) {
+	reasons := []string{}
+	if i.cnf.DDLCheckMaxTrxTime >= 0 {
+		conn, err := i.getDbConn()
+		if err != nil {
+			return nil, err
+		}
+		join, cond, args := trxChecker.lockFilter(i, "trx.trx_mysql_thread_id")
+		trxs, err := conn.Db.Query(fmt.Sprintf(`SELECT DISTINCT trx.trx_mysql_thread_id AS thread_id,
+TIMESTAMPDIFF(SECOND, trx.trx_started, NOW()) AS trx_time, p.USER AS user, p.HOST AS host
+FROM information_schema.innodb_trx AS trx%s
+LEFT JOIN information_schema.processlist AS p ON trx.trx_mysql_thread_id = p.ID
+WHERE trx.trx_started < NOW() - INTERVAL %d SECOND AND trx.trx_mysql_thread_id != CONNECTION_ID()%s`,
+			join, i.cnf.DDLCheckMaxTrxTime, cond), args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, trx := range trxs {
+			reasons = append(reasons, fmt.Sprintf("transaction of thread %s(%s@%s) is running for %ss",
+				trx["thread_id"].String, trx["user"].String, trx["host"].String, trx["trx_time"].String))
+		}
+
+		join, cond, args = trxChecker.lockFilter(i, "p.ID")
+		queries, err := conn.Db.Query(fmt.Sprintf(`SELECT DISTINCT p.ID AS thread_id, p.USER AS user,
+p.HOST AS host, p.TIME AS query_time, p.STATE AS state FROM information_schema.processlist AS p%s
+WHERE p.COMMAND NOT IN ('Sleep', 'Daemon', 'Binlog Dump', 'Binlog Dump GTID') AND p.USER != 'system user'
+AND p.TIME > %d AND p.ID != CONNECTION_ID()%s`, join, i.cnf.DDLCheckMaxTrxTime, cond), args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, query := range queries {
+			reasons = append(reasons, fmt.Sprintf("query of thread %s(%s@%s) is running for %ss, state: %s",
+				query["thread_id"].String, query["user"].String, query["host"].String,
+				query["query_time"].String, query["state"].String))
+		}
+	}
+
+	if lagChecker != nil {
+		lag, replica, err := lagChecker.MaxLag()
+		if err != nil {
+			reasons = append(reasons, err.Error())
+		} else if lag > i.cnf.DDLCheckMaxReplicaLag {
+			reasons = append(reasons, fmt.Sprintf("replication lag of replica %s is %ds", replica, lag))
+		}
+	}
+	return reasons, nil
+}
@@ -0,0 +1,100 @@
+package mysql
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect_checkBeforeDDL(t *testing.T) {
+	origin := ddlCheckInterval
+	ddlCheckInterval = time.Millisecond
+	defer func() { ddlCheckInterval = origin }()
+
+	expectInstrument := func(mock sqlmock.Sqlmock, enabled string) {
+		mock.ExpectQuery(regexp.QuoteMeta("FROM performance_schema.setup_instruments")).
+			WillReturnRows(sqlmock.NewRows([]string{"enabled"}).AddRow(enabled))
+	}
+	expectTrxQuery := func(mock sqlmock.Sqlmock, long bool) {
+		trxRows := sqlmock.NewRows([]string{"thread_id", "trx_time", "user", "host"})
+		if long {
+			trxRows.AddRow("10", "120", "app", "10.0.0.1:3306")
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.innodb_trx")+"(?s).*"+
+			regexp.QuoteMeta("(ml.OBJECT_SCHEMA, ml.OBJECT_NAME) IN ((?, ?))")).
+			WithArgs("exist_db", "exist_tb_1").WillReturnRows(trxRows)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.processlist")+"(?s).*"+
+			regexp.QuoteMeta("(ml.OBJECT_SCHEMA, ml.OBJECT_NAME) IN ((?, ?))")).
+			WithArgs("exist_db", "exist_tb_1").
+			WillReturnRows(sqlmock.NewRows([]string{"thread_id", "user", "host", "query_time", "state"}))
+	}
+	newInspect := func(action string) (*Inspect, sqlmock.Sqlmock) {
+		i := DefaultMysqlInspect()
+		i.cnf.DDLCheckMaxTrxTime = 60
+		i.cnf.DDLCheckMaxReplicaLag = -1
+		i.cnf.DDLCheckAction = action
+		i.cnf.DDLCheckWaitTimeout = 10
+		return i, mockInspectDbConn(t, i)
+	}
+	query := "alter table exist_db.exist_tb_1 add column v3 int"
+	reason := "transaction of thread 10(app@10.0.0.1:3306) is running for 120s"
+
+	// abort
+	i, mock := newInspect(DDLCheckActionAbort)
+	expectInstrument(mock, "YES")
+	expectTrxQuery(mock, true)
+	assert.EqualError(t, i.checkBeforeDDL(context.Background(), query), "check before DDL is not passed: "+reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// warn
+	i, mock = newInspect(DDLCheckActionWarn)
+	expectInstrument(mock, "YES")
+	expectTrxQuery(mock, true)
+	var progress []string
+	ctx := driver.WithProgress(context.Background(), func(index int, p string) {
+		progress = append(progress, p)
+	})
+	assert.NoError(t, i.checkBeforeDDL(ctx, query))
+	assert.Equal(t, []string{"check before DDL is not passed: " + reason}, progress)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// wait until the long transaction is finished.
+	i, mock = newInspect(DDLCheckActionWait)
+	expectInstrument(mock, "YES")
+	expectTrxQuery(mock, true)
+	expectTrxQuery(mock, false)
+	progress = nil
+	assert.NoError(t, i.checkBeforeDDL(ctx, query))
+	assert.Equal(t, []string{"wait for check before DDL: " + reason}, progress)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// wait timeout
+	i, mock = newInspect(DDLCheckActionWait)
+	i.cnf.DDLCheckWaitTimeout = 0
+	expectInstrument(mock, "YES")
+	expectTrxQuery(mock, true)
+	assert.EqualError(t, i.checkBeforeDDL(context.Background(), query),
+		"check before DDL is not passed after waiting 0s: "+reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// the transactions on all tables are checked if metadata lock is not instrumented.
+	i, mock = newInspect(DDLCheckActionAbort)
+	expectInstrument(mock, "NO")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.innodb_trx")).WithArgs().
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "trx_time", "user", "host"}).
+			AddRow("10", "120", "app", "10.0.0.1:3306"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.processlist")).WithArgs().
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "user", "host", "query_time", "state"}))
+	assert.EqualError(t, i.checkBeforeDDL(context.Background(), query), "check before DDL is not passed: "+reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// DML is not checked.
+	i, mock = newInspect(DDLCheckActionAbort)
+	assert.NoError(t, i.checkBeforeDDL(context.Background(), "update exist_db.exist_tb_1 set v1 = 'a'"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
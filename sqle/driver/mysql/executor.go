@@ -22,8 +22,11 @@ type Db interface {
 	Close()
 	Ping() error
 	Exec(query string) (driver.Result, error)
+	ExecContext(ctx context.Context, query string) (driver.Result, error)
 	Transact(qs ...string) ([]driver.Result, error)
+	TransactContext(ctx context.Context, qs ...string) ([]driver.Result, error)
 	Query(query string, args ...interface{}) ([]map[string]sql.NullString, error)
+	QueryRows(fn func(row map[string]sql.NullString) error, query string, args ...interface{}) error
 	Logger() *logrus.Entry
 }
 
@@ -34,13 +37,18 @@ type BaseConn struct {
 	user string
 	db   *sql.DB
 	conn *sql.Conn
+
+	// dsn and connId are used to kill the query running on conn.
+	dsn    string
+	connId int64
 }
 
 func newConn(entry *logrus.Entry, instance *mdriver.DSN, schema string) (*BaseConn, error) {
 	var db *sql.DB
 	var err error
-	db, err = sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?timeout=%s&charset=utf8&parseTime=True&loc=Local",
-		instance.User, instance.Password, instance.Host, instance.Port, schema, DAIL_TIMEOUT))
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?timeout=%s&charset=utf8&parseTime=True&loc=Local",
+		instance.User, instance.Password, instance.Host, instance.Port, schema, DAIL_TIMEOUT)
+	db, err = sql.Open("mysql", dsn)
 	if err != nil {
 		entry.Error(err)
 		return nil, errors.New(errors.ConnectRemoteDatabaseError, err)
@@ -56,13 +64,21 @@ func newConn(entry *logrus.Entry, instance *mdriver.DSN, schema string) (*BaseCo
 		return nil, errors.New(errors.ConnectRemoteDatabaseError, err)
 	}
 	entry.Infof("connected to %s:%s", instance.Host, instance.Port)
+
+	var connId int64
+	if err := conn.QueryRowContext(context.Background(), "SELECT CONNECTION_ID()").Scan(&connId); err != nil {
+		// the running query can not be killed without connection id, but it's not fatal.
+		entry.Warnf("get connection id error: %v", err)
+	}
 	return &BaseConn{
-		log:  entry,
-		host: instance.Host,
-		port: instance.Port,
-		user: instance.User,
-		db:   db,
-		conn: conn,
+		log:    entry,
+		host:   instance.Host,
+		port:   instance.Port,
+		user:   instance.User,
+		db:     db,
+		conn:   conn,
+		dsn:    dsn,
+		connId: connId,
 	}, nil
 }
 
@@ -85,7 +101,20 @@ func (c *BaseConn) Ping() error {
 }
 
 func (c *BaseConn) Exec(query string) (driver.Result, error) {
+	return c.ExecContext(context.Background(), query)
+}
+
+// ExecContext executes the query, the query will be killed if ctx is cancelled.
+func (c *BaseConn) ExecContext(ctx context.Context, query string) (driver.Result, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	stop := c.killQueryOnCancel(ctx)
 	result, err := c.conn.ExecContext(context.Background(), query)
+	stop()
+	if err != nil && ctx.Err() != nil {
+		err = fmt.Errorf("%v: %v", ctx.Err(), err)
+	}
 	if err != nil {
 		c.Logger().Errorf("exec sql failed; host: %s, port: %s, user: %s, query: %s, error: %s",
 			c.host, c.port, c.user, query, err.Error())
@@ -97,10 +126,18 @@ func (c *BaseConn) Exec(query string) (driver.Result, error) {
 }
 
 func (c *BaseConn) Transact(qs ...string) ([]driver.Result, error) {
+	return c.TransactContext(context.Background(), qs...)
+}
+
+// TransactContext executes the queries in one transaction, the running query will
+// be killed and the transaction will be rollbacked if ctx is cancelled.
+func (c *BaseConn) TransactContext(ctx context.Context, qs ...string) ([]driver.Result, error) {
 	var err error
 	var tx *sql.Tx
 	var results []driver.Result
 	c.Logger().Infof("doing sql transact, host: %s, port: %s, user: %s", c.host, c.port, c.user)
+	stop := c.killQueryOnCancel(ctx)
+	defer stop()
 	tx, err = c.conn.BeginTx(context.Background(), nil)
 	if err != nil {
 		return results, err
@@ -124,8 +161,16 @@ func (c *BaseConn) Transact(qs ...string) ([]driver.Result, error) {
 		}
 	}()
 	for _, query := range qs {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			c.Logger().Errorf("transact is cancelled before query: %s", query)
+			return results, err
+		}
 		var txResult driver.Result
 		txResult, err = tx.Exec(query)
+		if err != nil && ctx.Err() != nil {
+			err = fmt.Errorf("%v: %v", ctx.Err(), err)
+		}
 		if err != nil {
 			c.Logger().Errorf("exec sql failed, error: %s, query: %s", err, query)
 			return results, err
@@ -138,11 +183,25 @@ func (c *BaseConn) Transact(qs ...string) ([]driver.Result, error) {
 }
 
 func (c *BaseConn) Query(query string, args ...interface{}) ([]map[string]sql.NullString, error) {
+	result := make([]map[string]sql.NullString, 0)
+	err := c.QueryRows(func(row map[string]sql.NullString) error {
+		result = append(result, row)
+		return nil
+	}, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryRows calls fn with each row fetched from the cursor, so the rows are not kept in
+// memory. It stops and returns the error if fn returns error.
+func (c *BaseConn) QueryRows(fn func(row map[string]sql.NullString) error, query string, args ...interface{}) error {
 	rows, err := c.conn.QueryContext(context.Background(), query, args...)
 	if err != nil {
 		c.Logger().Errorf("query sql failed; host: %s, port: %s, user: %s, query: %s, error: %s\n",
 			c.host, c.port, c.user, query, err.Error())
-		return nil, errors.New(errors.ConnectRemoteDatabaseError, err)
+		return errors.New(errors.ConnectRemoteDatabaseError, err)
 	} else {
 		c.Logger().Infof("query sql success; host: %s, port: %s, user: %s, query: %s\n",
 			c.host, c.port, c.user, query)
@@ -152,9 +211,8 @@ func (c *BaseConn) Query(query string, args ...interface{}) ([]map[string]sql.Nu
 	if err != nil {
 		// unknown error
 		c.Logger().Error(err)
-		return nil, err
+		return err
 	}
-	result := make([]map[string]sql.NullString, 0)
 	for rows.Next() {
 		buf := make([]interface{}, len(columns))
 		data := make([]sql.NullString, len(columns))
@@ -163,7 +221,7 @@ func (c *BaseConn) Query(query string, args ...interface{}) ([]map[string]sql.Nu
 		}
 		if err := rows.Scan(buf...); err != nil {
 			c.Logger().Error(err)
-			return nil, err
+			return err
 		}
 		value := make(map[string]sql.NullString, len(columns))
 		for i := 0; i < len(columns); i++ {
@@ -171,9 +229,68 @@ func (c *BaseConn) Query(query string, args ...interface{}) ([]map[string]sql.Nu
 			v := data[i]
 			value[k] = v
 		}
-		result = append(result, value)
+		if err := fn(value); err != nil {
+			return err
+		}
 	}
-	return result, nil
+	if err := rows.Err(); err != nil {
+		c.Logger().Error(err)
+		return errors.New(errors.ConnectRemoteDatabaseError, err)
+	}
+	return nil
+}
+
+// killQueryOnCancel kills the query running on the connection when ctx is cancelled,
+// the query is killed by another connection because the connection is busy. The kill
+// is retried until the query is done, in case the query is not started when it's killed.
+// The returned function must be called after the query is done.
+func (c *BaseConn) killQueryOnCancel(ctx context.Context) (stop func()) {
+	if ctx.Done() == nil || c.connId == 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	killed := make(chan struct{})
+	go func() {
+		defer close(killed)
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			if err := c.killQuery(); err != nil {
+				c.Logger().Errorf("kill query on connection %d failed, error: %v", c.connId, err)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-killed
+	}
+}
+
+func (c *BaseConn) killQuery() error {
+	db, err := sql.Open("mysql", c.dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), DAIL_TIMEOUT)
+	defer cancel()
+	_, err = db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", c.connId))
+	if err != nil {
+		return err
+	}
+	c.Logger().Infof("killed query on connection %d; host: %s, port: %s, user: %s", c.connId, c.host, c.port, c.user)
+	return nil
 }
 
 func (c *BaseConn) Logger() *logrus.Entry {
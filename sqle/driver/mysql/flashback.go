@@ -0,0 +1,583 @@
+package mysql
+
+import (
+	"context"
+	_driver "database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/actiontech/sqle/sqle/driver"
+
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/pingcap/parser/ast"
+	"github.com/pkg/errors"
+)
+
+// binlogReadTimeout is the max time to read the binlog of the executed DML.
+var binlogReadTimeout = time.Minute
+
+// flashback generates the rollback SQL of the executed DML from the row images in
+// binlog. The rollback is exact even if the DML changes multiple tables, has sub query
+// or changes the table without primary key, which can't be rollbacked by the records
+// queried before execution. It requires the binlog in ROW format with FULL row image,
+// and the user of instance must have the REPLICATION SLAVE privilege.
+type flashback struct {
+	i *Inspect
+
+	// offset is the index of the first query in the queries executed by Exec or Tx.
+	offset  int
+	queries []string
+	isDML   []bool
+
+	// connId is the id of connection which executes the queries, the transactions
+	// of other connections in binlog are skipped.
+	connId uint32
+	start  gomysql.Position
+	// skippedTables are the backup tables written in the transaction of queries, the
+	// key is "schema.table".
+	skippedTables map[string]struct{}
+
+	// timeZone is the time zone of the connection, the TIMESTAMP in binlog is in UTC
+	// and converted to it, as the value written by the queries.
+	timeZone *time.Location
+}
+
+// startFlashback records the binlog position before the queries are executed, it
+// returns nil if there is no DML or the rollback of DML is not generated from binlog.
+func (i *Inspect) startFlashback(ctx context.Context, offset int, queries ...string) *flashback {
+	if i.cnf.DMLRollbackBinlogMaxRows < 0 || driver.IsRawExec(ctx) {
+		return nil
+	}
+	f := &flashback{
+		i:       i,
+		offset:  offset,
+		queries: queries,
+		isDML:   make([]bool, len(queries)),
+	}
+	hasDML := false
+	for idx, query := range queries {
+		node, err := parseOneSql(query)
+		if err != nil {
+			continue
+		}
+		switch node.(type) {
+		case *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt:
+			f.isDML[idx] = true
+			hasDML = true
+		}
+	}
+	if !hasDML {
+		return nil
+	}
+	if err := f.recordStart(); err != nil {
+		i.log.Warnf("rollback of DML is not generated from binlog: %v", err)
+		return nil
+	}
+	return f
+}
+
+// skipBackups skips the rows copied to the backup tables in the transaction of queries,
+// they are not rollbacked.
+func (f *flashback) skipBackups(backups dmlBackups) {
+	if f == nil {
+		return
+	}
+	for _, b := range backups {
+		if f.skippedTables == nil {
+			f.skippedTables = map[string]struct{}{}
+		}
+		f.skippedTables[b.schema+"."+b.backupName] = struct{}{}
+	}
+}
+
+func (f *flashback) recordStart() error {
+	conn, err := f.i.getDbConn()
+	if err != nil {
+		return err
+	}
+	result, err := conn.Db.Query(`SELECT CONNECTION_ID() AS conn_id,
+@@SESSION.binlog_format AS binlog_format, @@SESSION.binlog_row_image AS binlog_row_image,
+@@SESSION.time_zone AS time_zone, TIMESTAMPDIFF(SECOND, UTC_TIMESTAMP(), NOW()) AS time_zone_offset`)
+	if err != nil {
+		return err
+	}
+	if len(result) == 0 {
+		return fmt.Errorf("connection id is not found")
+	}
+	if format := result[0]["binlog_format"].String; !strings.EqualFold(format, "ROW") {
+		return fmt.Errorf("binlog_format is %s, not ROW", format)
+	}
+	if image := result[0]["binlog_row_image"].String; !strings.EqualFold(image, "FULL") {
+		return fmt.Errorf("binlog_row_image is %s, not FULL", image)
+	}
+	connId, err := strconv.ParseUint(result[0]["conn_id"].String, 10, 32)
+	if err != nil {
+		return errors.Wrap(err, "parse connection id")
+	}
+	f.connId = uint32(connId)
+	if f.timeZone, err = parseTimeZone(result[0]["time_zone"].String, result[0]["time_zone_offset"].String); err != nil {
+		return err
+	}
+
+	file, pos, err := conn.FetchMasterBinlogPos()
+	if err != nil {
+		return errors.Wrap(err, "fetch binlog position")
+	}
+	if file == "" {
+		return fmt.Errorf("binlog is not enabled")
+	}
+	f.start = gomysql.Position{Name: file, Pos: uint32(pos)}
+	return nil
+}
+
+// finish reads the binlog of the executed queries and reports the rollback SQLs of
+// DML by driver.ReportExecRollback. Nothing is reported if the queries are failed
+// or the rollback can't be generated, the rollback SQLs generated before execution
+// are kept.
+func (f *flashback) finish(ctx context.Context, results []_driver.Result, execErr error) {
+	if f == nil || execErr != nil {
+		return
+	}
+	conn, err := f.i.getDbConn()
+	if err != nil {
+		f.i.log.Warnf("rollback of DML is not generated from binlog: %v", err)
+		return
+	}
+	file, pos, err := conn.FetchMasterBinlogPos()
+	if err != nil {
+		f.i.log.Warnf("rollback of DML is not generated from binlog, fetch binlog position error: %v", err)
+		return
+	}
+	end := gomysql.Position{Name: file, Pos: uint32(pos)}
+
+	rollbackSQLs, err := f.genRollbackSQLs(end, results)
+	if err != nil {
+		f.i.log.Warnf("rollback of DML is not generated from binlog %v to %v: %v", f.start, end, err)
+		return
+	}
+	for idx, rollbackSQL := range rollbackSQLs {
+		if !f.isDML[idx] {
+			continue
+		}
+		driver.ReportExecRollback(ctx, f.offset+idx, &driver.ExecRollback{
+			RollbackSQL: rollbackSQL,
+			StartBinlog: &driver.BinlogPos{File: f.start.Name, Pos: int64(f.start.Pos)},
+			EndBinlog:   &driver.BinlogPos{File: end.Name, Pos: int64(end.Pos)},
+		})
+	}
+}
+
+// genRollbackSQLs returns the rollback SQL of every query, it's generated from the
+// rows events between the start position and end.
+func (f *flashback) genRollbackSQLs(end gomysql.Position, results []_driver.Result) ([]string, error) {
+	statements := [][]*replication.BinlogEvent{}
+	if f.start.Compare(end) < 0 {
+		var err error
+		if statements, err = f.readStatements(end); err != nil {
+			return nil, errors.Wrap(err, "read binlog")
+		}
+	}
+	owners := f.getStatementOwners(statements, results)
+
+	tables := map[string]*flashbackTable{}
+	rollbacks := make([][]string, len(f.queries))
+	for idx, statement := range statements {
+		sqls, err := f.genStatementRollbackSQLs(statement, tables)
+		if err != nil {
+			return nil, err
+		}
+		owner := owners[idx]
+		rollbacks[owner] = append(sqls, rollbacks[owner]...)
+	}
+	rollbackSQLs := make([]string, len(f.queries))
+	for idx, sqls := range rollbacks {
+		rollbackSQLs[idx] = strings.Join(sqls, "\n")
+	}
+	return rollbackSQLs, nil
+}
+
+// getStatementOwners returns the index of query which writes the statement in binlog.
+// A DML writes one statement if it changes any rows, or nothing. The statements are
+// owned by the first DML if they don't match the queries, e.g. the chunked DML writes
+// a statement for every chunk, the rollback of all statements is still exact when
+// they are executed together.
+func (f *flashback) getStatementOwners(statements [][]*replication.BinlogEvent, results []_driver.Result) []int {
+	first := -1
+	changed := []int{}
+	for idx := range f.queries {
+		if !f.isDML[idx] {
+			continue
+		}
+		if first < 0 {
+			first = idx
+		}
+		if idx >= len(results) || results[idx] == nil {
+			continue
+		}
+		if rows, err := results[idx].RowsAffected(); err == nil && rows > 0 {
+			changed = append(changed, idx)
+		}
+	}
+
+	owners := make([]int, len(statements))
+	if len(changed) == len(statements) {
+		copy(owners, changed)
+		return owners
+	}
+	if len(changed) > 1 {
+		f.i.log.Warnf("%d statements in binlog don't match %d DML, their rollback is generated for the first DML",
+			len(statements), len(changed))
+	}
+	for idx := range owners {
+		owners[idx] = first
+	}
+	return owners
+}
+
+// parseTimeZone returns the location of the time_zone of MySQL, it's the fixed offset
+// to UTC if the time_zone is SYSTEM or not found in the time zone database of Go.
+func parseTimeZone(timeZone, offset string) (*time.Location, error) {
+	if timeZone != "SYSTEM" && !strings.HasPrefix(timeZone, "+") && !strings.HasPrefix(timeZone, "-") {
+		if loc, err := time.LoadLocation(timeZone); err == nil {
+			return loc, nil
+		}
+	}
+	seconds, err := strconv.Atoi(offset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse offset of time zone %s", timeZone)
+	}
+	return time.FixedZone(timeZone, seconds), nil
+}
+
+// binlogServerID returns the server id to read the binlog as a replica, which must be
+// unique among the replicas of the instance. It's derived from the connection id which
+// is unique on the instance while the connection is alive, so the flashbacks of the
+// connections don't collide with each other, and it's above 1<<31 to avoid the server
+// ids of the real replicas.
+func binlogServerID(connId uint32) uint32 {
+	return 1<<31 | connId&(1<<31-1)
+}
+
+// readStatements reads the binlog from the start position to end as a replica, and
+// returns the rows events written by the connection, which are grouped by statement.
+func (f *flashback) readStatements(end gomysql.Position) ([][]*replication.BinlogEvent, error) {
+	port, err := strconv.ParseUint(f.i.inst.Port, 10, 16)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse port %s", f.i.inst.Port)
+	}
+	syncer := replication.NewBinlogSyncer(replication.BinlogSyncerConfig{
+		ServerID:                binlogServerID(f.connId),
+		Flavor:                  gomysql.MySQLFlavor,
+		Host:                    f.i.inst.Host,
+		Port:                    uint16(port),
+		User:                    f.i.inst.User,
+		Password:                f.i.inst.Password,
+		UseDecimal:              true,
+		TimestampStringLocation: f.timeZone,
+		DisableRetrySync:        true,
+	})
+	defer syncer.Close()
+
+	streamer, err := syncer.StartSync(f.start)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), binlogReadTimeout)
+	defer cancel()
+
+	c := &binlogCollector{
+		connId:        f.connId,
+		file:          f.start.Name,
+		end:           end,
+		skippedTables: f.skippedTables,
+	}
+	for {
+		ev, err := streamer.GetEvent(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if c.collect(ev) {
+			return c.statements, nil
+		}
+		if c.rows > f.i.cnf.DMLRollbackBinlogMaxRows {
+			return nil, fmt.Errorf("changed rows are more than %d", f.i.cnf.DMLRollbackBinlogMaxRows)
+		}
+	}
+}
+
+// binlogCollector collects the rows events of the transactions executed by the
+// connection until the end position.
+type binlogCollector struct {
+	connId uint32
+	// file is the current binlog file.
+	file string
+	end  gomysql.Position
+	// skippedTables are the tables whose rows events are not collected, the key is
+	// "schema.table".
+	skippedTables map[string]struct{}
+
+	inTrx      bool
+	rows       int64
+	statement  []*replication.BinlogEvent
+	statements [][]*replication.BinlogEvent
+}
+
+// collect returns true if the end position is reached.
+func (c *binlogCollector) collect(ev *replication.BinlogEvent) bool {
+	switch e := ev.Event.(type) {
+	case *replication.RotateEvent:
+		c.file = string(e.NextLogName)
+		return false
+	case *replication.QueryEvent:
+		// the XA statements are logged with xid, e.g. XA START 'xid'.
+		query := strings.ToUpper(strings.TrimSpace(string(e.Query)))
+		switch {
+		case query == "BEGIN" || strings.HasPrefix(query, "XA START"):
+			// the thread id of the connection is recorded in the query event which
+			// starts the transaction.
+			c.inTrx = e.SlaveProxyID == c.connId
+		case query == "COMMIT" || query == "ROLLBACK" || strings.HasPrefix(query, "XA END"):
+			c.inTrx = false
+		}
+	case *replication.XIDEvent:
+		c.inTrx = false
+	case *replication.RowsEvent:
+		if !c.inTrx {
+			break
+		}
+		if _, ok := c.skippedTables[string(e.Table.Schema)+"."+string(e.Table.Table)]; ok {
+			break
+		}
+		switch ev.Header.EventType {
+		case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2:
+			c.rows += int64(len(e.Rows) / 2)
+		default:
+			c.rows += int64(len(e.Rows))
+		}
+		c.statement = append(c.statement, ev)
+		if e.Flags&replication.RowsEventStmtEndFlag != 0 {
+			c.statements = append(c.statements, c.statement)
+			c.statement = nil
+		}
+	}
+	return gomysql.Position{Name: c.file, Pos: ev.Header.LogPos}.Compare(c.end) >= 0
+}
+
+// genStatementRollbackSQLs returns the SQLs which rollback the rows events of the
+// statement in reverse order.
+func (f *flashback) genStatementRollbackSQLs(statement []*replication.BinlogEvent,
+	tables map[string]*flashbackTable) ([]string, error) {
+
+	sqls := []string{}
+	for idx := len(statement) - 1; idx >= 0; idx-- {
+		ev := statement[idx]
+		e, ok := ev.Event.(*replication.RowsEvent)
+		if !ok {
+			continue
+		}
+		table, err := f.getTable(string(e.Table.Schema), string(e.Table.Table), tables)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range e.Rows {
+			if len(row) != len(table.columns) {
+				return nil, fmt.Errorf("columns of table %s are changed after execution", table.name)
+			}
+		}
+
+		switch ev.Header.EventType {
+		case replication.WRITE_ROWS_EVENTv0, replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2:
+			for r := len(e.Rows) - 1; r >= 0; r-- {
+				where, err := table.where(e.Rows[r])
+				if err != nil {
+					return nil, err
+				}
+				sqls = append(sqls, fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT 1;", table.name, where))
+			}
+		case replication.DELETE_ROWS_EVENTv0, replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2:
+			for r := len(e.Rows) - 1; r >= 0; r-- {
+				sqls = append(sqls, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);",
+					table.name, strings.Join(table.columnNames(), ", "), strings.Join(table.values(e.Rows[r]), ", ")))
+			}
+		case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2:
+			// the rows of update event are pairs of before image and after image.
+			for r := len(e.Rows) - 2; r >= 0; r -= 2 {
+				before, after := table.values(e.Rows[r]), table.values(e.Rows[r+1])
+				set := []string{}
+				for c, column := range table.columns {
+					if before[c] != after[c] {
+						set = append(set, fmt.Sprintf("`%s` = %s", column.name, before[c]))
+					}
+				}
+				if len(set) == 0 {
+					continue
+				}
+				where, err := table.where(e.Rows[r+1])
+				if err != nil {
+					return nil, err
+				}
+				sqls = append(sqls, fmt.Sprintf("UPDATE %s SET %s WHERE %s LIMIT 1;",
+					table.name, strings.Join(set, ", "), where))
+			}
+		}
+	}
+	return sqls, nil
+}
+
+type flashbackColumn struct {
+	name     string
+	dataType string
+	unsigned bool
+	isPK     bool
+}
+
+type flashbackTable struct {
+	// name is the quoted name with schema.
+	name    string
+	columns []*flashbackColumn
+	hasPK   bool
+}
+
+func (f *flashback) getTable(schema, table string, tables map[string]*flashbackTable) (*flashbackTable, error) {
+	name := getTableNameWithQuote(newTableName(schema, table))
+	if t, ok := tables[name]; ok {
+		return t, nil
+	}
+	conn, err := f.i.getDbConn()
+	if err != nil {
+		return nil, err
+	}
+	result, err := conn.Db.Query(`SELECT COLUMN_NAME AS column_name, DATA_TYPE AS data_type,
+COLUMN_TYPE AS column_type, COLUMN_KEY AS column_key FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get columns of table %s", name)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("table %s is not exist", name)
+	}
+	t := &flashbackTable{name: name}
+	for _, row := range result {
+		column := &flashbackColumn{
+			name:     row["column_name"].String,
+			dataType: strings.ToLower(row["data_type"].String),
+			unsigned: strings.Contains(strings.ToLower(row["column_type"].String), "unsigned"),
+			isPK:     row["column_key"].String == "PRI",
+		}
+		t.hasPK = t.hasPK || column.isPK
+		t.columns = append(t.columns, column)
+	}
+	tables[name] = t
+	return t, nil
+}
+
+func (t *flashbackTable) columnNames() []string {
+	names := make([]string, 0, len(t.columns))
+	for _, column := range t.columns {
+		names = append(names, fmt.Sprintf("`%s`", column.name))
+	}
+	return names
+}
+
+func (t *flashbackTable) values(row []interface{}) []string {
+	values := make([]string, 0, len(row))
+	for idx, v := range row {
+		values = append(values, t.columns[idx].literal(v))
+	}
+	return values
+}
+
+// where returns the condition which matches the row by primary key. If the table has
+// no primary key, it matches all columns except the floating-point columns, whose
+// values may be not equal to the literal. It returns error if no column can match
+// the row, then the rollback SQL doesn't change an arbitrary row.
+func (t *flashbackTable) where(row []interface{}) (string, error) {
+	conditions := []string{}
+	for idx, column := range t.columns {
+		if t.hasPK && !column.isPK {
+			continue
+		}
+		if !t.hasPK && (column.dataType == "float" || column.dataType == "double") {
+			continue
+		}
+		if row[idx] == nil {
+			conditions = append(conditions, fmt.Sprintf("`%s` IS NULL", column.name))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("`%s` = %s", column.name, column.literal(row[idx])))
+		}
+	}
+	if len(conditions) == 0 {
+		return "", fmt.Errorf("table %s has no primary key and only floating-point columns, the row can't be matched", t.name)
+	}
+	return strings.Join(conditions, " AND "), nil
+}
+
+// literal returns the SQL literal of the value decoded from the rows event.
+func (c *flashbackColumn) literal(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case int8:
+		if c.unsigned {
+			return strconv.FormatUint(uint64(uint8(v)), 10)
+		}
+		return strconv.FormatInt(int64(v), 10)
+	case int16:
+		if c.unsigned {
+			return strconv.FormatUint(uint64(uint16(v)), 10)
+		}
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		if c.unsigned && v < 0 {
+			// MEDIUMINT is decoded as int32 from 3 bytes.
+			if c.dataType == "mediumint" {
+				return strconv.FormatInt(int64(v)+1<<24, 10)
+			}
+			return strconv.FormatUint(uint64(uint32(v)), 10)
+		}
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		if c.unsigned {
+			return strconv.FormatUint(uint64(v), 10)
+		}
+		return strconv.FormatInt(v, 10)
+	case int:
+		return strconv.Itoa(v)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return quoteLiteral(v, c.dataType == "json")
+	case []byte:
+		return quoteLiteral(string(v), c.dataType == "json")
+	case fmt.Stringer:
+		// DECIMAL is decoded as decimal.Decimal.
+		return v.String()
+	default:
+		return quoteLiteral(fmt.Sprintf("%v", v), false)
+	}
+}
+
+// quoteLiteral returns the quoted string literal, the hex literal is returned if the
+// value is not valid UTF-8, e.g. the value of BLOB, unless it's text.
+func quoteLiteral(v string, text bool) string {
+	if !text && !utf8.ValidString(v) {
+		return fmt.Sprintf("X'%s'", hex.EncodeToString([]byte(v)))
+	}
+	return fmt.Sprintf("'%s'", strings.NewReplacer(
+		`\`, `\\`,
+		`'`, `\'`,
+		"\x00", `\0`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\x1a", `\Z`,
+	).Replace(v))
+}
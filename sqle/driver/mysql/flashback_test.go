@@ -0,0 +1,299 @@
+package mysql
+
+import (
+	"context"
+	_driver "database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/actiontech/sqle/sqle/driver"
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlashbackTestInspect(t *testing.T) (*Inspect, sqlmock.Sqlmock) {
+	i := DefaultMysqlInspect()
+	i.cnf.DMLRollbackBinlogMaxRows = 1000
+	return i, mockInspectDbConn(t, i)
+}
+
+func TestInspect_startFlashback(t *testing.T) {
+	i, mock := newFlashbackTestInspect(t)
+	mock.ExpectQuery("SELECT CONNECTION_ID()").WillReturnRows(
+		sqlmock.NewRows([]string{"conn_id", "binlog_format", "binlog_row_image", "time_zone", "time_zone_offset"}).
+			AddRow("12", "ROW", "FULL", "SYSTEM", "28800"))
+	mock.ExpectQuery("show master status").WillReturnRows(
+		sqlmock.NewRows([]string{"File", "Position"}).AddRow("mysql-bin.000003", "1024"))
+	f := i.startFlashback(context.Background(), 1, "create table t1(id int)", "delete from exist_db.exist_tb_1 where id = 1")
+	assert.NotNil(t, f)
+	assert.Equal(t, uint32(12), f.connId)
+	assert.Equal(t, gomysql.Position{Name: "mysql-bin.000003", Pos: 1024}, f.start)
+	assert.Equal(t, "2021-01-01 08:00:00", time.Unix(1609459200, 0).In(f.timeZone).Format("2006-01-02 15:04:05"))
+	assert.Equal(t, []bool{false, true}, f.isDML)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// binlog is not in ROW format.
+	i, mock = newFlashbackTestInspect(t)
+	mock.ExpectQuery("SELECT CONNECTION_ID()").WillReturnRows(
+		sqlmock.NewRows([]string{"conn_id", "binlog_format", "binlog_row_image"}).AddRow("12", "STATEMENT", "FULL"))
+	assert.Nil(t, i.startFlashback(context.Background(), 0, "delete from exist_db.exist_tb_1 where id = 1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// no DML.
+	i, mock = newFlashbackTestInspect(t)
+	assert.Nil(t, i.startFlashback(context.Background(), 0, "create table t1(id int)"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// executed as it is.
+	i, mock = newFlashbackTestInspect(t)
+	assert.Nil(t, i.startFlashback(driver.WithRawExec(context.Background()), 0,
+		"delete from exist_db.exist_tb_1 where id = 1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// disabled.
+	i, mock = newFlashbackTestInspect(t)
+	i.cnf.DMLRollbackBinlogMaxRows = -1
+	assert.Nil(t, i.startFlashback(context.Background(), 0, "delete from exist_db.exist_tb_1 where id = 1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParseTimeZone(t *testing.T) {
+	ts := time.Unix(1609459200, 0) // 2021-01-01 00:00:00 UTC
+	for _, tt := range []struct {
+		timeZone, offset, want string
+	}{
+		{"SYSTEM", "28800", "2021-01-01 08:00:00"},
+		{"+08:00", "28800", "2021-01-01 08:00:00"},
+		{"-05:00", "-18000", "2020-12-31 19:00:00"},
+		{"America/New_York", "-14400", "2020-12-31 19:00:00"},
+	} {
+		loc, err := parseTimeZone(tt.timeZone, tt.offset)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, ts.In(loc).Format("2006-01-02 15:04:05"), tt.timeZone)
+	}
+	_, err := parseTimeZone("SYSTEM", "")
+	assert.Error(t, err)
+}
+
+func TestBinlogServerID(t *testing.T) {
+	assert.Equal(t, uint32(1<<31+12), binlogServerID(12))
+	assert.Equal(t, uint32(1<<31+12), binlogServerID(1<<31+12))
+	assert.NotEqual(t, binlogServerID(12), binlogServerID(13))
+}
+
+func newTestRowsEvent(eventType replication.EventType, logPos uint32, table string, stmtEnd bool,
+	rows ...[]interface{}) *replication.BinlogEvent {
+	var flags uint16
+	if stmtEnd {
+		flags = replication.RowsEventStmtEndFlag
+	}
+	return &replication.BinlogEvent{
+		Header: &replication.EventHeader{EventType: eventType, LogPos: logPos},
+		Event: &replication.RowsEvent{
+			Table: &replication.TableMapEvent{Schema: []byte("exist_db"), Table: []byte(table)},
+			Flags: flags,
+			Rows:  rows,
+		},
+	}
+}
+
+func newTestQueryEvent(logPos, connId uint32, query string) *replication.BinlogEvent {
+	return &replication.BinlogEvent{
+		Header: &replication.EventHeader{EventType: replication.QUERY_EVENT, LogPos: logPos},
+		Event:  &replication.QueryEvent{SlaveProxyID: connId, Query: []byte(query)},
+	}
+}
+
+func newTestXIDEvent(logPos uint32) *replication.BinlogEvent {
+	return &replication.BinlogEvent{
+		Header: &replication.EventHeader{EventType: replication.XID_EVENT, LogPos: logPos},
+		Event:  &replication.XIDEvent{},
+	}
+}
+
+func TestBinlogCollector_collect(t *testing.T) {
+	c := &binlogCollector{
+		connId: 12,
+		file:   "mysql-bin.000003",
+		end:    gomysql.Position{Name: "mysql-bin.000004", Pos: 500},
+	}
+	events := []*replication.BinlogEvent{
+		// transaction of other connection.
+		newTestQueryEvent(1100, 13, "BEGIN"),
+		newTestRowsEvent(replication.DELETE_ROWS_EVENTv2, 1200, "exist_tb_1", true, []interface{}{int32(1)}),
+		newTestXIDEvent(1300),
+		{
+			Header: &replication.EventHeader{EventType: replication.ROTATE_EVENT},
+			Event:  &replication.RotateEvent{NextLogName: []byte("mysql-bin.000004")},
+		},
+		newTestQueryEvent(200, 12, "BEGIN"),
+		newTestRowsEvent(replication.UPDATE_ROWS_EVENTv2, 250, "exist_tb_1", false,
+			[]interface{}{int32(1)}, []interface{}{int32(2)}),
+		newTestRowsEvent(replication.UPDATE_ROWS_EVENTv2, 300, "exist_tb_2", true,
+			[]interface{}{int32(1)}, []interface{}{int32(2)}),
+		newTestRowsEvent(replication.DELETE_ROWS_EVENTv2, 400, "exist_tb_1", true,
+			[]interface{}{int32(3)}, []interface{}{int32(4)}),
+	}
+	for _, ev := range events {
+		assert.False(t, c.collect(ev))
+	}
+	assert.True(t, c.collect(newTestXIDEvent(500)))
+
+	assert.Equal(t, [][]*replication.BinlogEvent{events[5:7], events[7:8]}, c.statements)
+	assert.Equal(t, int64(4), c.rows)
+
+	// the rows copied to the backup table are skipped.
+	f := &flashback{}
+	f.skipBackups(dmlBackups{{schema: "exist_db", backupName: "_exist_tb_1_bak"}})
+	c = &binlogCollector{
+		connId:        12,
+		file:          "mysql-bin.000004",
+		end:           gomysql.Position{Name: "mysql-bin.000004", Pos: 500},
+		skippedTables: f.skippedTables,
+	}
+	events = []*replication.BinlogEvent{
+		newTestQueryEvent(200, 12, "BEGIN"),
+		newTestRowsEvent(replication.WRITE_ROWS_EVENTv2, 300, "_exist_tb_1_bak", true, []interface{}{int32(1)}),
+		newTestRowsEvent(replication.DELETE_ROWS_EVENTv2, 400, "exist_tb_1", true, []interface{}{int32(1)}),
+	}
+	for _, ev := range events {
+		assert.False(t, c.collect(ev))
+	}
+	assert.True(t, c.collect(newTestXIDEvent(500)))
+	assert.Equal(t, [][]*replication.BinlogEvent{events[2:3]}, c.statements)
+	assert.Equal(t, int64(1), c.rows)
+
+	// the XA statements are logged with xid.
+	c = &binlogCollector{
+		connId: 12,
+		file:   "mysql-bin.000004",
+		end:    gomysql.Position{Name: "mysql-bin.000004", Pos: 600},
+	}
+	events = []*replication.BinlogEvent{
+		newTestQueryEvent(200, 12, "XA START X'78696431',X'',1"),
+		newTestRowsEvent(replication.DELETE_ROWS_EVENTv2, 300, "exist_tb_1", true, []interface{}{int32(1)}),
+		newTestQueryEvent(400, 12, "XA END X'78696431',X'',1"),
+		newTestRowsEvent(replication.DELETE_ROWS_EVENTv2, 500, "exist_tb_1", true, []interface{}{int32(2)}),
+	}
+	for _, ev := range events {
+		assert.False(t, c.collect(ev))
+	}
+	assert.True(t, c.collect(newTestQueryEvent(600, 12, "XA COMMIT X'78696431',X'',1")))
+	assert.Equal(t, [][]*replication.BinlogEvent{events[1:2]}, c.statements)
+	assert.Equal(t, int64(1), c.rows)
+}
+
+func mockFlashbackTable(mock sqlmock.Sqlmock, table string, columns ...[]_driver.Value) {
+	rows := sqlmock.NewRows([]string{"column_name", "data_type", "column_type", "column_key"})
+	for _, column := range columns {
+		rows.AddRow(column...)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.COLUMNS")).
+		WithArgs("exist_db", table).WillReturnRows(rows)
+}
+
+func TestFlashback_genStatementRollbackSQLs(t *testing.T) {
+	i, mock := newFlashbackTestInspect(t)
+	f := &flashback{i: i}
+	// the events are rollbacked in reverse order.
+	mockFlashbackTable(mock, "exist_tb_2",
+		[]_driver.Value{"v1", "varchar", "varchar(255)", ""},
+		[]_driver.Value{"v2", "double", "double", ""},
+	)
+	mockFlashbackTable(mock, "exist_tb_1",
+		[]_driver.Value{"id", "int", "int(10) unsigned", "PRI"},
+		[]_driver.Value{"v1", "varchar", "varchar(255)", ""},
+		[]_driver.Value{"v2", "blob", "blob", ""},
+	)
+	tables := map[string]*flashbackTable{}
+
+	// multi-table update.
+	sqls, err := f.genStatementRollbackSQLs([]*replication.BinlogEvent{
+		newTestRowsEvent(replication.UPDATE_ROWS_EVENTv2, 0, "exist_tb_1", false,
+			[]interface{}{int32(-1), "a'b", []byte{0xff, 0x00}}, []interface{}{int32(-1), "c", []byte{0xff, 0x00}},
+			[]interface{}{int32(2), nil, nil}, []interface{}{int32(2), nil, nil}),
+		newTestRowsEvent(replication.UPDATE_ROWS_EVENTv2, 0, "exist_tb_2", true,
+			[]interface{}{nil, float64(1.5)}, []interface{}{"c", float64(2.5)}),
+	}, tables)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"UPDATE `exist_db`.`exist_tb_2` SET `v1` = NULL, `v2` = 1.5 WHERE `v1` = 'c' LIMIT 1;",
+		"UPDATE `exist_db`.`exist_tb_1` SET `v1` = 'a\\'b' WHERE `id` = 4294967295 LIMIT 1;",
+	}, sqls)
+
+	// the table is not queried again.
+	sqls, err = f.genStatementRollbackSQLs([]*replication.BinlogEvent{
+		newTestRowsEvent(replication.WRITE_ROWS_EVENTv2, 0, "exist_tb_1", false,
+			[]interface{}{int32(3), "a", nil}, []interface{}{int32(4), "b", nil}),
+		newTestRowsEvent(replication.DELETE_ROWS_EVENTv2, 0, "exist_tb_2", true,
+			[]interface{}{"a\nb", float64(1)}),
+	}, tables)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"INSERT INTO `exist_db`.`exist_tb_2` (`v1`, `v2`) VALUES ('a\\nb', 1);",
+		"DELETE FROM `exist_db`.`exist_tb_1` WHERE `id` = 4 LIMIT 1;",
+		"DELETE FROM `exist_db`.`exist_tb_1` WHERE `id` = 3 LIMIT 1;",
+	}, sqls)
+
+	// the row of keyless table which has only floating-point columns can't be matched.
+	mockFlashbackTable(mock, "exist_tb_3",
+		[]_driver.Value{"v1", "float", "float", ""},
+		[]_driver.Value{"v2", "double", "double", ""},
+	)
+	_, err = f.genStatementRollbackSQLs([]*replication.BinlogEvent{
+		newTestRowsEvent(replication.WRITE_ROWS_EVENTv2, 0, "exist_tb_3", true,
+			[]interface{}{float32(1), float64(1)}),
+	}, tables)
+	assert.Error(t, err)
+
+	// the table is changed after execution.
+	_, err = f.genStatementRollbackSQLs([]*replication.BinlogEvent{
+		newTestRowsEvent(replication.DELETE_ROWS_EVENTv2, 0, "exist_tb_2", true,
+			[]interface{}{"a", float64(1), int32(1)}),
+	}, tables)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFlashback_getStatementOwners(t *testing.T) {
+	f := &flashback{
+		i:       DefaultMysqlInspect(),
+		queries: []string{"q1", "q2", "q3", "q4"},
+		isDML:   []bool{false, true, true, true},
+	}
+	results := []_driver.Result{
+		sqlmock.NewResult(0, 0), sqlmock.NewResult(0, 2), sqlmock.NewResult(0, 0), sqlmock.NewResult(0, 1),
+	}
+	statements := make([][]*replication.BinlogEvent, 2)
+	assert.Equal(t, []int{1, 3}, f.getStatementOwners(statements, results))
+
+	// statements don't match DML, they are owned by the first DML.
+	statements = make([][]*replication.BinlogEvent, 3)
+	assert.Equal(t, []int{1, 1, 1}, f.getStatementOwners(statements, results))
+}
+
+func TestFlashbackColumn_literal(t *testing.T) {
+	tests := []struct {
+		column *flashbackColumn
+		value  interface{}
+		expect string
+	}{
+		{&flashbackColumn{dataType: "tinyint", unsigned: true}, int8(-1), "255"},
+		{&flashbackColumn{dataType: "tinyint"}, int8(-1), "-1"},
+		{&flashbackColumn{dataType: "smallint", unsigned: true}, int16(-1), "65535"},
+		{&flashbackColumn{dataType: "mediumint", unsigned: true}, int32(-1), "16777215"},
+		{&flashbackColumn{dataType: "bigint", unsigned: true}, int64(-1), "18446744073709551615"},
+		{&flashbackColumn{dataType: "float"}, float32(0.1), "0.1"},
+		{&flashbackColumn{dataType: "varchar"}, "it's\\", `'it\'s\\'`},
+		{&flashbackColumn{dataType: "varbinary"}, []byte{0x01, 0xfe}, "X'01fe'"},
+		{&flashbackColumn{dataType: "json"}, []byte(`{"a": "b"}`), `'{"a": "b"}'`},
+		{&flashbackColumn{dataType: "datetime"}, "2021-01-01 00:00:00", "'2021-01-01 00:00:00'"},
+		{&flashbackColumn{dataType: "int"}, nil, "NULL"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expect, tt.column.literal(tt.value))
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	_driver "database/sql/driver"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/actiontech/sqle/sqle/driver"
 	"github.com/actiontech/sqle/sqle/driver/mysql/onlineddl"
@@ -20,7 +22,15 @@ func init() {
 		allRules = append(allRules, &RuleHandlers[i].Rule)
 	}
 
-	driver.Register(driver.DriverTypeMySQL, newInspect, allRules)
+	driver.Register(driver.DriverTypeMySQL, newInspect, allRules, &driver.Metas{
+		Capabilities: []driver.Capability{
+			driver.CapabilityRollback,
+			driver.CapabilityExplain,
+			driver.CapabilityOnlineDDL,
+			driver.CapabilityOfflineAudit,
+			driver.CapabilityTransaction,
+		},
+	})
 
 	if err := LoadPtTemplateFromFile("./scripts/pt-online-schema-change.template"); err != nil {
 		panic(err)
@@ -53,6 +63,9 @@ type Inspect struct {
 	isConnected bool
 	// isOfflineAudit represent Audit without instance.
 	isOfflineAudit bool
+	// rollbackBatch reports the batches of rollback SQL during GenRollbackSQL, it's
+	// nil if the rollback SQL is returned at once.
+	rollbackBatch driver.RollbackBatchFunc
 }
 
 func newInspect(log *logrus.Entry, cfg *driver.Config) (driver.Driver, error) {
@@ -61,6 +74,13 @@ func newInspect(log *logrus.Entry, cfg *driver.Config) (driver.Driver, error) {
 		ctx.UseSchema(cfg.DSN.DatabaseName)
 	}
 
+	// the DML is executed in chunks with the default chunk size and sleep interval,
+	// if they are not configured.
+	chunkSizeRule := RuleHandlerMap[ConfigDMLChunkSize].Rule
+	chunkSleepRule := RuleHandlerMap[ConfigDMLChunkSleepMs].Rule
+	ddlCheckWaitTimeoutRule := RuleHandlerMap[ConfigDDLCheckWaitTimeout].Rule
+	rollbackBatchRowsRule := RuleHandlerMap[ConfigDMLRollbackBatchRows].Rule
+
 	i := &Inspect{
 		log: log,
 		Ctx: ctx,
@@ -68,6 +88,24 @@ func newInspect(log *logrus.Entry, cfg *driver.Config) (driver.Driver, error) {
 			DMLRollbackMaxRows: -1,
 			DDLOSCMinSize:      -1,
 			DDLGhostMinSize:    -1,
+
+			DDLGhostOldTableRetentionHours: -1,
+			DDLOnlineDDLTool:               OnlineDDLToolGhost,
+			DDLRecycleBinRetentionDays:     -1,
+
+			DMLChunkMinRows:       -1,
+			DMLChunkSize:          chunkSizeRule.GetValueInt(nil),
+			DMLChunkSleepMs:       chunkSleepRule.GetValueInt(nil),
+			DMLChunkMaxReplicaLag: -1,
+
+			DMLRollbackBinlogMaxRows:        -1,
+			DMLRollbackBackupRetentionHours: -1,
+			DMLRollbackBatchRows:            rollbackBatchRowsRule.GetValueInt(nil),
+
+			DDLCheckMaxTrxTime:    -1,
+			DDLCheckMaxReplicaLag: -1,
+			DDLCheckAction:        RuleHandlerMap[ConfigDDLCheckAction].Rule.Value,
+			DDLCheckWaitTimeout:   ddlCheckWaitTimeoutRule.GetValueInt(nil),
 		},
 
 		inst:           cfg.DSN,
@@ -89,6 +127,69 @@ func newInspect(log *logrus.Entry, cfg *driver.Config) (driver.Driver, error) {
 			defaultRule := RuleHandlerMap[ConfigDDLGhostMinSize].Rule
 			i.cnf.DDLGhostMinSize = rule.GetValueInt(&defaultRule)
 		}
+		if rule.Name == ConfigDDLGhostOldTableRetentionHours {
+			defaultRule := RuleHandlerMap[ConfigDDLGhostOldTableRetentionHours].Rule
+			i.cnf.DDLGhostOldTableRetentionHours = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDDLRecycleBinRetentionDays {
+			defaultRule := RuleHandlerMap[ConfigDDLRecycleBinRetentionDays].Rule
+			i.cnf.DDLRecycleBinRetentionDays = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDDLOnlineDDLTool {
+			switch tool := rule.GetValue(); tool {
+			case OnlineDDLToolGhost, OnlineDDLToolPtOSC:
+				i.cnf.DDLOnlineDDLTool = tool
+			}
+		}
+		if rule.Name == ConfigDMLChunkMinRows {
+			defaultRule := RuleHandlerMap[ConfigDMLChunkMinRows].Rule
+			i.cnf.DMLChunkMinRows = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDMLChunkSize {
+			defaultRule := RuleHandlerMap[ConfigDMLChunkSize].Rule
+			i.cnf.DMLChunkSize = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDMLChunkSleepMs {
+			defaultRule := RuleHandlerMap[ConfigDMLChunkSleepMs].Rule
+			i.cnf.DMLChunkSleepMs = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDMLChunkMaxReplicaLag {
+			defaultRule := RuleHandlerMap[ConfigDMLChunkMaxReplicaLag].Rule
+			i.cnf.DMLChunkMaxReplicaLag = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDMLRollbackBinlogMaxRows {
+			defaultRule := RuleHandlerMap[ConfigDMLRollbackBinlogMaxRows].Rule
+			i.cnf.DMLRollbackBinlogMaxRows = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDMLRollbackBackupRetentionHours {
+			defaultRule := RuleHandlerMap[ConfigDMLRollbackBackupRetentionHours].Rule
+			i.cnf.DMLRollbackBackupRetentionHours = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDMLRollbackKeylessTable {
+			i.cnf.DMLRollbackKeylessTable, _ = strconv.ParseBool(rule.GetValue())
+		}
+		if rule.Name == ConfigDMLRollbackBatchRows {
+			defaultRule := RuleHandlerMap[ConfigDMLRollbackBatchRows].Rule
+			i.cnf.DMLRollbackBatchRows = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDDLCheckMaxTrxTime {
+			defaultRule := RuleHandlerMap[ConfigDDLCheckMaxTrxTime].Rule
+			i.cnf.DDLCheckMaxTrxTime = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDDLCheckMaxReplicaLag {
+			defaultRule := RuleHandlerMap[ConfigDDLCheckMaxReplicaLag].Rule
+			i.cnf.DDLCheckMaxReplicaLag = rule.GetValueInt(&defaultRule)
+		}
+		if rule.Name == ConfigDDLCheckAction {
+			switch action := rule.GetValue(); action {
+			case DDLCheckActionWait, DDLCheckActionAbort, DDLCheckActionWarn:
+				i.cnf.DDLCheckAction = action
+			}
+		}
+		if rule.Name == ConfigDDLCheckWaitTimeout {
+			defaultRule := RuleHandlerMap[ConfigDDLCheckWaitTimeout].Rule
+			i.cnf.DDLCheckWaitTimeout = rule.GetValueInt(&defaultRule)
+		}
 	}
 
 	return i, nil
@@ -103,6 +204,36 @@ func (i *Inspect) Exec(ctx context.Context, query string) (_driver.Result, error
 		return nil, nil
 	}
 
+	// the rollback SQLs and the clean SQLs are not checked, and the tables dropped by
+	// them are not moved to recycle bin.
+	if !driver.IsRawExec(ctx) {
+		if err := i.checkBeforeDDL(ctx, query); err != nil {
+			return nil, err
+		}
+
+		recycled, err := i.execWithRecycleBin(ctx, query)
+		if err != nil {
+			return nil, errors.Wrap(err, "move dropped tables to recycle bin")
+		}
+		if recycled {
+			return _driver.ResultNoRows, nil
+		}
+	}
+
+	usePtOSC, err := i.onlineddlWithPtOSC(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "check whether use pt-online-schema-change or not")
+	}
+	if usePtOSC {
+		i.log.Infof("run pt-online-schema-change")
+		if err := i.execWithPtOSC(ctx, query); err != nil {
+			i.log.Errorf("run pt-online-schema-change error:%v", err)
+			return nil, errors.Wrap(err, "run pt-online-schema-change")
+		}
+		i.log.Infof("run OK!")
+		return _driver.ResultNoRows, nil
+	}
+
 	useGhost, err := i.onlineddlWithGhost(query)
 	if err != nil {
 		return nil, errors.Wrap(err, "check whether use ghost or not")
@@ -116,11 +247,16 @@ func (i *Inspect) Exec(ctx context.Context, query string) (_driver.Result, error
 		stmt := node[0].(*ast.AlterTableStmt)
 		schema := i.getSchemaName(stmt.Table)
 
+		keepOldTable := i.cnf.DDLGhostOldTableRetentionHours >= 0
+		var executor *onlineddl.Executor
 		run := func(dryRun bool) error {
-			executor, err := onlineddl.NewExecutor(i.log, i.inst, schema, query)
+			executor, err = onlineddl.NewExecutor(i.log, i.inst, schema, query)
 			if err != nil {
 				return err
 			}
+			if keepOldTable {
+				executor.KeepOldTable()
+			}
 
 			err = executor.Execute(ctx, dryRun)
 			if err != nil {
@@ -143,18 +279,77 @@ func (i *Inspect) Exec(ctx context.Context, query string) (_driver.Result, error
 		}
 		i.log.Infof("run OK!")
 
+		if keepOldTable {
+			i.reportGhostSwapBack(ctx, schema, stmt.Table.Name.O, executor.OldTableName())
+		}
+
 		return _driver.ResultNoRows, nil
 	}
 
+	chunked, err := i.getChunkedDML(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "check whether execute in chunks or not")
+	}
 	conn, err := i.getDbConn()
 	if err != nil {
 		return nil, err
 	}
-	return conn.Db.Exec(query)
+
+	backups, err := i.backupDMLs(ctx, 0, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result _driver.Result
+	if chunked != nil {
+		if err := backups.copyRows(ctx); err != nil {
+			return nil, err
+		}
+		fb := i.startFlashback(ctx, 0, query)
+		result, err = i.execChunkedDML(ctx, 0, chunked)
+		// the chunks committed before failure are rollbacked by the backup.
+		backups.finish(ctx, nil)
+		fb.finish(ctx, []_driver.Result{result}, err)
+		return result, err
+	}
+
+	fb := i.startFlashback(ctx, 0, query)
+	fb.skipBackups(backups)
+	if len(backups) > 0 {
+		// the rows are backed up in the transaction of DML.
+		var results []_driver.Result
+		if results, err = backups.transact(ctx, conn, 0, query); err == nil {
+			result = results[0]
+		}
+	} else {
+		result, err = conn.Db.ExecContext(ctx, query)
+	}
+	backups.finish(ctx, err)
+	fb.finish(ctx, []_driver.Result{result}, err)
+	return result, err
+}
+
+// reportGhostSwapBack reports the rollback of the ALTER executed by gh-ost, which
+// renames the old table kept by gh-ost back to the original table atomically. The
+// old table and the migrated table renamed by rollback are dropped after retention.
+func (i *Inspect) reportGhostSwapBack(ctx context.Context, schema, table, oldTable string) {
+	quote := func(name string) string {
+		return fmt.Sprintf("`%s`.`%s`", strings.Replace(schema, "`", "``", -1), strings.Replace(name, "`", "``", -1))
+	}
+	// the old table name always ends with "_del", so the name of migrated table is no
+	// longer than it.
+	migratedTable := strings.TrimSuffix(oldTable, "_del") + "_rb"
+
+	driver.ReportExecRollback(ctx, 0, &driver.ExecRollback{
+		RollbackSQL: fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s",
+			quote(table), quote(migratedTable), quote(oldTable), quote(table)),
+		CleanSQL:  fmt.Sprintf("DROP TABLE IF EXISTS %s, %s", quote(oldTable), quote(migratedTable)),
+		Retention: time.Duration(i.cnf.DDLGhostOldTableRetentionHours) * time.Hour,
+	})
 }
 
 func (i *Inspect) onlineddlWithGhost(query string) (bool, error) {
-	if i.cnf.DDLGhostMinSize == -1 {
+	if i.cnf.DDLGhostMinSize == -1 || i.cnf.DDLOnlineDDLTool == OnlineDDLToolPtOSC {
 		return false, nil
 	}
 
@@ -176,6 +371,9 @@ func (i *Inspect) onlineddlWithGhost(query string) (bool, error) {
 	return int64(tableSize) > i.cnf.DDLGhostMinSize, nil
 }
 
+// Tx executes the queries in one transaction. The DML is executed in chunks only if
+// it's the only query of the transaction, because the chunks are committed one by one,
+// which breaks the atomicity of the transaction.
 func (i *Inspect) Tx(ctx context.Context, queries ...string) ([]_driver.Result, error) {
 	if i.IsOfflineAudit() {
 		return nil, nil
@@ -184,7 +382,43 @@ func (i *Inspect) Tx(ctx context.Context, queries ...string) ([]_driver.Result,
 	if err != nil {
 		return nil, err
 	}
-	return conn.Db.Transact(queries...)
+
+	if len(queries) == 1 {
+		chunked, err := i.getChunkedDML(queries[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "check whether execute in chunks or not")
+		}
+		if chunked != nil {
+			backups, err := i.backupDMLs(ctx, 0, queries[0])
+			if err != nil {
+				return nil, err
+			}
+			if err := backups.copyRows(ctx); err != nil {
+				return nil, err
+			}
+			fb := i.startFlashback(ctx, 0, queries[0])
+			result, err := i.execChunkedDML(ctx, 0, chunked)
+			// the chunks committed before failure are rollbacked by the backup.
+			backups.finish(ctx, nil)
+			fb.finish(ctx, []_driver.Result{result}, err)
+			if err != nil {
+				return nil, err
+			}
+			return []_driver.Result{result}, nil
+		}
+	}
+
+	backups, err := i.backupDMLs(ctx, 0, queries...)
+	if err != nil {
+		return nil, err
+	}
+	fb := i.startFlashback(ctx, 0, queries...)
+	fb.skipBackups(backups)
+	// the rows are backed up in the transaction of queries.
+	results, err := backups.transact(ctx, conn, 0, queries...)
+	backups.finish(ctx, err)
+	fb.finish(ctx, results, err)
+	return results, err
 }
 
 func (i *Inspect) Query(ctx context.Context, query string, args ...interface{}) ([]map[string]sql.NullString, error) {
@@ -227,6 +461,18 @@ func (i *Inspect) Parse(ctx context.Context, sqlText string) ([]driver.Node, err
 	return ns, nil
 }
 
+func (i *Inspect) AuditBatch(ctx context.Context, sqls []string) ([]*driver.AuditResult, error) {
+	results := make([]*driver.AuditResult, 0, len(sqls))
+	for _, sql := range sqls {
+		result, err := i.Audit(ctx, sql)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 func (i *Inspect) Audit(ctx context.Context, sql string) (*driver.AuditResult, error) {
 	i.result = driver.NewInspectResults()
 
@@ -268,7 +514,13 @@ func (i *Inspect) Audit(ctx context.Context, sql string) (*driver.AuditResult, e
 		return nil, err
 	}
 	if oscCommandLine != "" {
-		i.result.Add(driver.RuleLevelNotice, fmt.Sprintf("[osc]%s", oscCommandLine))
+		i.result.AddItem(&driver.AuditResultItem{
+			RuleName: ConfigDDLOSCMinSize,
+			Category: RuleHandlerMap[ConfigDDLOSCMinSize].Rule.Category,
+			Level:    driver.RuleLevelNotice,
+			Message:  oscCommandLine,
+			Prefix:   "[osc]",
+		})
 	}
 	i.updateContext(nodes[0])
 	return i.result, nil
@@ -287,6 +539,8 @@ func (i *Inspect) GenRollbackSQL(ctx context.Context, sql string) (string, strin
 		return "", "", err
 	}
 
+	i.rollbackBatch = driver.GetRollbackBatch(ctx)
+	defer func() { i.rollbackBatch = nil }()
 	rollback, reason, err := i.GenerateRollbackSql(nodes[0])
 	if err != nil {
 		return "", "", err
@@ -328,6 +582,43 @@ type Config struct {
 	DMLRollbackMaxRows int64
 	DDLOSCMinSize      int64
 	DDLGhostMinSize    int64
+
+	// DDLGhostOldTableRetentionHours is -1 if the old table is not kept by gh-ost.
+	DDLGhostOldTableRetentionHours int64
+
+	// DDLOnlineDDLTool is the tool to execute the ALTER on the large table, it's gh-ost
+	// or pt-osc.
+	DDLOnlineDDLTool string
+
+	// DDLRecycleBinRetentionDays is -1 if the dropped tables are not moved to recycle bin.
+	DDLRecycleBinRetentionDays int64
+
+	// DMLChunkMinRows is -1 if the DML is not executed in chunks.
+	DMLChunkMinRows       int64
+	DMLChunkSize          int64
+	DMLChunkSleepMs       int64
+	DMLChunkMaxReplicaLag int64
+
+	// DMLRollbackBinlogMaxRows is -1 if the rollback of DML is not generated from binlog.
+	DMLRollbackBinlogMaxRows int64
+
+	// DMLRollbackBackupRetentionHours is -1 if the rows changed by DML are not backed up
+	// when they are more than DMLRollbackMaxRows.
+	DMLRollbackBackupRetentionHours int64
+
+	// DMLRollbackKeylessTable is true if the rollback of DML on the table which has no
+	// primary key or not null unique key is generated by matching the full row.
+	DMLRollbackKeylessTable bool
+
+	// DMLRollbackBatchRows is the max rows in one rollback SQL of DML, the rollback SQL
+	// is not split if it's not positive.
+	DMLRollbackBatchRows int64
+
+	// DDLCheckMaxTrxTime and DDLCheckMaxReplicaLag are -1 if they are not checked before DDL.
+	DDLCheckMaxTrxTime    int64
+	DDLCheckMaxReplicaLag int64
+	DDLCheckAction        string
+	DDLCheckWaitTimeout   int64
 }
 
 func (i *Inspect) Context() *Context {
@@ -357,9 +648,8 @@ func (i *Inspect) addResult(ruleName string, args ...interface{}) {
 	if ruleName != i.currentRule.Name {
 		return
 	}
-	level := i.currentRule.Level
 	message := RuleHandlerMap[ruleName].Message
-	i.result.Add(level, message, args...)
+	i.result.AddWithRule(&i.currentRule, message, args...)
 }
 
 // getDbConn get db conn and just connect once.
@@ -3,6 +3,7 @@ package mysql
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/actiontech/sqle/sqle/driver"
 	"github.com/stretchr/testify/assert"
@@ -161,3 +162,21 @@ func TestInspect_GenRollbackSQL(t *testing.T) {
 	assert.Equal(t, "", reason)
 	assert.Equal(t, "ALTER TABLE `exist_db`.`t1`\nDROP COLUMN `c1`;", rollback)
 }
+
+func TestInspect_reportGhostSwapBack(t *testing.T) {
+	i := DefaultMysqlInspect()
+	i.cnf.DDLGhostOldTableRetentionHours = 24
+
+	var rollback *driver.ExecRollback
+	ctx := driver.WithExecRollback(context.Background(), func(index int, r *driver.ExecRollback) {
+		assert.Equal(t, 0, index)
+		rollback = r
+	})
+	i.reportGhostSwapBack(ctx, "exist_db", "exist_tb_1", "_exist_tb_1_20211027150405_del")
+	assert.Equal(t, &driver.ExecRollback{
+		RollbackSQL: "RENAME TABLE `exist_db`.`exist_tb_1` TO `exist_db`.`_exist_tb_1_20211027150405_rb`, " +
+			"`exist_db`.`_exist_tb_1_20211027150405_del` TO `exist_db`.`exist_tb_1`",
+		CleanSQL:  "DROP TABLE IF EXISTS `exist_db`.`_exist_tb_1_20211027150405_del`, `exist_db`.`_exist_tb_1_20211027150405_rb`",
+		Retention: 24 * time.Hour,
+	}, rollback)
+}
@@ -1,12 +1,13 @@
 package onlineddl
 
 import (
-	"context"
 	"fmt"
 	"math/rand"
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/actiontech/sqle/sqle/driver"
 
@@ -21,9 +22,31 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// progressInterval is the interval to report the progress of migration.
+var progressInterval = 5 * time.Second
+
 type Executor struct {
-	l  base.Logger
+	l  *logAdaptor
 	mc *base.MigrationContext
+
+	inst   *driver.DSN
+	schema string
+	query  string
+
+	// postponed is 1 if the cut-over is postponed, the postpone flag file of gh-ost
+	// exists only if it's postponed. The flag file is removed after the migration
+	// if it's not specified by config file.
+	postponed              int64
+	ownPostponeCutOverFlag bool
+	// ownServeSocket is set if the socket file is not specified by config file.
+	// The flag file and the socket file which are not specified are created in a
+	// temporary directory of each migration by Execute, so that the migrations of
+	// the tables with the same name on different instances don't share them.
+	ownServeSocket bool
+
+	keepOldTable bool
+	// oldTableName is reported by the gh-ost process after the migration.
+	oldTableName string
 }
 
 func NewExecutor(logger *logrus.Entry, inst *driver.DSN, schema string, query string) (*Executor, error) {
@@ -84,26 +107,175 @@ func NewExecutor(logger *logrus.Entry, inst *driver.DSN, schema string, query st
 		return nil, errors.Wrap(err, "check migration context")
 	}
 
-	return &Executor{
-		l:  la,
-		mc: mc,
-	}, nil
+	e := &Executor{
+		l:      la,
+		mc:     mc,
+		inst:   inst,
+		schema: schema,
+		query:  query,
+	}
+	// the cut-over is postponed until user commands if the postpone flag file is
+	// specified by config file, otherwise it's postponed by command.
+	if mc.PostponeCutOverFlagFile == "" {
+		e.ownPostponeCutOverFlag = true
+		// gh-ost creates the flag file at the beginning of migration, it's removed
+		// later by syncPostponeCutOverFlag, the cut-over is not postponed before it.
+		atomic.StoreInt64(&mc.UserCommandedUnpostponeFlag, 1)
+	} else {
+		e.postponed = 1
+	}
+	e.ownServeSocket = mc.ServeSocketFile == ""
+	return e, nil
 }
 
-func (e *Executor) Execute(ctx context.Context, dryRun bool) error {
+// migrate runs the migration by gh-ost in the gh-ost process, the progress and
+// the replies of commands are written to w.
+func (e *Executor) migrate(dryRun bool, w *ghostWriter) error {
 	if dryRun {
 		e.mc.Noop = true
+	} else {
+		stopped := make(chan struct{})
+		defer close(stopped)
+		go e.reportProgress(w, stopped)
+	}
+	if e.ownPostponeCutOverFlag {
+		defer os.Remove(e.mc.PostponeCutOverFlagFile)
 	}
+	go e.reportTablesCreated(w)
 
-	m := logic.NewMigrator(e.mc)
-	err := m.Migrate()
-	if err != nil {
+	if err := logic.NewMigrator(e.mc).Migrate(); err != nil {
 		return errors.Wrapf(err, "migrate table, dry-run(%v)", dryRun)
 	}
+	return nil
+}
+
+// KeepOldTable makes gh-ost keep the old table after cut-over, the name of old table
+// has the timestamp of migration to avoid conflict with the table kept before.
+func (e *Executor) KeepOldTable() {
+	e.keepOldTable = true
+	e.mc.OkToDropTable = false
+	e.mc.TimestampOldTable = true
+}
+
+// OldTableName returns the name of old table which is renamed by cut-over, it should
+// be called after the migration is done.
+func (e *Executor) OldTableName() string {
+	return e.oldTableName
+}
+
+// handleCommand handles the command sent by user, it's like the interactive commands
+// of gh-ost.
+func (e *Executor) handleCommand(cmd driver.ExecCommand) error {
+	switch cmd {
+	case driver.ExecCommandThrottle:
+		atomic.StoreInt64(&e.mc.ThrottleCommandedByUser, 1)
+	case driver.ExecCommandUnthrottle:
+		atomic.StoreInt64(&e.mc.ThrottleCommandedByUser, 0)
+	case driver.ExecCommandPostponeCutOver:
+		if atomic.LoadInt64(&e.mc.InCutOverCriticalSectionFlag) > 0 {
+			return errors.New("the cut-over is in progress, can not postpone it")
+		}
+		atomic.StoreInt64(&e.postponed, 1)
+		atomic.StoreInt64(&e.mc.UserCommandedUnpostponeFlag, 0)
+		return e.syncPostponeCutOverFlag()
+	case driver.ExecCommandCutOver:
+		atomic.StoreInt64(&e.postponed, 0)
+		atomic.StoreInt64(&e.mc.UserCommandedUnpostponeFlag, 1)
+		return e.syncPostponeCutOverFlag()
+	case driver.ExecCommandAbort:
+		select {
+		case e.mc.PanicAbort <- fmt.Errorf("migration is aborted by user"):
+		default:
+		}
+	default:
+		return errors.Wrapf(driver.ErrExecCommandNotSupported, "command %s", cmd)
+	}
+	e.l.inner.Infof("command %s is done", cmd)
+	return nil
+}
 
+// syncPostponeCutOverFlag makes the postpone flag file exist only if the cut-over
+// is postponed.
+func (e *Executor) syncPostponeCutOverFlag() error {
+	postponed := atomic.LoadInt64(&e.postponed) > 0
+	exist := base.FileExists(e.mc.PostponeCutOverFlagFile)
+	switch {
+	case postponed && !exist:
+		if err := base.TouchFile(e.mc.PostponeCutOverFlagFile); err != nil {
+			return errors.Wrap(err, "create postpone flag file")
+		}
+	case !postponed && exist:
+		if err := os.Remove(e.mc.PostponeCutOverFlagFile); err != nil {
+			return errors.Wrap(err, "remove postpone flag file")
+		}
+	}
 	return nil
 }
 
+// reportProgress reports the progress of migration periodically until stopped is closed.
+func (e *Executor) reportProgress(w *ghostWriter, stopped chan struct{}) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopped:
+			return
+		case <-ticker.C:
+		}
+		if err := e.syncPostponeCutOverFlag(); err != nil {
+			e.l.inner.Warnf("sync postpone flag file error: %v", err)
+		}
+		w.write(&ghostMessage{Progress: e.progress()})
+	}
+}
+
+// reportTablesCreated reports that the ghost table and the changelog table are created
+// by this migration, gh-ost writes heartbeat to the changelog table after both of them
+// are created.
+func (e *Executor) reportTablesCreated(w *ghostWriter) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !e.mc.GetLastHeartbeatOnChangelogTime().IsZero() {
+			w.write(&ghostMessage{TablesCreated: true})
+			return
+		}
+	}
+}
+
+func (e *Executor) progress() string {
+	rowsCopied := e.mc.GetTotalRowsCopied()
+	rowsEstimate := atomic.LoadInt64(&e.mc.RowsEstimate) + atomic.LoadInt64(&e.mc.RowsDeltaEstimate)
+	if rowsEstimate < rowsCopied {
+		rowsEstimate = rowsCopied
+	}
+
+	var eta string
+	switch etaDuration := e.mc.GetETADuration(); {
+	case etaDuration == 0:
+		eta = "due"
+	case etaDuration < 0:
+		eta = "N/A"
+	default:
+		eta = base.PrettifyDurationOutput(etaDuration)
+	}
+
+	state := "migrating"
+	if atomic.LoadInt64(&e.mc.InCutOverCriticalSectionFlag) > 0 {
+		state = "cutting over"
+	} else if atomic.LoadInt64(&e.mc.IsPostponingCutOver) > 0 {
+		state = "postponing cut-over"
+		eta = "due"
+	} else if isThrottled, reason, _ := e.mc.IsThrottled(); isThrottled {
+		state = fmt.Sprintf("throttled, %s", reason)
+	}
+
+	return fmt.Sprintf("copy: %d/%d %.1f%%, applied: %d, lag: %.2fs, state: %s, ETA: %s",
+		rowsCopied, rowsEstimate, e.mc.GetProgressPct(),
+		atomic.LoadInt64(&e.mc.TotalDMLEventsApplied),
+		e.mc.GetCurrentLagDuration().Seconds(), state, eta)
+}
+
 const cfgPath = "./etc/gh-ost.ini"
 
 // config refer to https://github.com/github/gh-ost/blob/master/go/cmd/gh-ost/main.go
@@ -298,9 +470,6 @@ func (cfg *config) apply(mc *base.MigrationContext) error {
 	mc.ServeTCPPort = cfg.ServeTCPPort
 
 	mc.ServeSocketFile = cfg.ServeSocketFile
-	if mc.ServeSocketFile == "" {
-		mc.ServeSocketFile = fmt.Sprintf("/tmp/gh-ost.%s.%s.sock", mc.DatabaseName, mc.OriginalTableName)
-	}
 
 	switch cfg.CutOver {
 	case "atomic", "default", "":
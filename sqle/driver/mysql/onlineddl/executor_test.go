@@ -1,9 +1,17 @@
 package onlineddl
 
 import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/log"
+
+	"github.com/github/gh-ost/go/base"
 	_ "github.com/pingcap/tidb/types/parser_driver"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_parseAlterTableOptions(t *testing.T) {
@@ -65,3 +73,45 @@ func Test_parseAlterTableOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutor_handleCommand(t *testing.T) {
+	e, err := NewExecutor(log.NewEntry(), &driver.DSN{Host: "127.0.0.1", Port: "3306"}, "db1",
+		"alter table t1 add column i int")
+	assert.NoError(t, err)
+	assert.True(t, e.ownPostponeCutOverFlag)
+	e.mc.PostponeCutOverFlagFile = filepath.Join(t.TempDir(), "gh-ost.postpone")
+
+	controller := &driver.ExecController{}
+	ctx := driver.WithExecController(context.Background(), controller)
+	unset := driver.HandleExecCommand(ctx, e.handleCommand)
+
+	assert.NoError(t, controller.Send(driver.ExecCommandThrottle))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&e.mc.ThrottleCommandedByUser))
+	assert.NoError(t, controller.Send(driver.ExecCommandUnthrottle))
+	assert.Equal(t, int64(0), atomic.LoadInt64(&e.mc.ThrottleCommandedByUser))
+
+	// the cut-over is not postponed by default.
+	assert.Equal(t, int64(1), atomic.LoadInt64(&e.mc.UserCommandedUnpostponeFlag))
+	assert.NoError(t, base.TouchFile(e.mc.PostponeCutOverFlagFile))
+	assert.NoError(t, e.syncPostponeCutOverFlag())
+	assert.False(t, base.FileExists(e.mc.PostponeCutOverFlagFile))
+
+	assert.NoError(t, controller.Send(driver.ExecCommandPostponeCutOver))
+	assert.True(t, base.FileExists(e.mc.PostponeCutOverFlagFile))
+	assert.Equal(t, int64(0), atomic.LoadInt64(&e.mc.UserCommandedUnpostponeFlag))
+
+	assert.NoError(t, controller.Send(driver.ExecCommandCutOver))
+	assert.False(t, base.FileExists(e.mc.PostponeCutOverFlagFile))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&e.mc.UserCommandedUnpostponeFlag))
+
+	// gh-ost is listening on the panic-abort channel during migration.
+	e.mc.PanicAbort = make(chan error, 1)
+	assert.NoError(t, controller.Send(driver.ExecCommandAbort))
+	assert.EqualError(t, <-e.mc.PanicAbort, "migration is aborted by user")
+
+	assert.Error(t, controller.Send("unknown"))
+
+	// the command is not supported after the migration is done.
+	unset()
+	assert.Equal(t, driver.ErrExecCommandNotSupported, controller.Send(driver.ExecCommandThrottle))
+}
@@ -0,0 +1,365 @@
+package onlineddl
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/log"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// GhostProcessCommand is the sub command of sqled which runs the migration of gh-ost,
+// see RunGhostProcess.
+const GhostProcessCommand = "gh-ost"
+
+var (
+	// ghostProcessPath is the binary which runs the gh-ost process, it's sqled itself
+	// if it's empty.
+	ghostProcessPath = ""
+
+	// ghostCommandTimeout is the time to wait for the reply of command sent to the
+	// gh-ost process.
+	ghostCommandTimeout = 10 * time.Second
+
+	openGhostDB = func(inst *driver.DSN) (*sql.DB, error) {
+		return sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/?charset=utf8",
+			inst.User, inst.Password, inst.Host, inst.Port))
+	}
+)
+
+// ghostOutputLines is the number of the last output lines in error.
+const ghostOutputLines = 10
+
+// ghostSpec is the migration which is sent to the gh-ost process by stdin at first.
+type ghostSpec struct {
+	Inst   *driver.DSN `json:"inst"`
+	Schema string      `json:"schema"`
+	Query  string      `json:"query"`
+	DryRun bool        `json:"dry_run"`
+
+	KeepOldTable            bool   `json:"keep_old_table"`
+	PostponeCutOverFlagFile string `json:"postpone_cut_over_flag_file"`
+	ServeSocketFile         string `json:"serve_socket_file"`
+}
+
+// ghostRequest is the command which is sent to the gh-ost process by stdin.
+type ghostRequest struct {
+	Command driver.ExecCommand `json:"command"`
+}
+
+// ghostMessage is written by the gh-ost process to stdout.
+type ghostMessage struct {
+	Progress string `json:"progress,omitempty"`
+
+	// Command and Error are the reply of the command.
+	Command driver.ExecCommand `json:"command,omitempty"`
+	Error   string             `json:"error,omitempty"`
+
+	// TablesCreated is set once the ghost table and the changelog table are created.
+	TablesCreated bool `json:"tables_created,omitempty"`
+	// OldTableName is set after the migration is done.
+	OldTableName string `json:"old_table_name,omitempty"`
+}
+
+type ghostWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (w *ghostWriter) write(msg *ghostMessage) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(msg)
+}
+
+// RunGhostProcess runs the migration of gh-ost which is sent by Executor.Execute, it's
+// run by sqled in the subprocess, because gh-ost exits the process when the migration
+// is aborted, and nothing of the migration is left after the process exits.
+func RunGhostProcess(in io.Reader, out io.Writer) error {
+	dec := json.NewDecoder(in)
+	spec := &ghostSpec{}
+	if err := dec.Decode(spec); err != nil {
+		return errors.Wrap(err, "read migration")
+	}
+	e, err := NewExecutor(log.NewEntry(), spec.Inst, spec.Schema, spec.Query)
+	if err != nil {
+		return err
+	}
+	e.mc.PostponeCutOverFlagFile = spec.PostponeCutOverFlagFile
+	e.mc.ServeSocketFile = spec.ServeSocketFile
+	if spec.KeepOldTable {
+		e.KeepOldTable()
+	}
+
+	w := &ghostWriter{enc: json.NewEncoder(out)}
+	go func() {
+		for {
+			req := &ghostRequest{}
+			if err := dec.Decode(req); err != nil {
+				// sqled is exited, the migration is aborted with it.
+				e.l.Fatale(errors.Wrap(err, "read command"))
+				return
+			}
+			reply := &ghostMessage{Command: req.Command}
+			if err := e.handleCommand(req.Command); err != nil {
+				reply.Error = err.Error()
+			}
+			w.write(reply)
+		}
+	}()
+
+	if err := e.migrate(spec.DryRun, w); err != nil {
+		return err
+	}
+	return w.write(&ghostMessage{OldTableName: e.mc.GetOldTableName()})
+}
+
+// ghostProcess is the gh-ost process started by Executor.Execute.
+type ghostProcess struct {
+	cmd *exec.Cmd
+
+	// mu serializes the commands sent to the process.
+	mu      sync.Mutex
+	stdin   *json.Encoder
+	replies chan *ghostMessage
+
+	abortOnce sync.Once
+	aborted   chan struct{}
+	exited    chan struct{}
+}
+
+// sendCommand sends the command to the gh-ost process and waits for the reply. The
+// migration is aborted by killing the process.
+func (p *ghostProcess) sendCommand(cmd driver.ExecCommand) error {
+	switch cmd {
+	case driver.ExecCommandAbort:
+		p.abortOnce.Do(func() { close(p.aborted) })
+		return nil
+	case driver.ExecCommandThrottle, driver.ExecCommandUnthrottle,
+		driver.ExecCommandPostponeCutOver, driver.ExecCommandCutOver:
+	default:
+		return errors.Wrapf(driver.ErrExecCommandNotSupported, "command %s", cmd)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.stdin.Encode(&ghostRequest{Command: cmd}); err != nil {
+		return errors.Wrap(err, "send command to gh-ost")
+	}
+	timeout := time.After(ghostCommandTimeout)
+	for {
+		select {
+		case reply := <-p.replies:
+			// the reply of the command which is timeout before.
+			if reply.Command != cmd {
+				continue
+			}
+			if reply.Error != "" {
+				return errors.New(reply.Error)
+			}
+			return nil
+		case <-p.exited:
+			return fmt.Errorf("gh-ost is exited")
+		case <-timeout:
+			return fmt.Errorf("wait for gh-ost replying command %s timeout", cmd)
+		}
+	}
+}
+
+// stopOnDone kills the gh-ost process when ctx is done or it's aborted.
+func (p *ghostProcess) stopOnDone(ctx context.Context) {
+	select {
+	case <-p.exited:
+		return
+	case <-ctx.Done():
+	case <-p.aborted:
+	}
+	if err := p.cmd.Process.Kill(); err != nil {
+		log.NewEntry().Errorf("kill gh-ost process error: %v", err)
+	}
+}
+
+// Execute runs the migration in the gh-ost process until it exits, the process is
+// killed if ctx is done or the abort command is received. The ghost table and the
+// changelog table created by the killed migration are dropped.
+func (e *Executor) Execute(ctx context.Context, dryRun bool) error {
+	path := ghostProcessPath
+	if path == "" {
+		var err error
+		if path, err = os.Executable(); err != nil {
+			return errors.Wrap(err, "get path of sqled")
+		}
+	}
+	if e.ownPostponeCutOverFlag || e.ownServeSocket {
+		dir, err := ioutil.TempDir("", "gh-ost.")
+		if err != nil {
+			return errors.Wrap(err, "create directory for gh-ost")
+		}
+		// the flag file and the socket file are left if the process is killed.
+		defer os.RemoveAll(dir)
+		if e.ownPostponeCutOverFlag {
+			e.mc.PostponeCutOverFlagFile = filepath.Join(dir, "postpone")
+		}
+		if e.ownServeSocket {
+			e.mc.ServeSocketFile = filepath.Join(dir, "sock")
+		}
+	}
+
+	cmd := exec.Command(path, GhostProcessCommand)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "start gh-ost process")
+	}
+	e.l.inner.Infof("gh-ost process is started, pid: %d, dry-run(%v)", cmd.Process.Pid, dryRun)
+
+	p := &ghostProcess{
+		cmd:     cmd,
+		stdin:   json.NewEncoder(stdin),
+		replies: make(chan *ghostMessage, 1),
+		aborted: make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+	go p.stopOnDone(ctx)
+	if err := p.stdin.Encode(&ghostSpec{
+		Inst:                    e.inst,
+		Schema:                  e.schema,
+		Query:                   e.query,
+		DryRun:                  dryRun,
+		KeepOldTable:            e.keepOldTable,
+		PostponeCutOverFlagFile: e.mc.PostponeCutOverFlagFile,
+		ServeSocketFile:         e.mc.ServeSocketFile,
+	}); err != nil {
+		e.l.inner.Errorf("send migration to gh-ost error: %v", err)
+	}
+	if !dryRun {
+		unset := driver.HandleExecCommand(ctx, p.sendCommand)
+		defer unset()
+	}
+
+	// output is read after stderrDone is closed.
+	output := []string{}
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			e.l.inner.Info(line)
+			if len(output) == ghostOutputLines {
+				output = output[1:]
+			}
+			output = append(output, line)
+		}
+		// drain the output, otherwise gh-ost is blocked on writing.
+		io.Copy(ioutil.Discard, stderr)
+	}()
+
+	var tablesCreated bool
+	dec := json.NewDecoder(stdout)
+	for {
+		msg := &ghostMessage{}
+		if err := dec.Decode(msg); err != nil {
+			if err != io.EOF {
+				e.l.inner.Warnf("read output of gh-ost error: %v", err)
+				io.Copy(ioutil.Discard, stdout)
+			}
+			break
+		}
+		switch {
+		case msg.Progress != "":
+			driver.ReportProgress(ctx, 0, msg.Progress)
+		case msg.Command != "":
+			select {
+			case p.replies <- msg:
+			default:
+			}
+		case msg.TablesCreated:
+			tablesCreated = true
+		case msg.OldTableName != "":
+			e.oldTableName = msg.OldTableName
+		}
+	}
+	<-stderrDone
+	err = cmd.Wait()
+	close(p.exited)
+	if err == nil {
+		return nil
+	}
+
+	select {
+	case <-p.aborted:
+		err = fmt.Errorf("migration is aborted by user")
+	default:
+		if ctx.Err() != nil {
+			err = errors.Wrapf(ctx.Err(), "migration is cancelled, dry-run(%v)", dryRun)
+		} else if exitErr, ok := err.(*exec.ExitError); ok {
+			err = fmt.Errorf("gh-ost exit with code %d, dry-run(%v), output: %s",
+				exitErr.ExitCode(), dryRun, strings.Join(output, "\n"))
+		}
+	}
+	// the migration which exits by itself has torn down, its tables are left for
+	// checking, only the tables of killed migration are dropped.
+	if tablesCreated && (ctx.Err() != nil || isClosed(p.aborted)) {
+		e.dropGhostTables()
+	}
+	return err
+}
+
+// dropGhostTables drops the ghost table and the changelog table of the migration,
+// and removes the socket file of gh-ost.
+func (e *Executor) dropGhostTables() {
+	os.Remove(e.mc.ServeSocketFile)
+
+	db, err := openGhostDB(e.inst)
+	if err != nil {
+		e.l.inner.Errorf("connect to drop tables of gh-ost error: %v", err)
+		return
+	}
+	defer db.Close()
+	for _, table := range []string{e.mc.GetGhostTableName(), e.mc.GetChangelogTableName()} {
+		query := fmt.Sprintf("DROP TABLE IF EXISTS `%s`.`%s`",
+			strings.Replace(e.mc.DatabaseName, "`", "``", -1), strings.Replace(table, "`", "``", -1))
+		if _, err := db.Exec(query); err != nil {
+			e.l.inner.Errorf("drop table %s of gh-ost error: %v", table, err)
+			continue
+		}
+		e.l.inner.Infof("table %s of gh-ost is dropped", table)
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,141 @@
+package onlineddl
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/log"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockGhostProcess replaces the gh-ost process with the shell script.
+func mockGhostProcess(t *testing.T, script string) {
+	path := filepath.Join(t.TempDir(), "sqled")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	origin := ghostProcessPath
+	ghostProcessPath = path
+	t.Cleanup(func() { ghostProcessPath = origin })
+}
+
+func newTestGhostExecutor(t *testing.T) *Executor {
+	e, err := NewExecutor(log.NewEntry(), &driver.DSN{Host: "127.0.0.1", Port: "3306", User: "root", Password: "123456"},
+		"db1", "alter table t1 add column i int")
+	assert.NoError(t, err)
+	return e
+}
+
+func TestExecutor_Execute(t *testing.T) {
+	mockGhostProcess(t, `
+[ "$1" = "gh-ost" ] || exit 3
+read spec
+case "$spec" in *'"Password":"123456"'*'"query":"alter table t1 add column i int"'*'"dry_run":false'*'/postpone"'*'/sock"'*) ;; *) exit 4;; esac
+echo '{"tables_created":true}'
+echo '{"progress":"copy: 1/2 50.0%"}'
+read cmd
+case "$cmd" in *'"command":"throttle"'*) echo '{"command":"throttle"}';; *) exit 5;; esac
+echo "Done migrating" >&2
+echo '{"old_table_name":"_t1_del"}'
+`)
+	controller := &driver.ExecController{}
+	ctx := driver.WithExecController(context.Background(), controller)
+	var progress []string
+	sent := make(chan error, 1)
+	ctx = driver.WithProgress(ctx, func(index int, p string) {
+		progress = append(progress, p)
+		go func() { sent <- controller.Send(driver.ExecCommandThrottle) }()
+	})
+
+	e := newTestGhostExecutor(t)
+	assert.NoError(t, e.Execute(ctx, false))
+	select {
+	case err := <-sent:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("command is not sent")
+	}
+	assert.Equal(t, []string{"copy: 1/2 50.0%"}, progress)
+	assert.Equal(t, "_t1_del", e.OldTableName())
+	// the directory of the flag file and the socket file is removed.
+	assert.Equal(t, filepath.Dir(e.mc.PostponeCutOverFlagFile), filepath.Dir(e.mc.ServeSocketFile))
+	_, err := os.Stat(filepath.Dir(e.mc.PostponeCutOverFlagFile))
+	assert.True(t, os.IsNotExist(err))
+
+	// the command is not supported after the migration is done.
+	assert.Equal(t, driver.ErrExecCommandNotSupported, controller.Send(driver.ExecCommandThrottle))
+}
+
+func TestExecutor_Execute_failed(t *testing.T) {
+	mockGhostProcess(t, `
+read spec
+echo '{"tables_created":true}'
+echo "table t1 has no unique key" >&2
+exit 1
+`)
+	// the tables are left for checking.
+	origin := openGhostDB
+	defer func() { openGhostDB = origin }()
+	openGhostDB = func(inst *driver.DSN) (*sql.DB, error) {
+		t.Fatal("the tables of gh-ost are dropped")
+		return nil, nil
+	}
+	err := newTestGhostExecutor(t).Execute(context.Background(), true)
+	assert.EqualError(t, err, "gh-ost exit with code 1, dry-run(true), output: table t1 has no unique key")
+}
+
+func TestExecutor_Execute_stopped(t *testing.T) {
+	script := `
+read spec
+echo '{"tables_created":true}'
+echo '{"progress":"copy: 1/2 50.0%"}'
+while true; do sleep 0.01; done
+`
+	mockDropTables := func() sqlmock.Sqlmock {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		mock.ExpectExec("DROP TABLE IF EXISTS `db1`.`_t1_gho`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DROP TABLE IF EXISTS `db1`.`_t1_ghc`").WillReturnResult(sqlmock.NewResult(0, 0))
+		openGhostDB = func(inst *driver.DSN) (*sql.DB, error) {
+			return db, nil
+		}
+		return mock
+	}
+	origin := openGhostDB
+	defer func() { openGhostDB = origin }()
+
+	// cancelled
+	mockGhostProcess(t, script)
+	mock := mockDropTables()
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = driver.WithProgress(ctx, func(index int, p string) {
+		cancel()
+	})
+	err := newTestGhostExecutor(t).Execute(ctx, false)
+	assert.EqualError(t, err, "migration is cancelled, dry-run(false): context canceled")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// aborted by user
+	mockGhostProcess(t, script)
+	mock = mockDropTables()
+	controller := &driver.ExecController{}
+	ctx = driver.WithExecController(context.Background(), controller)
+	ctx = driver.WithProgress(ctx, func(index int, p string) {
+		assert.NoError(t, controller.Send(driver.ExecCommandAbort))
+	})
+	done := make(chan error)
+	go func() { done <- newTestGhostExecutor(t).Execute(ctx, false) }()
+	select {
+	case err := <-done:
+		assert.EqualError(t, err, "migration is aborted by user")
+	case <-time.After(10 * time.Second):
+		t.Fatal("gh-ost is not aborted")
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -66,6 +66,8 @@ func (l *logAdaptor) Fatalf(format string, args ...interface{}) error {
 	return nil
 }
 
+// Fatale is called when the migration is aborted, it exits the gh-ost process
+// which runs the migration, see RunGhostProcess.
 func (l *logAdaptor) Fatale(err error) error {
 	l.inner.Fatalln(err)
 	return nil
@@ -0,0 +1,212 @@
+package onlineddl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ptOSCPath is the path of pt-online-schema-change, it's found in PATH by default.
+	ptOSCPath = "pt-online-schema-change"
+
+	// ptOSCStopTimeout is the time to wait for pt-online-schema-change exiting after
+	// it's interrupted, it's killed after timeout.
+	ptOSCStopTimeout = 30 * time.Second
+)
+
+// ptOSCOutputLines is the number of the last output lines in error.
+const ptOSCOutputLines = 10
+
+var (
+	ptOSCCopyingRegexp = regexp.MustCompile(`^Copying .*:\s+(\d+)% (\S+) remain`)
+	ptOSCStageRegexp   = regexp.MustCompile(`^(Creating|Altering|Copying|Analyzing|Swapping|Dropping|Successfully|Rolled back) `)
+)
+
+// PtOSCExecutor executes the ALTER by pt-online-schema-change as a subprocess, see
+// https://www.percona.com/doc/percona-toolkit/LATEST/pt-online-schema-change.html.
+type PtOSCExecutor struct {
+	l    *logrus.Entry
+	inst *driver.DSN
+
+	schema string
+	table  string
+	// alter is the changes of ALTER for the --alter option.
+	alter string
+}
+
+func NewPtOSCExecutor(logger *logrus.Entry, inst *driver.DSN, schema, table, alter string) *PtOSCExecutor {
+	return &PtOSCExecutor{
+		l: logger.WithFields(logrus.Fields{
+			"onlineddl": "pt-osc",
+			"host":      inst.Host,
+			"port":      inst.Port,
+			"alter":     alter,
+		}),
+		inst:   inst,
+		schema: schema,
+		table:  table,
+		alter:  alter,
+	}
+}
+
+// Execute runs pt-online-schema-change until it exits, the progress is parsed from its
+// output and reported. It's interrupted if ctx is done or the abort command is received,
+// pt-online-schema-change cleans up the triggers and the new table after interrupted.
+func (e *PtOSCExecutor) Execute(ctx context.Context) error {
+	// the password is passed by defaults file, so it's not shown in process list.
+	defaultsFile, err := e.writeDefaultsFile()
+	if err != nil {
+		return errors.Wrap(err, "write defaults file for pt-online-schema-change")
+	}
+	defer os.Remove(defaultsFile)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	cmd := exec.Command(ptOSCPath, e.args(defaultsFile)...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return errors.Wrap(err, "start pt-online-schema-change")
+	}
+	e.l.Infof("pt-online-schema-change is started, pid: %d", cmd.Process.Pid)
+
+	aborted := make(chan struct{})
+	unset := driver.HandleExecCommand(ctx, func(cmd driver.ExecCommand) error {
+		if cmd != driver.ExecCommandAbort {
+			return errors.Wrapf(driver.ErrExecCommandNotSupported, "command %s", cmd)
+		}
+		select {
+		case <-aborted:
+		default:
+			close(aborted)
+		}
+		return nil
+	})
+	defer unset()
+
+	exited := make(chan struct{})
+	defer close(exited)
+	go e.stopOnDone(ctx, cmd.Process, aborted, exited)
+
+	output := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		e.l.Info(line)
+		if len(output) == ptOSCOutputLines {
+			output = output[1:]
+		}
+		output = append(output, line)
+		if progress := parsePtOSCProgress(line); progress != "" {
+			driver.ReportProgress(ctx, 0, progress)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		e.l.Warnf("read output of pt-online-schema-change error: %v", err)
+		// drain the output, otherwise pt-online-schema-change is blocked on writing.
+		io.Copy(ioutil.Discard, r)
+	}
+
+	err = cmd.Wait()
+	if err == nil {
+		return nil
+	}
+	select {
+	case <-aborted:
+		return fmt.Errorf("pt-online-schema-change is aborted by user, output: %s", strings.Join(output, "\n"))
+	default:
+	}
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "pt-online-schema-change is cancelled")
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return fmt.Errorf("pt-online-schema-change exit with code %d, output: %s",
+			exitErr.ExitCode(), strings.Join(output, "\n"))
+	}
+	return err
+}
+
+// stopOnDone interrupts pt-online-schema-change when ctx is done or it's aborted, and
+// kills it if it's not exited after ptOSCStopTimeout.
+func (e *PtOSCExecutor) stopOnDone(ctx context.Context, p *os.Process, aborted, exited chan struct{}) {
+	select {
+	case <-exited:
+		return
+	case <-ctx.Done():
+	case <-aborted:
+	}
+	e.l.Warn("interrupt pt-online-schema-change")
+	if err := p.Signal(os.Interrupt); err != nil {
+		e.l.Errorf("interrupt pt-online-schema-change error: %v", err)
+	}
+	select {
+	case <-exited:
+	case <-time.After(ptOSCStopTimeout):
+		e.l.Errorf("pt-online-schema-change is not exited after interrupted %v, kill it", ptOSCStopTimeout)
+		if err := p.Kill(); err != nil {
+			e.l.Errorf("kill pt-online-schema-change error: %v", err)
+		}
+	}
+}
+
+func (e *PtOSCExecutor) writeDefaultsFile() (string, error) {
+	f, err := ioutil.TempFile("", "pt-osc.*.cnf")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	password := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(e.inst.Password)
+	if _, err := fmt.Fprintf(f, "[client]\npassword=\"%s\"\n", password); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (e *PtOSCExecutor) args(defaultsFile string) []string {
+	return []string{
+		// --defaults-file must be the first option.
+		fmt.Sprintf("--defaults-file=%s", defaultsFile),
+		fmt.Sprintf("--alter=%s", e.alter),
+		fmt.Sprintf("--host=%s", e.inst.Host),
+		fmt.Sprintf("--port=%s", e.inst.Port),
+		fmt.Sprintf("--user=%s", e.inst.User),
+		"--progress=time,5",
+		"--execute",
+		fmt.Sprintf("D=%s,t=%s", e.schema, e.table),
+	}
+}
+
+// parsePtOSCProgress returns the progress in the output line of pt-online-schema-change,
+// it's empty if the line is not about progress.
+func parsePtOSCProgress(line string) string {
+	if matches := ptOSCCopyingRegexp.FindStringSubmatch(line); matches != nil {
+		return fmt.Sprintf("copy: %s%%, ETA: %s", matches[1], matches[2])
+	}
+	if ptOSCStageRegexp.MatchString(line) {
+		return line
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package onlineddl
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPtOSC replaces pt-online-schema-change with the shell script.
+func mockPtOSC(t *testing.T, script string) {
+	path := filepath.Join(t.TempDir(), "pt-online-schema-change")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	origin := ptOSCPath
+	ptOSCPath = path
+	t.Cleanup(func() { ptOSCPath = origin })
+}
+
+func newTestPtOSCExecutor() *PtOSCExecutor {
+	return NewPtOSCExecutor(log.NewEntry(), &driver.DSN{Host: "127.0.0.1", Port: "3306", User: "root", Password: "123456"},
+		"db1", "t1", "ADD COLUMN `i` INT")
+}
+
+func TestPtOSCExecutor_Execute(t *testing.T) {
+	mockPtOSC(t, `
+case "$1" in --defaults-file=*) grep -q 'password="123456"' "${1#--defaults-file=}" || exit 3;; *) exit 3;; esac
+[ "$2" = '--alter=ADD COLUMN `+"`i`"+` INT' ] || exit 4
+[ "$8" = "D=db1,t=t1" ] || exit 5
+echo 'Altering `+"`db1`.`t1`"+`...'
+echo 'Copying `+"`db1`.`t1`"+`:  45% 01:23 remain' >&2
+echo 'Successfully altered `+"`db1`.`t1`"+`.'
+`)
+	var progress []string
+	ctx := driver.WithProgress(context.Background(), func(index int, p string) {
+		progress = append(progress, p)
+	})
+	assert.NoError(t, newTestPtOSCExecutor().Execute(ctx))
+	assert.Equal(t, []string{
+		"Altering `db1`.`t1`...",
+		"copy: 45%, ETA: 01:23",
+		"Successfully altered `db1`.`t1`.",
+	}, progress)
+}
+
+func TestPtOSCExecutor_Execute_failed(t *testing.T) {
+	mockPtOSC(t, `
+echo "Error altering new table: DBD::mysql::db do failed"
+exit 2
+`)
+	err := newTestPtOSCExecutor().Execute(context.Background())
+	assert.EqualError(t, err, "pt-online-schema-change exit with code 2, output: Error altering new table: DBD::mysql::db do failed")
+}
+
+func TestPtOSCExecutor_Execute_stopped(t *testing.T) {
+	script := `
+trap 'echo "Rolled back"; exit 1' INT
+echo "Creating triggers..."
+while true; do sleep 0.01; done
+`
+	// cancelled
+	mockPtOSC(t, script)
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = driver.WithProgress(ctx, func(index int, p string) {
+		cancel()
+	})
+	err := newTestPtOSCExecutor().Execute(ctx)
+	assert.EqualError(t, err, "pt-online-schema-change is cancelled: context canceled")
+
+	// aborted by user
+	mockPtOSC(t, script)
+	controller := &driver.ExecController{}
+	ctx = driver.WithExecController(context.Background(), controller)
+	ctx = driver.WithProgress(ctx, func(index int, p string) {
+		assert.Error(t, controller.Send(driver.ExecCommandThrottle))
+		assert.NoError(t, controller.Send(driver.ExecCommandAbort))
+	})
+	done := make(chan error)
+	go func() { done <- newTestPtOSCExecutor().Execute(ctx) }()
+	select {
+	case err := <-done:
+		assert.EqualError(t, err, "pt-online-schema-change is aborted by user, output: Creating triggers...\nRolled back")
+	case <-time.After(10 * time.Second):
+		t.Fatal("pt-online-schema-change is not aborted")
+	}
+}
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -9,7 +10,10 @@ import (
 	"sync"
 	"text/template"
 
+	"github.com/actiontech/sqle/sqle/driver/mysql/onlineddl"
+
 	"github.com/pingcap/parser/ast"
+	"github.com/pkg/errors"
 )
 
 var ptTemplate = `pt-online-schema-change D={{.Schema}},t={{.Table}} --alter='{{.Alter}}' --host={{.Host}} --user={{.User}} --port={{.Port}} --ask-pass --print --execute`
@@ -36,6 +40,59 @@ const (
 	PTOSCAvoidNoDefaultValueOnNotNullColumn = "非空字段必须设置默认值，不然 pt-online-schema-change 会执行失败"
 )
 
+const (
+	OnlineDDLToolGhost = "gh-ost"
+	OnlineDDLToolPtOSC = "pt-osc"
+)
+
+// onlineddlWithPtOSC returns true if the query is executed by pt-online-schema-change,
+// it's used instead of gh-ost if it's configured, and the table size exceeds the
+// ddl_osc_min_size.
+func (i *Inspect) onlineddlWithPtOSC(query string) (bool, error) {
+	if i.cnf.DDLOnlineDDLTool != OnlineDDLToolPtOSC || i.cnf.DDLOSCMinSize < 0 {
+		return false, nil
+	}
+
+	node, err := parseOneSql(query)
+	if err != nil {
+		return false, errors.Wrap(err, "parse SQL")
+	}
+	stmt, ok := node.(*ast.AlterTableStmt)
+	if !ok {
+		return false, nil
+	}
+
+	tableSize, err := i.getTableSize(stmt.Table)
+	if err != nil {
+		return false, errors.Wrap(err, "get table size")
+	}
+	return int64(tableSize) >= i.cnf.DDLOSCMinSize, nil
+}
+
+// execWithPtOSC executes the ALTER by pt-online-schema-change, it returns error if the
+// ALTER can not be executed by it.
+func (i *Inspect) execWithPtOSC(ctx context.Context, query string) error {
+	node, err := parseOneSql(query)
+	if err != nil {
+		return errors.Wrap(err, "parse SQL")
+	}
+	stmt := node.(*ast.AlterTableStmt)
+
+	alter, notice, err := i.getOSCAlter(stmt)
+	if err != nil {
+		return err
+	}
+	if alter == "" {
+		if notice == "" {
+			notice = "no change in ALTER"
+		}
+		return fmt.Errorf("can not execute by pt-online-schema-change: %s", notice)
+	}
+
+	executor := onlineddl.NewPtOSCExecutor(i.log, i.inst, i.getSchemaName(stmt.Table), stmt.Table.Name.O, alter)
+	return executor.Execute(ctx)
+}
+
 // generateOSCCommandLine generate pt-online-schema-change command-line statement;
 // see https://www.percona.com/doc/percona-toolkit/LATEST/pt-online-schema-change.html.
 func (i *Inspect) generateOSCCommandLine(node ast.Node) (string, error) {
@@ -56,21 +113,48 @@ func (i *Inspect) generateOSCCommandLine(node ast.Node) (string, error) {
 		return "", err
 	}
 
+	alter, notice, err := i.getOSCAlter(stmt)
+	if alter == "" {
+		return notice, err
+	}
+
+	ptTemplateMutex.Lock()
+	text := ptTemplate
+	ptTemplateMutex.Unlock()
+	tp, err := template.New("tp").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	buff := bytes.NewBufferString("")
+	err = tp.Execute(buff, map[string]interface{}{
+		"Alter":  alter,
+		"Host":   i.inst.Host,
+		"Port":   i.inst.Port,
+		"User":   i.inst.User,
+		"Schema": i.getSchemaName(stmt.Table),
+		"Table":  stmt.Table.Name.String(),
+	})
+	return buff.String(), err
+}
+
+// getOSCAlter returns the changes of ALTER for the --alter option of pt-online-schema-change,
+// the notice is returned instead if the ALTER can not be executed by it.
+func (i *Inspect) getOSCAlter(stmt *ast.AlterTableStmt) (alter string, notice string, err error) {
 	createTableStmt, exist, err := i.getCreateTableStmt(stmt.Table)
 	if !exist || err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	// In almost all cases a PRIMARY KEY or UNIQUE INDEX needs to be present in the table.
 	// This is necessary because the tool creates a DELETE trigger to keep the new table
 	// updated while the process is running.
 	if !hasPrimaryKey(createTableStmt) && !hasUniqIndex(createTableStmt) {
-		return PTOSCNoUniqueIndexOrPrimaryKey, nil
+		return "", PTOSCNoUniqueIndexOrPrimaryKey, nil
 	}
 
 	// The RENAME clause cannot be used to rename the table.
 	if len(getAlterTableSpecByTp(stmt.Specs, ast.AlterTableRenameTable)) > 0 {
-		return PTOSCAvoidRenameTable, nil
+		return "", PTOSCAvoidRenameTable, nil
 	}
 
 	// If you add a column without a default value and make it NOT NULL, the tool will fail,
@@ -79,7 +163,7 @@ func (i *Inspect) generateOSCCommandLine(node ast.Node) (string, error) {
 		for _, col := range spec.NewColumns {
 			if HasOneInOptions(col.Options, ast.ColumnOptionNotNull) {
 				if !HasOneInOptions(col.Options, ast.ColumnOptionDefaultValue) {
-					return PTOSCAvoidNoDefaultValueOnNotNullColumn, nil
+					return "", PTOSCAvoidNoDefaultValueOnNotNullColumn, nil
 				}
 			}
 		}
@@ -91,7 +175,7 @@ func (i *Inspect) generateOSCCommandLine(node ast.Node) (string, error) {
 	for _, spec := range getAlterTableSpecByTp(stmt.Specs, ast.AlterTableAddConstraint) {
 		switch spec.Constraint.Tp {
 		case ast.ConstraintUniq:
-			return PTOSCAvoidUniqueIndex, nil
+			return "", PTOSCAvoidUniqueIndex, nil
 		}
 	}
 
@@ -115,24 +199,7 @@ func (i *Inspect) generateOSCCommandLine(node ast.Node) (string, error) {
 	}
 
 	if len(changes) <= 0 {
-		return "", nil
+		return "", "", nil
 	}
-
-	ptTemplateMutex.Lock()
-	text := ptTemplate
-	ptTemplateMutex.Unlock()
-	tp, err := template.New("tp").Parse(text)
-	if err != nil {
-		return "", err
-	}
-	buff := bytes.NewBufferString("")
-	err = tp.Execute(buff, map[string]interface{}{
-		"Alter":  strings.Join(changes, ","),
-		"Host":   i.inst.Host,
-		"Port":   i.inst.Port,
-		"User":   i.inst.User,
-		"Schema": i.getSchemaName(stmt.Table),
-		"Table":  stmt.Table.Name.String(),
-	})
-	return buff.String(), err
+	return strings.Join(changes, ","), "", nil
 }
@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -58,3 +59,34 @@ func runOSCCase(t *testing.T, desc string, sql, expect string) {
 	}
 	assert.Equal(t, expect, actual, desc)
 }
+
+func TestInspect_onlineddlWithPtOSC(t *testing.T) {
+	query := "alter table exist_db.exist_tb_1 add column v3 varchar(255);"
+
+	i := DefaultMysqlInspect()
+	i.cnf.DDLOnlineDDLTool = OnlineDDLToolPtOSC
+	i.Ctx.schemas["exist_db"].Tables["exist_tb_1"].Size = 16
+	usePtOSC, err := i.onlineddlWithPtOSC(query)
+	assert.NoError(t, err)
+	assert.True(t, usePtOSC)
+	useGhost, err := i.onlineddlWithGhost(query)
+	assert.NoError(t, err)
+	assert.False(t, useGhost)
+
+	i.Ctx.schemas["exist_db"].Tables["exist_tb_1"].Size = 15
+	usePtOSC, err = i.onlineddlWithPtOSC(query)
+	assert.NoError(t, err)
+	assert.False(t, usePtOSC)
+
+	// gh-ost is used by default.
+	i = DefaultMysqlInspect()
+	i.Ctx.schemas["exist_db"].Tables["exist_tb_1"].Size = 17
+	usePtOSC, err = i.onlineddlWithPtOSC(query)
+	assert.NoError(t, err)
+	assert.False(t, usePtOSC)
+
+	// the ALTER which can not be executed by pt-online-schema-change.
+	i = DefaultMysqlInspect()
+	err = i.execWithPtOSC(context.TODO(), "alter table exist_db.exist_tb_3 add column v3 varchar(255);")
+	assert.EqualError(t, err, "can not execute by pt-online-schema-change: "+PTOSCNoUniqueIndexOrPrimaryKey)
+}
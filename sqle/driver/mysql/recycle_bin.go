@@ -0,0 +1,203 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+
+	"github.com/pingcap/parser/ast"
+)
+
+// RecycleBinSchema is the schema which the dropped tables are moved to if the recycle
+// bin is enabled.
+const RecycleBinSchema = "sqle_recycle_bin"
+
+// execWithRecycleBin moves the tables dropped by DROP TABLE or DROP DATABASE to the
+// recycle bin schema instead of dropping them, the rollback renames them back and the
+// tables in recycle bin are purged after the retention. It returns false if the query
+// is not executed, e.g. the table is not exist, the query is executed as it is.
+func (i *Inspect) execWithRecycleBin(ctx context.Context, query string) (bool, error) {
+	if i.cnf.DDLRecycleBinRetentionDays < 0 {
+		return false, nil
+	}
+	node, err := parseOneSql(query)
+	if err != nil {
+		return false, nil
+	}
+	switch stmt := node.(type) {
+	case *ast.DropTableStmt:
+		if stmt.IsView {
+			return false, nil
+		}
+		return i.dropTablesToRecycleBin(ctx, stmt)
+	case *ast.DropDatabaseStmt:
+		return i.dropSchemaToRecycleBin(ctx, stmt)
+	}
+	return false, nil
+}
+
+func (i *Inspect) dropTablesToRecycleBin(ctx context.Context, stmt *ast.DropTableStmt) (bool, error) {
+	conn, err := i.getDbConn()
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	from, to := []string{}, []string{}
+	for idx, table := range stmt.Tables {
+		schema := i.getSchemaName(table)
+		if strings.EqualFold(schema, RecycleBinSchema) {
+			// the tables in recycle bin are dropped at once.
+			return false, nil
+		}
+		result, err := conn.Db.Query(`SELECT TABLE_NAME AS table_name FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND TABLE_TYPE = 'BASE TABLE'`, schema, table.Name.O)
+		if err != nil {
+			return false, err
+		}
+		if len(result) == 0 {
+			if stmt.IfExists {
+				continue
+			}
+			// the query is failed as it's expected.
+			return false, nil
+		}
+		if hasTriggers, err := i.hasTriggers(ctx, schema, table.Name.O); err != nil || hasTriggers {
+			return false, err
+		}
+		from = append(from, getTableNameWithQuote(newTableName(schema, table.Name.O)))
+		to = append(to, getTableNameWithQuote(newTableName(RecycleBinSchema,
+			recycleBinTableName(now, idx, schema, table.Name.O))))
+	}
+	if len(from) == 0 {
+		return true, nil
+	}
+	if err := i.moveTables(ctx, from, to); err != nil {
+		return false, err
+	}
+	i.reportRecycleBin(ctx, strings.Join(from, ", "), "", from, to)
+	return true, nil
+}
+
+func (i *Inspect) dropSchemaToRecycleBin(ctx context.Context, stmt *ast.DropDatabaseStmt) (bool, error) {
+	if strings.EqualFold(stmt.Name, RecycleBinSchema) {
+		return false, nil
+	}
+	conn, err := i.getDbConn()
+	if err != nil {
+		return false, err
+	}
+	schemas, err := conn.Db.Query(`SELECT DEFAULT_CHARACTER_SET_NAME AS charset,
+DEFAULT_COLLATION_NAME AS collation FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?`, stmt.Name)
+	if err != nil {
+		return false, err
+	}
+	if len(schemas) == 0 {
+		return false, nil
+	}
+	tables, err := conn.Db.Query(`SELECT TABLE_NAME AS table_name FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME`, stmt.Name)
+	if err != nil {
+		return false, err
+	}
+	if len(tables) == 0 {
+		return false, nil
+	}
+	if hasTriggers, err := i.hasTriggers(ctx, stmt.Name, ""); err != nil || hasTriggers {
+		return false, err
+	}
+
+	now := time.Now()
+	from, to := []string{}, []string{}
+	for idx, table := range tables {
+		from = append(from, getTableNameWithQuote(newTableName(stmt.Name, table["table_name"].String)))
+		to = append(to, getTableNameWithQuote(newTableName(RecycleBinSchema,
+			recycleBinTableName(now, idx, stmt.Name, table["table_name"].String))))
+	}
+	if err := i.moveTables(ctx, from, to); err != nil {
+		return false, err
+	}
+	// the objects except tables in the schema are dropped, e.g. views and procedures.
+	if _, err := conn.Db.ExecContext(ctx, fmt.Sprintf("DROP DATABASE `%s`", stmt.Name)); err != nil {
+		if err := i.moveTables(context.Background(), to, from); err != nil {
+			i.log.Errorf("move tables back from recycle bin error: %v", err)
+		}
+		return false, err
+	}
+	createSchema := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` DEFAULT CHARACTER SET %s COLLATE %s;\n",
+		stmt.Name, schemas[0]["charset"].String, schemas[0]["collation"].String)
+	i.reportRecycleBin(ctx, fmt.Sprintf("`%s`", stmt.Name), createSchema, from, to)
+	return true, nil
+}
+
+// hasTriggers returns true if the table has triggers, all tables in the schema are
+// checked if table is empty. The table with triggers can't be renamed to the recycle
+// bin schema (ER_TRG_IN_WRONG_SCHEMA), so it's dropped as it is.
+func (i *Inspect) hasTriggers(ctx context.Context, schema, table string) (bool, error) {
+	conn, err := i.getDbConn()
+	if err != nil {
+		return false, err
+	}
+	query := "SELECT TRIGGER_NAME AS trigger_name FROM information_schema.TRIGGERS WHERE EVENT_OBJECT_SCHEMA = ?"
+	args := []interface{}{schema}
+	if table != "" {
+		query += " AND EVENT_OBJECT_TABLE = ?"
+		args = append(args, table)
+	}
+	result, err := conn.Db.Query(query+" LIMIT 1", args...)
+	if err != nil || len(result) == 0 {
+		return false, err
+	}
+	object := fmt.Sprintf("`%s`", schema)
+	if table != "" {
+		object = getTableNameWithQuote(newTableName(schema, table))
+	}
+	i.log.Warnf("%s has triggers, it's dropped instead of moved to recycle bin", object)
+	driver.ReportProgress(ctx, 0, fmt.Sprintf("%s has triggers, it's dropped instead of moved to recycle bin", object))
+	return true, nil
+}
+
+// moveTables renames the tables atomically, the recycle bin schema is created if it's
+// not exist.
+func (i *Inspect) moveTables(ctx context.Context, from, to []string) error {
+	conn, err := i.getDbConn()
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", RecycleBinSchema)); err != nil {
+		return err
+	}
+	_, err = conn.Db.ExecContext(ctx, fmt.Sprintf("RENAME TABLE %s", renamePairs(from, to)))
+	return err
+}
+
+// reportRecycleBin reports the rollback which moves the tables back from recycle bin,
+// the object is the name of dropped table or schema.
+func (i *Inspect) reportRecycleBin(ctx context.Context, object, preRollbackSQL string, from, to []string) {
+	driver.ReportExecRollback(ctx, 0, &driver.ExecRollback{
+		RollbackSQL:      fmt.Sprintf("%sRENAME TABLE %s;", preRollbackSQL, renamePairs(to, from)),
+		CleanSQL:         fmt.Sprintf("DROP TABLE IF EXISTS %s", strings.Join(to, ", ")),
+		Retention:        time.Duration(i.cnf.DDLRecycleBinRetentionDays) * 24 * time.Hour,
+		RecycleBinObject: object,
+	})
+}
+
+func renamePairs(from, to []string) string {
+	pairs := make([]string, 0, len(from))
+	for idx := range from {
+		pairs = append(pairs, fmt.Sprintf("%s TO %s", from[idx], to[idx]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// recycleBinTableName returns the timestamped name of the table in recycle bin, which
+// is no longer than 64 characters.
+func recycleBinTableName(now time.Time, index int, schema, table string) string {
+	name := fmt.Sprintf("%s_%d_%s_%s", now.Format("20060102150405"), index, schema, table)
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
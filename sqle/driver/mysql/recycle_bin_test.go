@@ -0,0 +1,178 @@
+package mysql
+
+import (
+	"context"
+	_driver "database/sql/driver"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRecycleBinTestInspect(t *testing.T) (*Inspect, sqlmock.Sqlmock) {
+	i := DefaultMysqlInspect()
+	i.cnf.DDLRecycleBinRetentionDays = 7
+	return i, mockInspectDbConn(t, i)
+}
+
+func recycleBinTestContext(rollback **driver.ExecRollback) context.Context {
+	return driver.WithExecRollback(context.Background(), func(index int, r *driver.ExecRollback) {
+		*rollback = r
+	})
+}
+
+func expectTriggers(mock sqlmock.Sqlmock, exist bool, args ..._driver.Value) {
+	rows := sqlmock.NewRows([]string{"trigger_name"})
+	if exist {
+		rows.AddRow("trg_1")
+	}
+	mock.ExpectQuery("FROM information_schema.TRIGGERS").WithArgs(args...).WillReturnRows(rows)
+}
+
+func TestInspect_Exec_dropTableToRecycleBin(t *testing.T) {
+	i, mock := newRecycleBinTestInspect(t)
+	mock.ExpectQuery("SELECT TABLE_NAME AS table_name FROM information_schema.TABLES").
+		WithArgs("exist_db", "exist_tb_1").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("exist_tb_1"))
+	expectTriggers(mock, false, "exist_db", "exist_tb_1")
+	mock.ExpectExec(regexp.QuoteMeta("CREATE DATABASE IF NOT EXISTS `sqle_recycle_bin`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RENAME TABLE `exist_db`.`exist_tb_1` TO `sqle_recycle_bin`.`[0-9]{14}_0_exist_db_exist_tb_1`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var rollback *driver.ExecRollback
+	_, err := i.Exec(recycleBinTestContext(&rollback), "drop table exist_db.exist_tb_1")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NotNil(t, rollback)
+	assert.Regexp(t, "^RENAME TABLE `sqle_recycle_bin`.`[0-9]{14}_0_exist_db_exist_tb_1` TO `exist_db`.`exist_tb_1`;$",
+		rollback.RollbackSQL)
+	assert.Regexp(t, "^DROP TABLE IF EXISTS `sqle_recycle_bin`.`[0-9]{14}_0_exist_db_exist_tb_1`$", rollback.CleanSQL)
+	assert.Equal(t, 7*24*time.Hour, rollback.Retention)
+	assert.Equal(t, "`exist_db`.`exist_tb_1`", rollback.RecycleBinObject)
+
+	// the table which is not exist is skipped by IF EXISTS.
+	i, mock = newRecycleBinTestInspect(t)
+	mock.ExpectQuery("SELECT TABLE_NAME AS table_name FROM information_schema.TABLES").
+		WithArgs("exist_db", "not_exist_tb").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}))
+	rollback = nil
+	_, err = i.Exec(recycleBinTestContext(&rollback), "drop table if exists exist_db.not_exist_tb")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, rollback)
+
+	// the query is executed as it is if the table is not exist.
+	i, mock = newRecycleBinTestInspect(t)
+	mock.ExpectQuery("SELECT TABLE_NAME AS table_name FROM information_schema.TABLES").
+		WithArgs("exist_db", "not_exist_tb").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}))
+	mock.ExpectExec(regexp.QuoteMeta("drop table exist_db.not_exist_tb")).
+		WillReturnError(fmt.Errorf("unknown table"))
+	_, err = i.Exec(recycleBinTestContext(&rollback), "drop table exist_db.not_exist_tb")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, rollback)
+
+	// the table with triggers can't be renamed to other schema, it's dropped as it is.
+	i, mock = newRecycleBinTestInspect(t)
+	mock.ExpectQuery("SELECT TABLE_NAME AS table_name FROM information_schema.TABLES").
+		WithArgs("exist_db", "exist_tb_1").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("exist_tb_1"))
+	expectTriggers(mock, true, "exist_db", "exist_tb_1")
+	mock.ExpectExec(regexp.QuoteMeta("drop table exist_db.exist_tb_1")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = i.Exec(recycleBinTestContext(&rollback), "drop table exist_db.exist_tb_1")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, rollback)
+
+	// recycle bin is disabled.
+	i, mock = newRecycleBinTestInspect(t)
+	i.cnf.DDLRecycleBinRetentionDays = -1
+	mock.ExpectExec(regexp.QuoteMeta("drop table exist_db.exist_tb_1")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = i.Exec(recycleBinTestContext(&rollback), "drop table exist_db.exist_tb_1")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, rollback)
+
+	// the clean SQL and the rollback SQL are executed as they are.
+	i, mock = newRecycleBinTestInspect(t)
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE IF EXISTS `exist_db`.`_exist_tb_1_bak`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = i.Exec(driver.WithRawExec(recycleBinTestContext(&rollback)), "DROP TABLE IF EXISTS `exist_db`.`_exist_tb_1_bak`")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, rollback)
+}
+
+func TestInspect_Exec_dropSchemaToRecycleBin(t *testing.T) {
+	i, mock := newRecycleBinTestInspect(t)
+	mock.ExpectQuery("SELECT DEFAULT_CHARACTER_SET_NAME AS charset").WithArgs("exist_db").
+		WillReturnRows(sqlmock.NewRows([]string{"charset", "collation"}).AddRow("utf8mb4", "utf8mb4_bin"))
+	mock.ExpectQuery("SELECT TABLE_NAME AS table_name FROM information_schema.TABLES").WithArgs("exist_db").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("exist_tb_1").AddRow("exist_tb_2"))
+	expectTriggers(mock, false, "exist_db")
+	mock.ExpectExec(regexp.QuoteMeta("CREATE DATABASE IF NOT EXISTS `sqle_recycle_bin`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RENAME TABLE `exist_db`.`exist_tb_1` TO `sqle_recycle_bin`.`[0-9]{14}_0_exist_db_exist_tb_1`, " +
+		"`exist_db`.`exist_tb_2` TO `sqle_recycle_bin`.`[0-9]{14}_1_exist_db_exist_tb_2`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DROP DATABASE `exist_db`")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var rollback *driver.ExecRollback
+	_, err := i.Exec(recycleBinTestContext(&rollback), "drop database exist_db")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NotNil(t, rollback)
+	assert.Regexp(t, "^CREATE DATABASE IF NOT EXISTS `exist_db` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_bin;\n"+
+		"RENAME TABLE `sqle_recycle_bin`.`[0-9]{14}_0_exist_db_exist_tb_1` TO `exist_db`.`exist_tb_1`, "+
+		"`sqle_recycle_bin`.`[0-9]{14}_1_exist_db_exist_tb_2` TO `exist_db`.`exist_tb_2`;$", rollback.RollbackSQL)
+	assert.Equal(t, "`exist_db`", rollback.RecycleBinObject)
+
+	// the tables are moved back if the schema is failed to drop.
+	i, mock = newRecycleBinTestInspect(t)
+	mock.ExpectQuery("SELECT DEFAULT_CHARACTER_SET_NAME AS charset").WithArgs("exist_db").
+		WillReturnRows(sqlmock.NewRows([]string{"charset", "collation"}).AddRow("utf8mb4", "utf8mb4_bin"))
+	mock.ExpectQuery("SELECT TABLE_NAME AS table_name FROM information_schema.TABLES").WithArgs("exist_db").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("exist_tb_1"))
+	expectTriggers(mock, false, "exist_db")
+	mock.ExpectExec(regexp.QuoteMeta("CREATE DATABASE IF NOT EXISTS `sqle_recycle_bin`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RENAME TABLE `exist_db`.`exist_tb_1` TO").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DROP DATABASE `exist_db`")).WillReturnError(fmt.Errorf("access denied"))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE DATABASE IF NOT EXISTS `sqle_recycle_bin`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RENAME TABLE `sqle_recycle_bin`.`[0-9]{14}_0_exist_db_exist_tb_1` TO `exist_db`.`exist_tb_1`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rollback = nil
+	_, err = i.Exec(recycleBinTestContext(&rollback), "drop database exist_db")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, rollback)
+
+	// the schema with triggers is dropped as it is.
+	i, mock = newRecycleBinTestInspect(t)
+	mock.ExpectQuery("SELECT DEFAULT_CHARACTER_SET_NAME AS charset").WithArgs("exist_db").
+		WillReturnRows(sqlmock.NewRows([]string{"charset", "collation"}).AddRow("utf8mb4", "utf8mb4_bin"))
+	mock.ExpectQuery("SELECT TABLE_NAME AS table_name FROM information_schema.TABLES").WithArgs("exist_db").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("exist_tb_1"))
+	expectTriggers(mock, true, "exist_db")
+	mock.ExpectExec(regexp.QuoteMeta("drop database exist_db")).WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = i.Exec(recycleBinTestContext(&rollback), "drop database exist_db")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, rollback)
+}
+
+func TestRecycleBinTableName(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.Local)
+	assert.Equal(t, "20210102030405_1_db1_t1", recycleBinTableName(now, 1, "db1", "t1"))
+	assert.Len(t, recycleBinTableName(now, 1, "db1", fmt.Sprintf("%064d", 0)), 64)
+}
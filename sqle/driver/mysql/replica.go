@@ -0,0 +1,87 @@
+package mysql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/actiontech/sqle/sqle/driver"
+
+	"github.com/sirupsen/logrus"
+)
+
+// replicaLagChecker checks the replication lag of the replicas of instance. The replicas
+// are found by "SHOW SLAVE HOSTS", and connected with the user and password of instance,
+// the replica which is not found by it (report_host is not set) is ignored.
+type replicaLagChecker struct {
+	log      *logrus.Entry
+	replicas map[string]*Executor
+}
+
+func (i *Inspect) newReplicaLagChecker() (*replicaLagChecker, error) {
+	conn, err := i.getDbConn()
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := conn.Db.Query("SHOW SLAVE HOSTS")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &replicaLagChecker{
+		log:      i.log,
+		replicas: map[string]*Executor{},
+	}
+	for _, host := range hosts {
+		if host["Host"].String == "" {
+			i.log.Warnf("replica %s is ignored by replication lag check, report_host is not set on it",
+				host["Server_id"].String)
+			continue
+		}
+		replica := &driver.DSN{
+			Host:     host["Host"].String,
+			Port:     host["Port"].String,
+			User:     i.inst.User,
+			Password: i.inst.Password,
+		}
+		conn, err := NewExecutor(i.log, replica, "")
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("connect to replica %s:%s error: %v", replica.Host, replica.Port, err)
+		}
+		c.replicas[fmt.Sprintf("%s:%s", replica.Host, replica.Port)] = conn
+	}
+	return c, nil
+}
+
+// MaxLag returns the max Seconds_Behind_Master of the replicas, and the replica which
+// has the max lag. It returns error if the replication of any replica is not running.
+func (c *replicaLagChecker) MaxLag() (int64, string, error) {
+	var maxLag int64
+	var maxLagReplica string
+	for replica, conn := range c.replicas {
+		status, err := conn.Db.Query("SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, replica, err
+		}
+		for _, channel := range status {
+			if !channel["Seconds_Behind_Master"].Valid {
+				return 0, replica, fmt.Errorf("replication of replica %s is not running", replica)
+			}
+			lag, err := strconv.ParseInt(channel["Seconds_Behind_Master"].String, 10, 64)
+			if err != nil {
+				return 0, replica, err
+			}
+			if lag > maxLag || maxLagReplica == "" {
+				maxLag = lag
+				maxLagReplica = replica
+			}
+		}
+	}
+	return maxLag, maxLagReplica, nil
+}
+
+func (c *replicaLagChecker) Close() {
+	for _, conn := range c.replicas {
+		conn.Db.Close()
+	}
+}
@@ -9,7 +9,10 @@ import (
 	"github.com/actiontech/sqle/sqle/errors"
 
 	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
 	_model "github.com/pingcap/parser/model"
+	"github.com/pingcap/tidb/types"
+	driver "github.com/pingcap/tidb/types/parser_driver"
 )
 
 func (i *Inspect) GenerateRollbackSql(node ast.Node) (string, string, error) {
@@ -61,9 +64,12 @@ const (
 	NotSupportMultiTableStatementRollback     = "暂不支持回滚多表的 DML 语句"
 	NotSupportOnDuplicatStatementRollback     = "暂不支持回滚 ON DUPLICATE 语句"
 	NotSupportSubQueryStatementRollback       = "暂不支持回滚带子查询的语句"
-	NotSupportNoPrimaryKeyTableRollback       = "不支持回滚没有主键的表的DML语句"
+	NotSupportNoPrimaryKeyTableRollback       = "不支持回滚没有主键或非空唯一键的表的DML语句"
 	NotSupportInsertWithoutPrimaryKeyRollback = "不支持回滚 INSERT 没有指定主键的语句"
 	NotSupportExceedMaxRowsRollback           = "预计影响行数超过配置的最大值，不生成回滚语句"
+	ExceedMaxRowsRollbackWithBackup           = "预计影响行数超过配置的最大值，上线前将变更的数据备份到备份表，通过备份表回滚"
+	ExceedMaxRowsRollbackWithChunkedBackup    = "预计影响行数超过配置的最大值，上线前将变更的数据备份到备份表，通过备份表回滚；语句分批上线，备份和上线不在同一事务中，备份后到上线前其他会话修改的数据在回滚时会被覆盖"
+	KeylessTableRollbackRisk                  = "表没有主键和非空唯一键，回滚语句按整行匹配并限制 LIMIT 1，表中存在重复行时可能回滚其他行"
 )
 
 // generateAlterTableRollbackSql generate alter table SQL for alter table.
@@ -373,13 +379,23 @@ func (i *Inspect) generateInsertRollbackSql(stmt *ast.InsertStmt) (string, strin
 	if !exist {
 		return "", "", nil
 	}
-	pkColumnsName, hasPk, err := i.getPrimaryKey(createTableStmt)
-	if err != nil {
-		return "", "", err
-	}
-	if !hasPk {
+	keyColumnsName, hasKey := getRollbackKey(createTableStmt)
+	if !hasKey && !i.cnf.DMLRollbackKeylessTable {
 		return "", NotSupportNoPrimaryKeyTableRollback, nil
 	}
+	// the inserted rows are matched by the inserted values if the table has no key.
+	reason, limit := "", ""
+	columns := map[string]*rollbackColumn{}
+	if !hasKey {
+		reason, limit = KeylessTableRollbackRisk, " LIMIT 1"
+		rollbackColumns, err := i.getRollbackColumns(table, createTableStmt)
+		if err != nil {
+			return "", "", err
+		}
+		for _, col := range rollbackColumns {
+			columns[strings.ToLower(col.name)] = col
+		}
+	}
 
 	rollbackSql := ""
 
@@ -406,18 +422,25 @@ func (i *Inspect) generateInsertRollbackSql(stmt *ast.InsertStmt) (string, strin
 				return "", "", nil
 			}
 			for n, name := range columnsName {
-				_, isPk := pkColumnsName[name]
-				if isPk {
-					where = append(where, fmt.Sprintf("%s = '%s'", name, exprFormat(value[n])))
+				_, isKey := keyColumnsName[strings.ToLower(name)]
+				if isKey {
+					where = append(where, fmt.Sprintf("%s = %s", name, insertKeyValueFormat(value[n])))
+				} else if col, ok := columns[strings.ToLower(name)]; ok {
+					if cond, ok := col.matchValueCondition(value[n]); ok {
+						where = append(where, cond)
+					}
 				}
 			}
-			if len(where) != len(pkColumnsName) {
+			if hasKey && len(where) != len(keyColumnsName) {
 				return "", NotSupportInsertWithoutPrimaryKeyRollback, nil
 			}
-			rollbackSql += fmt.Sprintf("DELETE FROM %s WHERE %s;\n",
-				i.getTableNameWithQuote(table), strings.Join(where, " AND "))
+			if len(where) == 0 {
+				return "", NotSupportNoPrimaryKeyTableRollback, nil
+			}
+			rollbackSql += fmt.Sprintf("DELETE FROM %s WHERE %s%s;\n",
+				i.getTableNameWithQuote(table), strings.Join(where, " AND "), limit)
 		}
-		return rollbackSql, "", nil
+		return rollbackSql, reason, nil
 	}
 
 	// match "insert into table_name set col_name = value1, ..."
@@ -428,31 +451,32 @@ func (i *Inspect) generateInsertRollbackSql(stmt *ast.InsertStmt) (string, strin
 		where := []string{}
 		for _, setExpr := range stmt.Setlist {
 			name := setExpr.Column.Name.String()
-			_, isPk := pkColumnsName[name]
-			if isPk {
-				where = append(where, fmt.Sprintf("%s = '%s'", name, exprFormat(setExpr.Expr)))
+			_, isKey := keyColumnsName[setExpr.Column.Name.L]
+			if isKey {
+				where = append(where, fmt.Sprintf("%s = %s", name, insertKeyValueFormat(setExpr.Expr)))
+			} else if col, ok := columns[setExpr.Column.Name.L]; ok {
+				if cond, ok := col.matchValueCondition(setExpr.Expr); ok {
+					where = append(where, cond)
+				}
 			}
 		}
-		if len(where) != len(pkColumnsName) {
+		if hasKey && len(where) != len(keyColumnsName) {
 			return "", "", nil
 		}
-		rollbackSql = fmt.Sprintf("DELETE FROM %s WHERE %s;\n",
-			i.getTableNameWithQuote(table), strings.Join(where, " AND "))
+		if len(where) == 0 {
+			return "", NotSupportNoPrimaryKeyTableRollback, nil
+		}
+		rollbackSql = fmt.Sprintf("DELETE FROM %s WHERE %s%s;\n",
+			i.getTableNameWithQuote(table), strings.Join(where, " AND "), limit)
 	}
-	return rollbackSql, "", nil
+	return rollbackSql, reason, nil
 }
 
 // generateDeleteRollbackSql generate insert SQL for delete.
 func (i *Inspect) generateDeleteRollbackSql(stmt *ast.DeleteStmt) (string, string, error) {
-	// not support multi-table syntax
-	if stmt.IsMultiTable {
-		i.Logger().Infof("not support generate rollback sql with multi-delete statement")
-		return "", NotSupportMultiTableStatementRollback, nil
-	}
-	// sub query statement
-	if whereStmtHasSubQuery(stmt.Where) {
-		i.Logger().Infof("not support generate rollback sql with sub query")
-		return "", NotSupportSubQueryStatementRollback, nil
+	// multi-table or sub query statement
+	if stmt.IsMultiTable || whereStmtHasSubQuery(stmt.Where) {
+		return i.generateDeleteRollbackSqlByPrimaryKeys(stmt)
 	}
 	var err error
 	tables := getTables(stmt.TableRefs.TableRefs)
@@ -461,13 +485,12 @@ func (i *Inspect) generateDeleteRollbackSql(stmt *ast.DeleteStmt) (string, strin
 	if err != nil || !exist {
 		return "", "", err
 	}
-	_, hasPk, err := i.getPrimaryKey(createTableStmt)
-	if err != nil {
-		return "", "", err
-	}
-	if !hasPk {
+	// the deleted rows are inserted back as they are, even if the table has no key.
+	_, hasKey := getRollbackKey(createTableStmt)
+	if !hasKey && !i.cnf.DMLRollbackKeylessTable {
 		return "", NotSupportNoPrimaryKeyTableRollback, nil
 	}
+	_, hasPk := getPrimaryKey(createTableStmt)
 
 	var max = i.cnf.DMLRollbackMaxRows
 	limit, err := getLimitCount(stmt.Limit, max+1)
@@ -480,38 +503,42 @@ func (i *Inspect) generateDeleteRollbackSql(stmt *ast.DeleteStmt) (string, strin
 			return "", "", err
 		}
 		if count > max {
-			return "", NotSupportExceedMaxRowsRollback, nil
+			reason, err := i.exceedMaxRowsRollbackReason(!hasPk, stmt.Text())
+			return "", reason, err
 		}
 	}
-	records, err := i.getRecords(table, "", stmt.Where, stmt.Order, limit)
-
-	values := []string{}
+	columns, err := i.getRollbackColumns(table, createTableStmt)
+	if err != nil {
+		return "", "", err
+	}
+	w := i.newRollbackWriter()
+	tableName := i.getTableNameWithQuote(table)
+	err = i.queryRecords(table, "", columns, stmt.Where, stmt.Order, limit, func(record map[string]sql.NullString) error {
+		w.writeInsert(tableName, columns, record)
+		return nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return w.String(), "", nil
+}
 
+// insertSqlPrefix returns the prefix of insert SQL which restores the deleted records.
+func insertSqlPrefix(table string, columns []*rollbackColumn) string {
 	columnsName := []string{}
-	for _, col := range createTableStmt.Cols {
-		columnsName = append(columnsName, col.Name.Name.String())
+	for _, col := range columns {
+		columnsName = append(columnsName, col.name)
 	}
-	for _, record := range records {
-		if len(record) != len(columnsName) {
-			return "", "", nil
-		}
-		vs := []string{}
-		for _, name := range columnsName {
-			v := "NULL"
-			if record[name].Valid {
-				v = fmt.Sprintf("'%s'", record[name].String)
-			}
-			vs = append(vs, v)
-		}
-		values = append(values, fmt.Sprintf("(%s)", strings.Join(vs, ", ")))
-	}
-	rollbackSql := ""
-	if len(values) > 0 {
-		rollbackSql = fmt.Sprintf("INSERT INTO %s (`%s`) VALUES %s;",
-			i.getTableNameWithQuote(table), strings.Join(columnsName, "`, `"),
-			strings.Join(values, ", "))
+	return fmt.Sprintf("INSERT INTO %s (`%s`) VALUES ", table, strings.Join(columnsName, "`, `"))
+}
+
+// insertSqlValues returns the values of insert SQL which restores the deleted record.
+func insertSqlValues(columns []*rollbackColumn, record map[string]sql.NullString) string {
+	vs := []string{}
+	for _, col := range columns {
+		vs = append(vs, col.literal(record[col.name]))
 	}
-	return rollbackSql, "", nil
+	return fmt.Sprintf("(%s)", strings.Join(vs, ", "))
 }
 
 // generateUpdateRollbackSql generate update SQL for update.
@@ -519,13 +546,7 @@ func (i *Inspect) generateUpdateRollbackSql(stmt *ast.UpdateStmt) (string, strin
 	tableSources := getTableSources(stmt.TableRefs.TableRefs)
 	// multi table syntax
 	if len(tableSources) != 1 {
-		i.Logger().Infof("not support generate rollback sql with multi-update statement")
-		return "", NotSupportMultiTableStatementRollback, nil
-	}
-	// sub query statement
-	if whereStmtHasSubQuery(stmt.Where) {
-		i.Logger().Infof("not support generate rollback sql with sub query")
-		return "", NotSupportSubQueryStatementRollback, nil
+		return i.generateUpdateRollbackSqlByPrimaryKeys(stmt)
 	}
 	var (
 		table      *ast.TableName
@@ -542,17 +563,19 @@ func (i *Inspect) generateUpdateRollbackSql(stmt *ast.UpdateStmt) (string, strin
 	default:
 		return "", NotSupportStatementRollback, nil
 	}
+	// sub query statement
+	if whereStmtHasSubQuery(stmt.Where) {
+		return i.generateUpdateRollbackSqlByPrimaryKeys(stmt)
+	}
 	createTableStmt, exist, err := i.getCreateTableStmt(table)
 	if err != nil || !exist {
 		return "", "", err
 	}
-	pkColumnsName, hasPk, err := i.getPrimaryKey(createTableStmt)
-	if err != nil {
-		return "", "", err
-	}
-	if !hasPk {
+	keyColumnsName, hasKey := getRollbackKey(createTableStmt)
+	if !hasKey && !i.cnf.DMLRollbackKeylessTable {
 		return "", NotSupportNoPrimaryKeyTableRollback, nil
 	}
+	_, hasPk := getPrimaryKey(createTableStmt)
 
 	var max = i.cnf.DMLRollbackMaxRows
 	limit, err := getLimitCount(stmt.Limit, max+1)
@@ -565,70 +588,134 @@ func (i *Inspect) generateUpdateRollbackSql(stmt *ast.UpdateStmt) (string, strin
 			return "", "", err
 		}
 		if count > max {
-			return "", NotSupportExceedMaxRowsRollback, nil
+			// the rows are backed up only if the table has primary key.
+			reason, err := i.exceedMaxRowsRollbackReason(!hasPk || updatePrimaryKey(stmt, keyColumnsName), stmt.Text())
+			return "", reason, err
 		}
 	}
-	records, err := i.getRecords(table, tableAlias, stmt.Where, stmt.Order, limit)
-
-	columnsName := []string{}
-	rollbackSql := ""
-	for _, col := range createTableStmt.Cols {
-		columnsName = append(columnsName, col.Name.Name.String())
+	columns, err := i.getRollbackColumns(table, createTableStmt)
+	if err != nil {
+		return "", "", err
 	}
-	for _, record := range records {
-		if len(record) != len(columnsName) {
-			return "", "", nil
-		}
-		where := []string{}
-		value := []string{}
-		for _, col := range createTableStmt.Cols {
-			colChanged := false
-			_, isPk := pkColumnsName[col.Name.Name.L]
-			isPkChanged := false
-			pkValue := ""
-
-			for _, l := range stmt.List {
-				if col.Name.Name.L == l.Column.Name.L {
-					colChanged = true
-					if isPk {
-						isPkChanged = true
-						pkValue = exprFormat(l.Expr)
-					}
-				}
-			}
-			name := col.Name.String()
-			v := "NULL"
-			if record[name].Valid {
-				v = fmt.Sprintf("'%s'", record[name].String)
-			}
+	w := i.newRollbackWriter()
+	tableName := i.getTableNameWithQuote(table)
+	err = i.queryRecords(table, tableAlias, columns, stmt.Where, stmt.Order, limit, func(record map[string]sql.NullString) error {
+		w.writeUpdate(tableName, columns, keyColumnsName, stmt.List, record)
+		return nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if !hasKey && w.count > 0 {
+		return w.String(), KeylessTableRollbackRisk, nil
+	}
+	return w.String(), "", nil
+}
 
-			if colChanged {
-				value = append(value, fmt.Sprintf("%s = %s", name, v))
+// generateUpdateSqlFromRecord generate update SQL which restores the columns changed by
+// the assignments of the updated record. The row is matched by the key columns, or by
+// the full row with LIMIT 1 if keyColumnsName is empty. It returns empty string if the
+// row can't be matched.
+func generateUpdateSqlFromRecord(table string, columns []*rollbackColumn,
+	keyColumnsName map[string]struct{}, list []*ast.Assignment, record map[string]sql.NullString) string {
+	keyless := len(keyColumnsName) == 0
+	where := []string{}
+	value := []string{}
+	for _, col := range columns {
+		_, isKey := keyColumnsName[strings.ToLower(col.name)]
+		var assignment *ast.Assignment
+		for _, l := range list {
+			if strings.ToLower(col.name) == l.Column.Name.L {
+				assignment = l
 			}
-			if isPk {
-				if isPkChanged {
-					where = append(where, fmt.Sprintf("%s = '%s'", name, pkValue))
-				} else {
-					where = append(where, fmt.Sprintf("%s = %s", name, v))
+		}
+		v := col.literal(record[col.name])
 
-				}
+		if assignment != nil {
+			value = append(value, fmt.Sprintf("`%s` = %s", col.name, v))
+		}
+		switch {
+		case isKey && assignment != nil:
+			where = append(where, fmt.Sprintf("`%s` = %s", col.name, assignmentValueFormat(assignment.Expr)))
+		case isKey:
+			where = append(where, fmt.Sprintf("`%s` = %s", col.name, v))
+		case keyless && assignment != nil:
+			// the changed column is matched only if it's changed to constant.
+			if cond, ok := col.matchValueCondition(assignment.Expr); ok {
+				where = append(where, cond)
 			}
+		case keyless && col.canMatch():
+			where = append(where, col.matchCondition(v))
 		}
-		rollbackSql += fmt.Sprintf("UPDATE %s SET %s WHERE %s;", i.getTableNameWithQuote(table),
+	}
+	if len(where) == 0 {
+		return ""
+	}
+	if keyless {
+		return fmt.Sprintf("UPDATE %s SET %s WHERE %s LIMIT 1;", table,
 			strings.Join(value, ", "), strings.Join(where, " AND "))
 	}
-	return rollbackSql, "", nil
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s;", table,
+		strings.Join(value, ", "), strings.Join(where, " AND "))
 }
 
-// getRecords select all data which will be update or delete.
-func (i *Inspect) getRecords(tableName *ast.TableName, tableAlias string, where ast.ExprNode,
-	order *ast.OrderByClause, limit int64) ([]map[string]sql.NullString, error) {
+// queryRecords select all data which will be update or delete, fn is called with each
+// record fetched from cursor.
+func (i *Inspect) queryRecords(tableName *ast.TableName, tableAlias string, columns []*rollbackColumn,
+	where ast.ExprNode, order *ast.OrderByClause, limit int64, fn func(record map[string]sql.NullString) error) error {
 	conn, err := i.getDbConn()
 	if err != nil {
-		return nil, err
+		return err
+	}
+	sql := i.generateGetRecordsSql(rollbackSelectExpr(columns), tableName, tableAlias, where, order, limit)
+	return conn.Db.QueryRows(fn, sql)
+}
+
+// assignmentValueFormat formats the value assigned by UPDATE, the string is quoted by
+// single quotes which exprFormat doesn't do.
+func assignmentValueFormat(expr ast.ExprNode) string {
+	v, err := restoreToSqlWithFlag(format.DefaultRestoreFlags, expr)
+	if err != nil {
+		return exprFormat(expr)
+	}
+	return v
+}
+
+// insertKeyValueFormat formats the key value of INSERT, the string is restored as it is
+// rather than quoted again, which exprFormat does.
+func insertKeyValueFormat(expr ast.ExprNode) string {
+	if v, ok := expr.(*driver.ValueExpr); ok && v.Kind() == types.KindString {
+		return assignmentValueFormat(expr)
+	}
+	return fmt.Sprintf("'%s'", exprFormat(expr))
+}
+
+// exceedMaxRowsRollbackReason returns the reason of UPDATE/DELETE whose affected rows
+// are more than DMLRollbackMaxRows, the rows are backed up before execution if it's
+// enabled, unless the UPDATE changes the primary key. The rows are backed up in the
+// transaction of DML, except the DML executed in chunks.
+func (i *Inspect) exceedMaxRowsRollbackReason(updatePrimaryKey bool, query string) (string, error) {
+	if i.cnf.DMLRollbackBackupRetentionHours < 0 || updatePrimaryKey {
+		return NotSupportExceedMaxRowsRollback, nil
+	}
+	chunked, err := i.getChunkedDML(query)
+	if err != nil {
+		return "", err
+	}
+	if chunked != nil {
+		return ExceedMaxRowsRollbackWithChunkedBackup, nil
+	}
+	return ExceedMaxRowsRollbackWithBackup, nil
+}
+
+// updatePrimaryKey returns true if the UPDATE changes any primary key column.
+func updatePrimaryKey(stmt *ast.UpdateStmt, pkColumnsName map[string]struct{}) bool {
+	for _, l := range stmt.List {
+		if _, isPk := pkColumnsName[l.Column.Name.L]; isPk {
+			return true
+		}
 	}
-	sql := i.generateGetRecordsSql("*", tableName, tableAlias, where, order, limit)
-	return conn.Db.Query(sql)
+	return false
 }
 
 // getRecordCount select all data count which will be update or delete.
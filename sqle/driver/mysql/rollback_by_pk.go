@@ -0,0 +1,348 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+)
+
+// NotSupportUpdatePrimaryKeyRollback is the reason of the multi-table or sub query UPDATE
+// which changes the primary key, the new primary key can't be resolved before execution.
+const NotSupportUpdatePrimaryKeyRollback = "暂不支持回滚修改主键的多表或带子查询的 UPDATE 语句"
+
+// pkLookupMaxPlaceholders is the max number of placeholders in the SQL which selects
+// the records by primary keys.
+var pkLookupMaxPlaceholders = 10000
+
+// dmlTarget is a table changed by the multi-table or sub query DML, the rollback SQL of
+// the DML is generated for each target by the records whose primary keys are resolved
+// by the table references and where condition of the DML.
+type dmlTarget struct {
+	// source is the quoted name referred in the DML, which is the alias if it's set.
+	source        string
+	table         *ast.TableName
+	columns       []*rollbackColumn
+	pkColumnsName map[string]struct{}
+	// pkColumns are the primary key columns in the order of table definition.
+	pkColumns []string
+	// assignments are the assignments on the target of UPDATE.
+	assignments []*ast.Assignment
+	// pks are the primary keys of the records changed by the DML.
+	pks []map[string]sql.NullString
+}
+
+// newDMLTarget returns nil if the table is not exist, the DML will fail.
+func (i *Inspect) newDMLTarget(source *ast.TableSource) (*dmlTarget, string, error) {
+	table, ok := source.Source.(*ast.TableName)
+	if !ok {
+		return nil, NotSupportStatementRollback, nil
+	}
+	createTableStmt, exist, err := i.getCreateTableStmt(table)
+	if err != nil || !exist {
+		return nil, "", err
+	}
+	// the not null unique key is used as primary key if the table has no primary key.
+	pkColumnsName, hasKey := getRollbackKey(createTableStmt)
+	if !hasKey {
+		return nil, NotSupportNoPrimaryKeyTableRollback, nil
+	}
+	target := &dmlTarget{
+		source:        tableSourceQuotedName(source),
+		table:         newTableName(i.getSchemaName(table), table.Name.String()),
+		pkColumnsName: pkColumnsName,
+	}
+	target.columns, err = i.getRollbackColumns(target.table, createTableStmt)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, col := range createTableStmt.Cols {
+		if _, isPk := pkColumnsName[col.Name.Name.L]; isPk {
+			target.pkColumns = append(target.pkColumns, col.Name.Name.String())
+		}
+	}
+	return target, "", nil
+}
+
+// findTableSource returns the table source referred by the name in DML, the name is the
+// alias of table source if the schema is empty.
+func (i *Inspect) findTableSource(sources []*ast.TableSource, schema, name string) *ast.TableSource {
+	for _, source := range sources {
+		table, ok := source.Source.(*ast.TableName)
+		if !ok {
+			continue
+		}
+		if source.AsName.L != "" {
+			if schema == "" && source.AsName.L == strings.ToLower(name) {
+				return source
+			}
+			continue
+		}
+		if table.Name.L != strings.ToLower(name) {
+			continue
+		}
+		if schema == "" || strings.EqualFold(i.getSchemaName(table), schema) {
+			return source
+		}
+	}
+	return nil
+}
+
+// generateDeleteRollbackSqlByPrimaryKeys generate insert SQL of each deleted table for
+// the multi-table or sub query delete.
+func (i *Inspect) generateDeleteRollbackSqlByPrimaryKeys(stmt *ast.DeleteStmt) (string, string, error) {
+	sources := getTableSources(stmt.TableRefs.TableRefs)
+	targetSources := []*ast.TableSource{}
+	if stmt.IsMultiTable {
+		for _, table := range stmt.Tables.Tables {
+			source := i.findTableSource(sources, table.Schema.String(), table.Name.String())
+			// the DML will fail if the deleted table is not in the table references.
+			if source == nil {
+				return "", "", nil
+			}
+			targetSources = append(targetSources, source)
+		}
+	} else {
+		targetSources = sources
+	}
+
+	targets := []*dmlTarget{}
+	for _, source := range targetSources {
+		target, reason, err := i.newDMLTarget(source)
+		if err != nil || target == nil {
+			return "", reason, err
+		}
+		targets = append(targets, target)
+	}
+	// the primary keys of all targets are resolved before any batch is reported, so
+	// nothing is reported if any target can't be rollbacked.
+	for _, target := range targets {
+		reason, err := i.resolvePrimaryKeys(target, stmt.IsMultiTable, stmt.TableRefs.TableRefs,
+			stmt.Where, stmt.Order, stmt.Limit)
+		if err != nil || reason != "" {
+			return "", reason, err
+		}
+	}
+	w := i.newRollbackWriter()
+	for _, target := range targets {
+		table := getTableNameWithQuote(target.table)
+		err := i.queryRecordsByPrimaryKeys(target, func(record map[string]sql.NullString) error {
+			w.writeInsert(table, target.columns, record)
+			return nil
+		})
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return w.String(), "", nil
+}
+
+// generateUpdateRollbackSqlByPrimaryKeys generate update SQL of each updated table for
+// the multi-table or sub query update.
+func (i *Inspect) generateUpdateRollbackSqlByPrimaryKeys(stmt *ast.UpdateStmt) (string, string, error) {
+	sources := getTableSources(stmt.TableRefs.TableRefs)
+	targets := []*dmlTarget{}
+	for _, l := range stmt.List {
+		target, reason, err := i.getUpdateTarget(sources, targets, l.Column)
+		if err != nil || target == nil {
+			return "", reason, err
+		}
+		if _, isPk := target.pkColumnsName[l.Column.Name.L]; isPk {
+			return "", NotSupportUpdatePrimaryKeyRollback, nil
+		}
+		if len(target.assignments) == 0 {
+			targets = append(targets, target)
+		}
+		target.assignments = append(target.assignments, l)
+	}
+
+	// the primary keys of all targets are resolved before any batch is reported, so
+	// nothing is reported if any target can't be rollbacked.
+	for _, target := range targets {
+		reason, err := i.resolvePrimaryKeys(target, len(sources) > 1, stmt.TableRefs.TableRefs,
+			stmt.Where, stmt.Order, stmt.Limit)
+		if err != nil || reason != "" {
+			return "", reason, err
+		}
+	}
+	w := i.newRollbackWriter()
+	for _, target := range targets {
+		table := getTableNameWithQuote(target.table)
+		err := i.queryRecordsByPrimaryKeys(target, func(record map[string]sql.NullString) error {
+			w.writeUpdate(table, target.columns, target.pkColumnsName, target.assignments, record)
+			return nil
+		})
+		if err != nil {
+			return "", "", err
+		}
+		// the update SQLs of different targets are not in one batch.
+		w.flush()
+	}
+	return w.String(), "", nil
+}
+
+// getUpdateTarget returns the target which the column of assignment belongs to, the
+// target is reused if it's resolved by the previous assignments.
+func (i *Inspect) getUpdateTarget(sources []*ast.TableSource, targets []*dmlTarget,
+	column *ast.ColumnName) (*dmlTarget, string, error) {
+	var source *ast.TableSource
+	if column.Table.String() != "" {
+		source = i.findTableSource(sources, column.Schema.String(), column.Table.String())
+	} else {
+		for _, s := range sources {
+			table, ok := s.Source.(*ast.TableName)
+			if !ok {
+				continue
+			}
+			createTableStmt, exist, err := i.getCreateTableStmt(table)
+			if err != nil {
+				return nil, "", err
+			}
+			if !exist || !tableExistColIgnoreCase(createTableStmt, column.Name.L) {
+				continue
+			}
+			// the column is ambiguous, the DML will fail.
+			if source != nil {
+				return nil, "", nil
+			}
+			source = s
+		}
+	}
+	if source == nil {
+		return nil, "", nil
+	}
+	for _, target := range targets {
+		if target.source == tableSourceQuotedName(source) {
+			return target, "", nil
+		}
+	}
+	return i.newDMLTarget(source)
+}
+
+func tableSourceQuotedName(source *ast.TableSource) string {
+	if source.AsName.String() != "" {
+		return fmt.Sprintf("`%s`", source.AsName)
+	}
+	return getTableNameWithQuote(source.Source.(*ast.TableName))
+}
+
+func tableExistColIgnoreCase(table *ast.CreateTableStmt, colName string) bool {
+	for _, col := range table.Cols {
+		if col.Name.Name.L == colName {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePrimaryKeys resolves the primary keys of the target changed by the DML to
+// target.pks. The reason is returned if the records are more than DMLRollbackMaxRows.
+func (i *Inspect) resolvePrimaryKeys(target *dmlTarget, isMultiTable bool, refs *ast.Join,
+	where ast.ExprNode, order *ast.OrderByClause, limit *ast.Limit) (string, error) {
+	var max = i.cnf.DMLRollbackMaxRows
+	limitCount, err := getLimitCount(limit, max+1)
+	if err != nil {
+		return "", err
+	}
+	if limitCount > max+1 {
+		limitCount = max + 1
+	}
+	pkSql, err := generateGetPrimaryKeysSql(target, isMultiTable, refs, where, order, limitCount)
+	if err != nil {
+		return "", err
+	}
+	conn, err := i.getDbConn()
+	if err != nil {
+		return "", err
+	}
+	pks, err := conn.Db.Query(pkSql)
+	if err != nil {
+		return "", err
+	}
+	if int64(len(pks)) > max {
+		return NotSupportExceedMaxRowsRollback, nil
+	}
+	target.pks = pks
+	return "", nil
+}
+
+// queryRecordsByPrimaryKeys selects the records by the primary keys resolved by
+// resolvePrimaryKeys, fn is called with each record fetched from cursor.
+func (i *Inspect) queryRecordsByPrimaryKeys(target *dmlTarget, fn func(record map[string]sql.NullString) error) error {
+	pks := target.pks
+	if len(pks) == 0 {
+		return nil
+	}
+	conn, err := i.getDbConn()
+	if err != nil {
+		return err
+	}
+
+	// the records are selected in chunks, the placeholders of a prepared statement are
+	// limited to 65535.
+	chunkSize := pkLookupMaxPlaceholders / len(target.pkColumns)
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	for start := 0; start < len(pks); start += chunkSize {
+		end := start + chunkSize
+		if end > len(pks) {
+			end = len(pks)
+		}
+		values := []string{}
+		args := []interface{}{}
+		for _, pk := range pks[start:end] {
+			placeholders := []string{}
+			for _, col := range target.pkColumns {
+				placeholders = append(placeholders, "?")
+				args = append(args, pk[col].String)
+			}
+			values = append(values, fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")))
+		}
+		err := conn.Db.QueryRows(fn, fmt.Sprintf("SELECT %s FROM %s WHERE (`%s`) IN (%s);",
+			rollbackSelectExpr(target.columns), getTableNameWithQuote(target.table), strings.Join(target.pkColumns, "`, `"),
+			strings.Join(values, ", ")), args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateGetPrimaryKeysSql generate select SQL which selects the primary keys of the
+// target by the table references and where condition of the DML. The multi-table DML
+// has no order and limit, but the target rows may be joined more than once.
+func generateGetPrimaryKeysSql(target *dmlTarget, isMultiTable bool, refs *ast.Join,
+	where ast.ExprNode, order *ast.OrderByClause, limit int64) (string, error) {
+	columns := []string{}
+	for _, col := range target.pkColumns {
+		columns = append(columns, fmt.Sprintf("%s.`%s`", target.source, col))
+	}
+	expr := strings.Join(columns, ", ")
+	if isMultiTable {
+		expr = "DISTINCT " + expr
+	}
+	// the sub query is lost by exprFormat, so the nodes are restored.
+	from, err := restoreToSqlWithFlag(format.DefaultRestoreFlags, refs)
+	if err != nil {
+		return "", err
+	}
+	pkSql := fmt.Sprintf("SELECT %s FROM %s", expr, from)
+	if where != nil {
+		cond, err := restoreToSqlWithFlag(format.DefaultRestoreFlags, where)
+		if err != nil {
+			return "", err
+		}
+		pkSql = fmt.Sprintf("%s WHERE %s", pkSql, cond)
+	}
+	if order != nil {
+		orderBy, err := restoreToSqlWithFlag(format.DefaultRestoreFlags, order)
+		if err != nil {
+			return "", err
+		}
+		pkSql = fmt.Sprintf("%s %s", pkSql, orderBy)
+	}
+	return fmt.Sprintf("%s LIMIT %d;", pkSql, limit), nil
+}
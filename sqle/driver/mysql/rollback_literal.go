@@ -0,0 +1,186 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+)
+
+// rollbackColumn is a column of the records which are selected to generate rollback SQL,
+// the values are rendered to literals by the type and charset of the column.
+type rollbackColumn struct {
+	name    string
+	tp      byte
+	charset string
+}
+
+// getRollbackColumns returns the columns of table, the charset of string column is the
+// default charset of table or schema if it's not specified.
+func (i *Inspect) getRollbackColumns(table *ast.TableName, createTableStmt *ast.CreateTableStmt) ([]*rollbackColumn, error) {
+	tableCharset := ""
+	for _, op := range createTableStmt.Options {
+		if op.Tp == ast.TableOptionCharset {
+			tableCharset = op.StrValue
+		}
+	}
+	columns := make([]*rollbackColumn, 0, len(createTableStmt.Cols))
+	for _, col := range createTableStmt.Cols {
+		c := &rollbackColumn{
+			name:    col.Name.Name.String(),
+			tp:      col.Tp.Tp,
+			charset: strings.ToLower(col.Tp.Charset),
+		}
+		if c.isString() && c.charset == "" {
+			if tableCharset == "" {
+				charset, err := i.getSchemaCharacter(table, "")
+				if err != nil {
+					return nil, err
+				}
+				tableCharset = charset
+			}
+			c.charset = strings.ToLower(tableCharset)
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+func (c *rollbackColumn) isString() bool {
+	switch c.tp {
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString, mysql.TypeTinyBlob, mysql.TypeMediumBlob,
+		mysql.TypeLongBlob, mysql.TypeBlob, mysql.TypeEnum, mysql.TypeSet:
+		return true
+	}
+	return false
+}
+
+func (c *rollbackColumn) isNumeric() bool {
+	switch c.tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong, mysql.TypeYear,
+		mysql.TypeDecimal, mysql.TypeNewDecimal, mysql.TypeFloat, mysql.TypeDouble, mysql.TypeBit:
+		return true
+	}
+	return false
+}
+
+// isTemporal returns true if the column is parsed to time.Time by the connection with
+// parseTime, which is scanned to string in RFC3339 format.
+func (c *rollbackColumn) isTemporal() bool {
+	switch c.tp {
+	case mysql.TypeDate, mysql.TypeNewDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		return true
+	}
+	return false
+}
+
+// selectExpr returns the expression which selects the column. The string is selected as
+// hex to keep the bytes which may be changed by the charset of connection, BIT is
+// selected as number, and DATE/DATETIME/TIMESTAMP are selected as string in the format
+// of MySQL.
+func (c *rollbackColumn) selectExpr() string {
+	switch {
+	case c.isString():
+		return fmt.Sprintf("HEX(`%s`) AS `%s`", c.name, c.name)
+	case c.tp == mysql.TypeBit:
+		return fmt.Sprintf("`%s` + 0 AS `%s`", c.name, c.name)
+	case c.isTemporal():
+		return fmt.Sprintf("CAST(`%s` AS CHAR) AS `%s`", c.name, c.name)
+	}
+	return fmt.Sprintf("`%s`", c.name)
+}
+
+// rollbackSelectExpr returns the select expression of all columns.
+func rollbackSelectExpr(columns []*rollbackColumn) string {
+	exprs := make([]string, 0, len(columns))
+	for _, c := range columns {
+		exprs = append(exprs, c.selectExpr())
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// literal renders the value selected by selectExpr to SQL literal.
+func (c *rollbackColumn) literal(v sql.NullString) string {
+	switch {
+	case !v.Valid:
+		return "NULL"
+	case c.isNumeric():
+		return v.String
+	case c.isString():
+		b, err := hex.DecodeString(v.String)
+		if err != nil {
+			return quoteLiteral(v.String, false)
+		}
+		if c.charset == "binary" {
+			return fmt.Sprintf("X'%s'", hex.EncodeToString(b))
+		}
+		return textLiteral(c.charset, string(b))
+	case c.tp == mysql.TypeJSON:
+		return textLiteral("utf8mb4", v.String)
+	}
+	return quoteLiteral(v.String, false)
+}
+
+// canMatch returns false if the column can't be matched by the value exactly, e.g. the
+// approximate number and JSON.
+func (c *rollbackColumn) canMatch() bool {
+	switch c.tp {
+	case mysql.TypeFloat, mysql.TypeDouble, mysql.TypeJSON:
+		return false
+	}
+	return true
+}
+
+// matchCondition returns the condition which matches the column by the literal.
+func (c *rollbackColumn) matchCondition(literal string) string {
+	if literal == "NULL" {
+		return fmt.Sprintf("`%s` IS NULL", c.name)
+	}
+	return fmt.Sprintf("`%s` = %s", c.name, literal)
+}
+
+// matchValueCondition returns the condition which matches the column by the value in
+// DML, it returns false if the value isn't constant.
+func (c *rollbackColumn) matchValueCondition(expr ast.ExprNode) (string, bool) {
+	if _, ok := expr.(ast.ValueExpr); !ok || !c.canMatch() {
+		return "", false
+	}
+	return c.matchCondition(assignmentValueFormat(expr)), true
+}
+
+// textLiteral renders the text in charset to quoted string if the text is readable,
+// otherwise to hex literal, which is stored as it is in column of any charset. The
+// text in utf8mb4 is quoted with introducer, because the charset of connection is utf8.
+func textLiteral(charset, s string) string {
+	switch charset {
+	case "utf8", "utf8mb3", "utf8mb4":
+		if !utf8.ValidString(s) {
+			break
+		}
+		for _, r := range s {
+			if r > 0xFFFF {
+				return "_utf8mb4" + quoteLiteral(s, true)
+			}
+		}
+		return quoteLiteral(s, true)
+	case "ucs2", "utf16", "utf16le", "utf32":
+	default:
+		if isASCII(s) {
+			return quoteLiteral(s, true)
+		}
+	}
+	return fmt.Sprintf("X'%s'", hex.EncodeToString([]byte(s)))
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/parser/opcode"
+	"github.com/pingcap/tidb/types"
+	parserdriver "github.com/pingcap/tidb/types/parser_driver"
+	"github.com/stretchr/testify/assert"
+)
+
+// parseLiteral parses the literal rendered for rollback SQL back to the value.
+func parseLiteral(t *testing.T, literal string) (string, bool) {
+	node, err := parseOneSql("SELECT " + literal)
+	if !assert.NoError(t, err, literal) {
+		return "", false
+	}
+	expr := node.(*ast.SelectStmt).Fields.Fields[0].Expr
+	sign := ""
+	if unary, ok := expr.(*ast.UnaryOperationExpr); ok && unary.Op == opcode.Minus {
+		sign, expr = "-", unary.V
+	}
+	v, ok := expr.(*parserdriver.ValueExpr)
+	if !assert.True(t, ok, literal) {
+		return "", false
+	}
+	switch v.Kind() {
+	case types.KindNull:
+		return "", true
+	case types.KindString, types.KindBytes, types.KindBinaryLiteral:
+		return string(v.GetBytes()), false
+	case types.KindFloat64:
+		return sign + strconv.FormatFloat(v.GetFloat64(), 'g', -1, 64), false
+	}
+	s, err := v.ToString()
+	assert.NoError(t, err, literal)
+	return sign + s, false
+}
+
+func TestRollbackColumn_literal(t *testing.T) {
+	tests := []struct {
+		column string
+		// value is the value of column stored in MySQL.
+		value   string
+		null    bool
+		literal string
+	}{
+		{column: "tinyint", value: "-128", literal: "-128"},
+		{column: "smallint unsigned", value: "65535", literal: "65535"},
+		{column: "mediumint", value: "-8388608", literal: "-8388608"},
+		{column: "int", value: "0", literal: "0"},
+		{column: "bigint unsigned", value: "18446744073709551615", literal: "18446744073709551615"},
+		{column: "year", value: "2021", literal: "2021"},
+		{column: "decimal(10,4)", value: "-12.3400", literal: "-12.3400"},
+		{column: "float", value: "1.5e-07", literal: "1.5e-07"},
+		{column: "double", value: "3.141592653589793", literal: "3.141592653589793"},
+		{column: "bit(8)", value: "255", literal: "255"},
+		{column: "int", null: true, literal: "NULL"},
+		{column: "char(10)", value: "", literal: "''"},
+		{column: "varchar(255)", value: "it's a \"test\"", literal: `'it\'s a "test"'`},
+		{column: "varchar(255)", value: "C:\\dir\\\n\r\x00\x1a", literal: `'C:\\dir\\\n\r\0\Z'`},
+		{column: "text", value: "中文😀", literal: "_utf8mb4'中文😀'"},
+		{column: "varchar(255) character set utf8", value: "中文", literal: "'中文'"},
+		{column: "varchar(255) character set utf8", value: "\xff\xfe", literal: "X'fffe'"},
+		{column: "varchar(255) character set latin1", value: "abc", literal: "'abc'"},
+		{column: "varchar(255) character set latin1", value: "caf\xe9", literal: "X'636166e9'"},
+		{column: "varchar(255) binary", value: "abc", literal: "'abc'"},
+		{column: "binary(4)", value: "\x00\xff'\\", literal: "X'00ff275c'"},
+		{column: "varbinary(255)", value: "", literal: "X''"},
+		{column: "blob", value: "\x89PNG\r\n", literal: "X'89504e470d0a'"},
+		{column: "longblob", null: true, literal: "NULL"},
+		{column: "mediumtext", value: "a\tb", literal: "'a\tb'"},
+		{column: "enum('a','b''c')", value: "b'c", literal: `'b\'c'`},
+		{column: "set('a','b')", value: "a,b", literal: "'a,b'"},
+		{column: "json", value: `{"k": "it's \"😀\""}`, literal: `_utf8mb4'{"k": "it\'s \\"😀\\""}'`},
+		{column: "date", value: "2021-01-02", literal: "'2021-01-02'"},
+		{column: "datetime(6)", value: "2021-01-02 03:04:05.000006", literal: "'2021-01-02 03:04:05.000006'"},
+		{column: "timestamp", value: "2021-01-02 03:04:05", literal: "'2021-01-02 03:04:05'"},
+		{column: "time", value: "-838:59:59", literal: "'-838:59:59'"},
+	}
+	for _, tt := range tests {
+		node, err := parseOneSql("CREATE TABLE exist_db.t1 (c " + tt.column + ") DEFAULT CHARSET=utf8mb4")
+		if !assert.NoError(t, err, tt.column) {
+			continue
+		}
+		i := DefaultMysqlInspect()
+		columns, err := i.getRollbackColumns(newTableName("exist_db", "t1"), node.(*ast.CreateTableStmt))
+		assert.NoError(t, err)
+		c := columns[0]
+
+		// the value is selected by selectExpr.
+		selected := sql.NullString{String: tt.value, Valid: !tt.null}
+		if c.isString() && !tt.null {
+			selected.String = hex.EncodeToString([]byte(tt.value))
+		}
+		literal := c.literal(selected)
+		assert.Equal(t, tt.literal, literal, tt.column)
+
+		value, null := parseLiteral(t, literal)
+		assert.Equal(t, tt.null, null, tt.column)
+		assert.Equal(t, tt.value, value, tt.column)
+	}
+}
+
+func TestTextLiteral(t *testing.T) {
+	// the parser doesn't support the charset which is not compatible with ASCII.
+	assert.Equal(t, "X'0061'", textLiteral("ucs2", "\x00a"))
+	assert.Equal(t, "X'0061'", textLiteral("utf16", "\x00a"))
+	assert.Equal(t, "'abc'", textLiteral("gbk", "abc"))
+	assert.Equal(t, "X'd6d0'", textLiteral("gbk", "\xd6\xd0"))
+}
+
+func TestRollbackColumn_selectExpr(t *testing.T) {
+	assert.Equal(t, "HEX(`c`) AS `c`", (&rollbackColumn{name: "c", tp: mysql.TypeVarchar}).selectExpr())
+	assert.Equal(t, "`c` + 0 AS `c`", (&rollbackColumn{name: "c", tp: mysql.TypeBit}).selectExpr())
+	assert.Equal(t, "`c`", (&rollbackColumn{name: "c", tp: mysql.TypeLonglong}).selectExpr())
+	assert.Equal(t, "CAST(`c` AS CHAR) AS `c`", (&rollbackColumn{name: "c", tp: mysql.TypeDatetime}).selectExpr())
+}
+
+func TestDeleteRollbackSql_temporal(t *testing.T) {
+	i := DefaultMysqlInspect()
+	node, err := parseOneSql("CREATE TABLE exist_db.t1 (id int PRIMARY KEY, d date, dt datetime(6), ts timestamp, tm time)")
+	assert.NoError(t, err)
+	i.Ctx.AddTable("exist_db", "t1", &TableInfo{
+		sizeLoad:      true,
+		isLoad:        true,
+		OriginalTable: node.(*ast.CreateTableStmt),
+	})
+	mock := mockInspectDbConn(t, i)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) as count FROM `exist_db`.`t1`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("1"))
+	// the temporal values are scanned as they are formatted by MySQL, rather than
+	// time.Time parsed by the connection.
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, CAST(`d` AS CHAR) AS `d`, CAST(`dt` AS CHAR) AS `dt`, " +
+		"CAST(`ts` AS CHAR) AS `ts`, `tm` FROM `exist_db`.`t1` WHERE `id` = 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "d", "dt", "ts", "tm"}).
+			AddRow(int64(1), []byte("2021-01-02"), []byte("2021-01-02 03:04:05.123456"),
+				[]byte("2021-01-02 03:04:05"), []byte("-12:30:00")))
+
+	node, err = parseOneSql("delete from exist_db.t1 where id = 1")
+	assert.NoError(t, err)
+	rollbackSql, reason, err := i.GenerateDMLStmtRollbackSql(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "", reason)
+	assert.Equal(t, "INSERT INTO `exist_db`.`t1` (`id`, `d`, `dt`, `ts`, `tm`) VALUES "+
+		"(1, '2021-01-02', '2021-01-02 03:04:05.123456', '2021-01-02 03:04:05', '-12:30:00');", rollbackSql)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateRollbackSqlFromRecords(t *testing.T) {
+	i := DefaultMysqlInspect()
+	node, err := parseOneSql("CREATE TABLE exist_db.t1 (id varchar(10) PRIMARY KEY, b blob, v json)")
+	assert.NoError(t, err)
+	columns, err := i.getRollbackColumns(newTableName("exist_db", "t1"), node.(*ast.CreateTableStmt))
+	assert.NoError(t, err)
+	records := []map[string]sql.NullString{{
+		"id": {String: hex.EncodeToString([]byte("a'1")), Valid: true},
+		"b":  {String: "00ff", Valid: true},
+		"v":  {String: `["x"]`, Valid: true},
+	}}
+
+	w := &rollbackWriter{}
+	w.writeInsert("`exist_db`.`t1`", columns, records[0])
+	sql := w.String()
+	assert.Equal(t, "INSERT INTO `exist_db`.`t1` (`id`, `b`, `v`) VALUES ('a\\'1', X'00ff', '[\"x\"]');", sql)
+	_, err = parseOneSql(sql)
+	assert.NoError(t, err)
+
+	update, err := parseOneSql("UPDATE exist_db.t1 SET b = NULL, id = 'b''2'")
+	assert.NoError(t, err)
+	sql = generateUpdateSqlFromRecord("`exist_db`.`t1`", columns, map[string]struct{}{"id": {}},
+		update.(*ast.UpdateStmt).List, records[0])
+	assert.Equal(t, "UPDATE `exist_db`.`t1` SET `id` = 'a\\'1', `b` = X'00ff' WHERE `id` = 'b''2';", sql)
+	_, err = parseOneSql(sql)
+	assert.NoError(t, err)
+}
@@ -2,8 +2,12 @@ package mysql
 
 import (
 	"context"
+	"regexp"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/pingcap/parser/ast"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -176,3 +180,283 @@ func TestInsertRollbackSql(t *testing.T) {
 		"DELETE FROM `exist_db`.`exist_tb_1` WHERE id = '10';\n",
 	)
 }
+
+func newRollbackByPrimaryKeysTestInspect(t *testing.T) (*Inspect, sqlmock.Sqlmock) {
+	i := DefaultMysqlInspect()
+	node, err := parseOneSql(`CREATE TABLE exist_db.exist_tb_4 (
+id bigint unsigned NOT NULL,
+tb1_id bigint unsigned NOT NULL,
+v1 varchar(255),
+v3 varchar(255),
+PRIMARY KEY (id, tb1_id)
+);`)
+	assert.NoError(t, err)
+	i.Ctx.AddTable("exist_db", "exist_tb_4", &TableInfo{
+		sizeLoad:      true,
+		isLoad:        true,
+		OriginalTable: node.(*ast.CreateTableStmt),
+	})
+	return i, mockInspectDbConn(t, i)
+}
+
+func runRollbackByPrimaryKeysCase(t *testing.T, i *Inspect, mock sqlmock.Sqlmock, query, rollbackSql, reason string) {
+	node, err := parseOneSql(query)
+	assert.NoError(t, err)
+	sql, unableReason, err := i.GenerateDMLStmtRollbackSql(node)
+	assert.NoError(t, err, query)
+	assert.Equal(t, reason, unableReason, query)
+	assert.Equal(t, rollbackSql, sql, query)
+	assert.NoError(t, mock.ExpectationsWereMet(), query)
+}
+
+func TestDeleteRollbackSqlByPrimaryKeys(t *testing.T) {
+	i, mock := newRollbackByPrimaryKeysTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `a`.`id` FROM `exist_db`.`exist_tb_1` AS `a` " +
+		"JOIN `exist_db`.`exist_tb_4` AS `b` ON `a`.`id`=`b`.`tb1_id` WHERE `b`.`v1`='x' LIMIT 1001;")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `b`.`id`, `b`.`tb1_id` FROM")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tb1_id"}).AddRow("10", "1"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, HEX(`v1`) AS `v1`, HEX(`v2`) AS `v2` FROM `exist_db`.`exist_tb_1` WHERE (`id`) IN ((?), (?));")).
+		WithArgs("1", "2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "v1", "v2"}).AddRow("1", "61", "62").AddRow("2", "63", nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, `tb1_id`, HEX(`v1`) AS `v1`, HEX(`v3`) AS `v3` FROM `exist_db`.`exist_tb_4` WHERE (`id`, `tb1_id`) IN ((?, ?));")).
+		WithArgs("10", "1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tb1_id", "v1", "v3"}).AddRow("10", "1", "78", "79"))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"delete a, b from exist_db.exist_tb_1 a join exist_db.exist_tb_4 b on a.id = b.tb1_id where b.v1 = 'x'",
+		"INSERT INTO `exist_db`.`exist_tb_1` (`id`, `v1`, `v2`) VALUES (1, 'a', 'b'), (2, 'c', NULL);\n"+
+			"INSERT INTO `exist_db`.`exist_tb_4` (`id`, `tb1_id`, `v1`, `v3`) VALUES (10, 1, 'x', 'y');", "")
+
+	// sub query
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `exist_db`.`exist_tb_1`.`id` FROM `exist_db`.`exist_tb_1` " +
+		"WHERE `id` IN (SELECT `tb1_id` FROM `exist_db`.`exist_tb_4`) ORDER BY `id` LIMIT 10;")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, HEX(`v1`) AS `v1`, HEX(`v2`) AS `v2` FROM `exist_db`.`exist_tb_1` WHERE (`id`) IN ((?));")).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "v1", "v2"}).AddRow("1", "61", "62"))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"delete from exist_db.exist_tb_1 where id in (select tb1_id from exist_db.exist_tb_4) order by id limit 10",
+		"INSERT INTO `exist_db`.`exist_tb_1` (`id`, `v1`, `v2`) VALUES (1, 'a', 'b');", "")
+
+	// the records are selected in chunks.
+	origin := pkLookupMaxPlaceholders
+	pkLookupMaxPlaceholders = 2
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `exist_db`.`exist_tb_1`.`id` FROM")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2").AddRow("3"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, HEX(`v1`) AS `v1`, HEX(`v2`) AS `v2` FROM `exist_db`.`exist_tb_1` WHERE (`id`) IN ((?), (?));")).
+		WithArgs("1", "2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "v1", "v2"}).AddRow("1", "61", "62").AddRow("2", "63", nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, HEX(`v1`) AS `v1`, HEX(`v2`) AS `v2` FROM `exist_db`.`exist_tb_1` WHERE (`id`) IN ((?));")).
+		WithArgs("3").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "v1", "v2"}).AddRow("3", "64", "65"))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"delete from exist_db.exist_tb_1 where id in (select tb1_id from exist_db.exist_tb_4)",
+		"INSERT INTO `exist_db`.`exist_tb_1` (`id`, `v1`, `v2`) VALUES (1, 'a', 'b'), (2, 'c', NULL), (3, 'd', 'e');", "")
+	pkLookupMaxPlaceholders = origin
+
+	// no record is deleted.
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `exist_db`.`exist_tb_1`.`id` FROM")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"delete from exist_db.exist_tb_1 where id in (select tb1_id from exist_db.exist_tb_4)", "", "")
+
+	// exceed max rows
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	i.cnf.DMLRollbackMaxRows = 1
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `exist_db`.`exist_tb_1`.`id` FROM")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"delete from exist_db.exist_tb_1 where id in (select tb1_id from exist_db.exist_tb_4)",
+		"", NotSupportExceedMaxRowsRollback)
+
+	// nothing is reported if the later target exceeds max rows.
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	i.cnf.DMLRollbackMaxRows = 1
+	i.cnf.DMLRollbackBatchRows = 1
+	batches := []string{}
+	i.rollbackBatch = func(rollbackSQL string) { batches = append(batches, rollbackSQL) }
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `a`.`id` FROM")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `b`.`id`, `b`.`tb1_id` FROM")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tb1_id"}).AddRow("10", "1").AddRow("11", "1"))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"delete a, b from exist_db.exist_tb_1 a join exist_db.exist_tb_4 b on a.id = b.tb1_id",
+		"", NotSupportExceedMaxRowsRollback)
+	assert.Empty(t, batches)
+
+	// not null unique key
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `exist_db`.`exist_tb_2`.`id` FROM")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, HEX(`v1`) AS `v1`, HEX(`v2`) AS `v2`, `user_id` FROM `exist_db`.`exist_tb_2` WHERE (`id`) IN ((?));")).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "v1", "v2", "user_id"}).AddRow("1", "61", nil, "2"))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"delete exist_db.exist_tb_2 from exist_db.exist_tb_1 join exist_db.exist_tb_2 on exist_tb_1.id = exist_tb_2.user_id",
+		"INSERT INTO `exist_db`.`exist_tb_2` (`id`, `v1`, `v2`, `user_id`) VALUES (1, 'a', NULL, 2);", "")
+
+	// no primary key
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"delete exist_db.exist_tb_3 from exist_db.exist_tb_1 join exist_db.exist_tb_3 on exist_tb_1.id = exist_tb_3.v3",
+		"", NotSupportNoPrimaryKeyTableRollback)
+}
+
+func TestUpdateRollbackSqlByPrimaryKeys(t *testing.T) {
+	i, mock := newRollbackByPrimaryKeysTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `a`.`id` FROM `exist_db`.`exist_tb_1` AS `a` " +
+		"JOIN `exist_db`.`exist_tb_4` AS `b` ON `a`.`id`=`b`.`tb1_id` LIMIT 1001;")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `b`.`id`, `b`.`tb1_id` FROM")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tb1_id"}).AddRow("10", "1"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, HEX(`v1`) AS `v1`, HEX(`v2`) AS `v2` FROM `exist_db`.`exist_tb_1` WHERE (`id`) IN ((?));")).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "v1", "v2"}).AddRow("1", "61", "62"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, `tb1_id`, HEX(`v1`) AS `v1`, HEX(`v3`) AS `v3` FROM `exist_db`.`exist_tb_4` WHERE (`id`, `tb1_id`) IN ((?, ?));")).
+		WithArgs("10", "1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tb1_id", "v1", "v3"}).AddRow("10", "1", "78", nil))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"update exist_db.exist_tb_1 a join exist_db.exist_tb_4 b on a.id = b.tb1_id set a.v1 = b.v1, v3 = 'z', a.v2 = 'z'",
+		"UPDATE `exist_db`.`exist_tb_1` SET `v1` = 'a', `v2` = 'b' WHERE `id` = 1;\n"+
+			"UPDATE `exist_db`.`exist_tb_4` SET `v3` = NULL WHERE `id` = 10 AND `tb1_id` = 1;", "")
+
+	// sub query
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `t`.`id` FROM `exist_db`.`exist_tb_1` AS `t` " +
+		"WHERE `t`.`id` IN (SELECT `tb1_id` FROM `exist_db`.`exist_tb_4` WHERE `v3`='y') LIMIT 1001;")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, HEX(`v1`) AS `v1`, HEX(`v2`) AS `v2` FROM `exist_db`.`exist_tb_1` WHERE (`id`) IN ((?));")).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "v1", "v2"}).AddRow("1", "61", "62"))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"update exist_db.exist_tb_1 as t set v2 = 'z' where t.id in (select tb1_id from exist_db.exist_tb_4 where v3 = 'y')",
+		"UPDATE `exist_db`.`exist_tb_1` SET `v2` = 'b' WHERE `id` = 1;", "")
+
+	// update primary key
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"update exist_db.exist_tb_1 a join exist_db.exist_tb_4 b on a.id = b.tb1_id set b.tb1_id = 0",
+		"", NotSupportUpdatePrimaryKeyRollback)
+
+	// the column is ambiguous
+	i, mock = newRollbackByPrimaryKeysTestInspect(t)
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"update exist_db.exist_tb_1 a join exist_db.exist_tb_4 b on a.id = b.tb1_id set v1 = 'z'", "", "")
+}
+
+func newRollbackKeylessTestInspect(t *testing.T) (*Inspect, sqlmock.Sqlmock) {
+	i := DefaultMysqlInspect()
+	for name, query := range map[string]string{
+		"exist_tb_5": `CREATE TABLE exist_db.exist_tb_5 (
+id bigint unsigned NOT NULL,
+code varchar(32) NOT NULL,
+name varchar(32),
+UNIQUE KEY uniq_name(name),
+UNIQUE KEY uniq_code(code)
+);`,
+		"exist_tb_6": `CREATE TABLE exist_db.exist_tb_6 (
+v1 varchar(255),
+v2 int,
+f float
+);`,
+	} {
+		node, err := parseOneSql(query)
+		assert.NoError(t, err)
+		i.Ctx.AddTable("exist_db", name, &TableInfo{
+			sizeLoad:      true,
+			isLoad:        true,
+			OriginalTable: node.(*ast.CreateTableStmt),
+		})
+	}
+	return i, mockInspectDbConn(t, i)
+}
+
+func TestRollbackSqlOfKeylessTable(t *testing.T) {
+	// not null unique key
+	i, mock := newRollbackKeylessTestInspect(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, HEX(`code`) AS `code`, HEX(`name`) AS `name` FROM `exist_db`.`exist_tb_5`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "name"}).AddRow("1", "6131", nil))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"update exist_db.exist_tb_5 set name = 'z' where id = 1 limit 10",
+		"UPDATE `exist_db`.`exist_tb_5` SET `name` = NULL WHERE `code` = 'a1';", "")
+
+	i, mock = newRollbackKeylessTestInspect(t)
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"insert into exist_db.exist_tb_5 (id, code) values (1, 'a1')",
+		"DELETE FROM `exist_db`.`exist_tb_5` WHERE code = 'a1';\n", "")
+
+	// the keyless table is not rolled back by default.
+	i, mock = newRollbackKeylessTestInspect(t)
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"update exist_db.exist_tb_6 set v2 = 2 limit 10", "", NotSupportNoPrimaryKeyTableRollback)
+
+	i, mock = newRollbackKeylessTestInspect(t)
+	i.cnf.DMLRollbackKeylessTable = true
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT HEX(`v1`) AS `v1`, `v2`, `f` FROM `exist_db`.`exist_tb_6`")).
+		WillReturnRows(sqlmock.NewRows([]string{"v1", "v2", "f"}).AddRow("61", "1", "1.5").AddRow(nil, nil, nil))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"update exist_db.exist_tb_6 set v2 = 2, f = 0 limit 10",
+		"UPDATE `exist_db`.`exist_tb_6` SET `v2` = 1, `f` = 1.5 WHERE `v1` = 'a' AND `v2` = 2 LIMIT 1;"+
+			"UPDATE `exist_db`.`exist_tb_6` SET `v2` = NULL, `f` = NULL WHERE `v1` IS NULL AND `v2` = 2 LIMIT 1;",
+		KeylessTableRollbackRisk)
+
+	// the changed column is not matched if it's not changed to constant.
+	i, mock = newRollbackKeylessTestInspect(t)
+	i.cnf.DMLRollbackKeylessTable = true
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT HEX(`v1`) AS `v1`, `v2`, `f` FROM `exist_db`.`exist_tb_6`")).
+		WillReturnRows(sqlmock.NewRows([]string{"v1", "v2", "f"}).AddRow("61", "1", "1.5"))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"update exist_db.exist_tb_6 set v2 = v2 + 1 limit 10",
+		"UPDATE `exist_db`.`exist_tb_6` SET `v2` = 1 WHERE `v1` = 'a' LIMIT 1;", KeylessTableRollbackRisk)
+
+	i, mock = newRollbackKeylessTestInspect(t)
+	i.cnf.DMLRollbackKeylessTable = true
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"insert into exist_db.exist_tb_6 (v1, v2, f) values ('a', NULL, 1.5)",
+		"DELETE FROM `exist_db`.`exist_tb_6` WHERE `v1` = 'a' AND `v2` IS NULL LIMIT 1;\n", KeylessTableRollbackRisk)
+
+	// the deleted rows are inserted back as they are.
+	i, mock = newRollbackKeylessTestInspect(t)
+	i.cnf.DMLRollbackKeylessTable = true
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT HEX(`v1`) AS `v1`, `v2`, `f` FROM `exist_db`.`exist_tb_6`")).
+		WillReturnRows(sqlmock.NewRows([]string{"v1", "v2", "f"}).AddRow("61", "1", "1.5"))
+	runRollbackByPrimaryKeysCase(t, i, mock,
+		"delete from exist_db.exist_tb_6 where v1 = 'a' limit 10",
+		"INSERT INTO `exist_db`.`exist_tb_6` (`v1`, `v2`, `f`) VALUES ('a', 1, 1.5);", "")
+}
+
+func TestRollbackSqlInBatches(t *testing.T) {
+	newInspect := func() (*Inspect, sqlmock.Sqlmock) {
+		i := DefaultMysqlInspect()
+		i.cnf.DMLRollbackBatchRows = 2
+		mock := mockInspectDbConn(t, i)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`, HEX(`v1`) AS `v1`, HEX(`v2`) AS `v2` FROM `exist_db`.`exist_tb_1`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "v1", "v2"}).
+				AddRow("1", "61", "62").AddRow("2", "63", "64").AddRow("3", "65", "66"))
+		return i, mock
+	}
+
+	// the batches are returned at once if there is no RollbackBatchFunc.
+	i, mock := newInspect()
+	runRollbackByPrimaryKeysCase(t, i, mock, "delete from exist_db.exist_tb_1 where v1 > 'a' limit 10",
+		"INSERT INTO `exist_db`.`exist_tb_1` (`id`, `v1`, `v2`) VALUES (1, 'a', 'b'), (2, 'c', 'd');\n"+
+			"INSERT INTO `exist_db`.`exist_tb_1` (`id`, `v1`, `v2`) VALUES (3, 'e', 'f');", "")
+
+	i, mock = newInspect()
+	batches := []string{}
+	ctx := driver.WithRollbackBatch(context.TODO(), func(rollbackSQL string) {
+		batches = append(batches, rollbackSQL)
+	})
+	rollbackSql, reason, err := i.GenRollbackSQL(ctx, "update exist_db.exist_tb_1 set v2 = 'z' where v1 > 'a' limit 10")
+	assert.NoError(t, err)
+	assert.Equal(t, "", reason)
+	assert.Equal(t, "", rollbackSql)
+	assert.Equal(t, []string{
+		"UPDATE `exist_db`.`exist_tb_1` SET `v2` = 'b' WHERE `id` = 1;UPDATE `exist_db`.`exist_tb_1` SET `v2` = 'd' WHERE `id` = 2;",
+		"UPDATE `exist_db`.`exist_tb_1` SET `v2` = 'f' WHERE `id` = 3;",
+	}, batches)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/pingcap/parser/ast"
+)
+
+// rollbackWriter builds the rollback SQL of the records which are fetched from cursor one
+// by one. The rollback SQL is split into batches of DMLRollbackBatchRows rows, the batch
+// is reported by driver.RollbackBatchFunc once it's full if it's set, so the rollback
+// SQL is not kept in memory. Otherwise the batches are joined and returned by String.
+type rollbackWriter struct {
+	batchRows int64
+	report    driver.RollbackBatchFunc
+	batches   []string
+
+	// the current batch is prefix + rows joined by sep + suffix.
+	prefix, sep, suffix string
+	rows                []string
+
+	// count is the count of all rows written.
+	count int64
+}
+
+func (i *Inspect) newRollbackWriter() *rollbackWriter {
+	return &rollbackWriter{
+		batchRows: i.cnf.DMLRollbackBatchRows,
+		report:    i.rollbackBatch,
+	}
+}
+
+// writeInsert writes the values of the deleted record to insert SQL, the records of the
+// same table in one batch are inserted by one insert SQL.
+func (w *rollbackWriter) writeInsert(table string, columns []*rollbackColumn, record map[string]sql.NullString) {
+	w.write(insertSqlPrefix(table, columns), ", ", ";", insertSqlValues(columns, record))
+}
+
+// writeUpdate writes the update SQL which restores the updated record, the record is
+// skipped if it can't be matched.
+func (w *rollbackWriter) writeUpdate(table string, columns []*rollbackColumn, keyColumnsName map[string]struct{},
+	list []*ast.Assignment, record map[string]sql.NullString) {
+	if rollbackSql := generateUpdateSqlFromRecord(table, columns, keyColumnsName, list, record); rollbackSql != "" {
+		w.write("", "", "", rollbackSql)
+	}
+}
+
+func (w *rollbackWriter) write(prefix, sep, suffix, row string) {
+	if prefix != w.prefix || sep != w.sep || suffix != w.suffix {
+		w.flush()
+		w.prefix, w.sep, w.suffix = prefix, sep, suffix
+	}
+	w.rows = append(w.rows, row)
+	w.count++
+	if w.batchRows > 0 && int64(len(w.rows)) >= w.batchRows {
+		w.flush()
+	}
+}
+
+// flush ends the current batch, it's called between the tables so the records of
+// different tables are never in one batch.
+func (w *rollbackWriter) flush() {
+	if len(w.rows) == 0 {
+		return
+	}
+	batch := w.prefix + strings.Join(w.rows, w.sep) + w.suffix
+	w.rows = nil
+	if w.report != nil {
+		w.report(batch)
+	} else {
+		w.batches = append(w.batches, batch)
+	}
+}
+
+// String flushes the current batch and returns the batches which are not reported.
+func (w *rollbackWriter) String() string {
+	w.flush()
+	return strings.Join(w.batches, "\n")
+}
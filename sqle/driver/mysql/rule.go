@@ -101,6 +101,25 @@ const (
 	ConfigDMLRollbackMaxRows = "dml_rollback_max_rows"
 	ConfigDDLOSCMinSize      = "ddl_osc_min_size"
 	ConfigDDLGhostMinSize    = "ddl_ghost_min_size"
+
+	ConfigDDLGhostOldTableRetentionHours = "ddl_ghost_old_table_retention_hours"
+	ConfigDDLOnlineDDLTool               = "ddl_online_ddl_tool"
+	ConfigDDLRecycleBinRetentionDays     = "ddl_recycle_bin_retention_days"
+
+	ConfigDMLChunkMinRows       = "dml_chunk_min_rows"
+	ConfigDMLChunkSize          = "dml_chunk_size"
+	ConfigDMLChunkSleepMs       = "dml_chunk_sleep_ms"
+	ConfigDMLChunkMaxReplicaLag = "dml_chunk_max_replica_lag"
+
+	ConfigDMLRollbackBinlogMaxRows        = "dml_rollback_binlog_max_rows"
+	ConfigDMLRollbackBackupRetentionHours = "dml_rollback_backup_retention_hours"
+	ConfigDMLRollbackKeylessTable         = "dml_rollback_keyless_table"
+	ConfigDMLRollbackBatchRows            = "dml_rollback_batch_rows"
+
+	ConfigDDLCheckMaxTrxTime    = "ddl_check_max_trx_time"
+	ConfigDDLCheckMaxReplicaLag = "ddl_check_max_replica_lag"
+	ConfigDDLCheckAction        = "ddl_check_action"
+	ConfigDDLCheckWaitTimeout   = "ddl_check_wait_timeout"
 )
 
 type RuleHandler struct {
@@ -165,6 +184,156 @@ var RuleHandlers = []RuleHandler{
 		},
 		Func: nil,
 	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDDLGhostOldTableRetentionHours,
+			Desc:     "使用gh-ost上线时保留原表指定时长(小时)，回滚时将原表改名换回，超时后删除原表",
+			Value:    "-1",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDDLOnlineDDLTool,
+			Desc:     "改表时使用的online DDL工具(gh-ost/pt-osc)，使用pt-osc时，表空间超过ddl_osc_min_size指定大小(MB)时使用pt-online-schema-change上线",
+			Value:    OnlineDDLToolGhost,
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDDLRecycleBinRetentionDays,
+			Desc:     "删除表或库时将表移动到回收站库 sqle_recycle_bin，回滚时将表移回，回收站中的表保留指定时长(天)后清除",
+			Value:    "-1",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDMLChunkMinRows,
+			Desc:     "UPDATE/DELETE 语句预计影响行数超过指定值时按主键分批上线，和其他 SQL 在同一事务中上线时不分批",
+			Value:    "-1",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDMLChunkSize,
+			Desc:     "按主键分批上线 UPDATE/DELETE 语句时，每批的主键行数",
+			Value:    "1000",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDMLChunkSleepMs,
+			Desc:     "按主键分批上线 UPDATE/DELETE 语句时，每批之间的间隔时间(毫秒)",
+			Value:    "100",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDMLChunkMaxReplicaLag,
+			Desc:     "按主键分批上线 UPDATE/DELETE 语句时，从库延迟超过指定时间(秒)则暂停上线",
+			Value:    "-1",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDMLRollbackBinlogMaxRows,
+			Desc:     "上线 DML 时记录 binlog 位置，上线后根据 binlog 中的行镜像生成回滚语句，变更行数超过指定值时不生成",
+			Value:    "-1",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDMLRollbackBackupRetentionHours,
+			Desc:     "UPDATE/DELETE 语句预计影响行数超过 dml_rollback_max_rows 时，上线前将变更的数据备份到备份表用于回滚，备份表保留指定时长(小时)后删除",
+			Value:    "-1",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDMLRollbackKeylessTable,
+			Desc:     "值为 true 时，表没有主键和非空唯一键时按整行匹配并限制 LIMIT 1 生成 DML 的回滚语句，表中存在重复行时可能回滚其他行",
+			Value:    "false",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDMLRollbackBatchRows,
+			Desc:     "生成 DML 的回滚语句时，每条回滚语句最多包含的行数，超过时拆分为多条回滚语句",
+			Value:    "1000",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDDLCheckMaxTrxTime,
+			Desc:     "上线 DDL 前，检查是否存在持有变更表元数据锁且执行时间超过指定时间(秒)的事务或查询",
+			Value:    "-1",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDDLCheckMaxReplicaLag,
+			Desc:     "上线 DDL 前，检查从库延迟是否超过指定时间(秒)",
+			Value:    "-1",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDDLCheckAction,
+			Desc:     "上线 DDL 前检查不通过时的处理方式，wait: 等待检查通过，abort: 中止上线，warn: 记录警告并继续上线",
+			Value:    DDLCheckActionWait,
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
+	{
+		Rule: driver.Rule{
+			Name:     ConfigDDLCheckWaitTimeout,
+			Desc:     "上线 DDL 前检查不通过时的最长等待时间(秒)，超时则中止上线",
+			Value:    "600",
+			Level:    driver.RuleLevelNormal,
+			Category: RuleTypeGlobalConfig,
+		},
+		Func: nil,
+	},
 
 	// rule
 	{
@@ -463,6 +463,69 @@ func hasUniqIndex(stmt *ast.CreateTableStmt) bool {
 	return false
 }
 
+// getNotNullUniqueKey returns the columns of the unique key whose columns are all not
+// null, the key which has the fewest columns is returned if there are more than one.
+func getNotNullUniqueKey(stmt *ast.CreateTableStmt) (map[string]struct{}, bool) {
+	notNullColumns := map[string]struct{}{}
+	keys := [][]string{}
+	for _, col := range stmt.Cols {
+		if HasOneInOptions(col.Options, ast.ColumnOptionNotNull, ast.ColumnOptionPrimaryKey) {
+			notNullColumns[col.Name.Name.L] = struct{}{}
+		}
+		if HasOneInOptions(col.Options, ast.ColumnOptionUniqKey) {
+			keys = append(keys, []string{col.Name.Name.L})
+		}
+	}
+	for _, constraint := range stmt.Constraints {
+		switch constraint.Tp {
+		case ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		default:
+			continue
+		}
+		key := []string{}
+		for _, col := range constraint.Keys {
+			if col.Column == nil {
+				key = nil
+				break
+			}
+			key = append(key, col.Column.Name.L)
+		}
+		if len(key) > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	var best []string
+KEYS:
+	for _, key := range keys {
+		for _, col := range key {
+			if _, ok := notNullColumns[col]; !ok {
+				continue KEYS
+			}
+		}
+		if best == nil || len(key) < len(best) {
+			best = key
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	keyColumnsName := map[string]struct{}{}
+	for _, col := range best {
+		keyColumnsName[col] = struct{}{}
+	}
+	return keyColumnsName, true
+}
+
+// getRollbackKey returns the columns of primary key, or the not null unique key if
+// the table has no primary key, which identify the rows changed by DML.
+func getRollbackKey(stmt *ast.CreateTableStmt) (map[string]struct{}, bool) {
+	if pkColumnsName, hasPk := getPrimaryKey(stmt); hasPk {
+		return pkColumnsName, true
+	}
+	return getNotNullUniqueKey(stmt)
+}
+
 func replaceTableName(query, schema, table string) string {
 	re := regexp.MustCompile(fmt.Sprintf("%s\\.%s|`%s`\\.`%s`|`%s`\\.%s|%s\\.`%s`",
 		schema, table, schema, table, schema, table, schema, table))
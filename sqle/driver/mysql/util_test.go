@@ -67,39 +67,25 @@ func TestInspectResults(t *testing.T) {
 [error]表 not_exist_tb 不存在`, results.Message())
 
 	results2 := driver.NewInspectResults()
-	results2.Add(results.Level(), results.Message())
+	results2.AddWithRule(&handler.Rule, handler.Message)
 	results2.Add(driver.RuleLevelNotice, "test")
+	results2.Add(driver.RuleLevelNotice, "")
 	assert.Equal(t, driver.RuleLevelError, results2.Level())
 	assert.Equal(t,
 		`[error]新建表必须加入if not exists create，保证重复执行不报错
-[error]表 not_exist_tb 不存在
 [notice]test`, results2.Message())
+	assert.Len(t, results2.Items(), 2)
+	assert.Equal(t, DDLCheckPKWithoutIfNotExists, results2.Items()[0].RuleName)
+	assert.Equal(t, handler.Rule.Category, results2.Items()[0].Category)
+	assert.Equal(t, "", results2.Items()[1].RuleName)
 
 	results3 := driver.NewInspectResults()
-	results3.Add(results2.Level(), results2.Message())
-	results3.Add(driver.RuleLevelNotice, "[osc]test")
-	assert.Equal(t, driver.RuleLevelError, results3.Level())
+	results3.AddItem(&driver.AuditResultItem{Level: driver.RuleLevelNotice, Message: "test", Prefix: "[osc]"})
+	results3.Add(driver.RuleLevelWarn, "test")
+	assert.Equal(t, driver.RuleLevelWarn, results3.Level())
 	assert.Equal(t,
-		`[error]新建表必须加入if not exists create，保证重复执行不报错
-[error]表 not_exist_tb 不存在
-[notice]test
-[osc]test`, results3.Message())
-
-	results4 := driver.NewInspectResults()
-	results4.Add(driver.RuleLevelNotice, "[notice]test")
-	results4.Add(driver.RuleLevelError, "[osc]test")
-	assert.Equal(t, driver.RuleLevelError, results4.Level())
-	assert.Equal(t,
-		`[notice]test
-[osc]test`, results4.Message())
-
-	results5 := driver.NewInspectResults()
-	results5.Add(driver.RuleLevelWarn, "[warn]test")
-	results5.Add(driver.RuleLevelNotice, "[osc]test")
-	assert.Equal(t, driver.RuleLevelWarn, results5.Level())
-	assert.Equal(t,
-		`[warn]test
-[osc]test`, results5.Message())
+		`[osc]test
+[warn]test`, results3.Message())
 }
 
 type VisitorTestCase struct {
@@ -253,3 +239,23 @@ func testFingerprint(t *testing.T, input, expect string) {
 	}
 	assert.Equal(t, expect, acutal)
 }
+
+func TestGetNotNullUniqueKey(t *testing.T) {
+	node, err := parseOneSql(`CREATE TABLE t1 (
+a int NOT NULL,
+b int NOT NULL UNIQUE,
+c int,
+d int NOT NULL,
+UNIQUE KEY uniq_c(c),
+UNIQUE KEY uniq_a_d(a, d)
+);`)
+	assert.NoError(t, err)
+	key, ok := getNotNullUniqueKey(node.(*ast.CreateTableStmt))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]struct{}{"b": {}}, key)
+
+	node, err = parseOneSql(`CREATE TABLE t1 (a int NOT NULL, c int, UNIQUE KEY uniq_a_c(a, c));`)
+	assert.NoError(t, err)
+	_, ok = getNotNullUniqueKey(node.(*ast.CreateTableStmt))
+	assert.False(t, ok)
+}
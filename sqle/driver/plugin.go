@@ -2,11 +2,13 @@ package driver
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sync"
 
 	"github.com/actiontech/sqle/sqle/driver/proto"
 	"github.com/actiontech/sqle/sqle/log"
@@ -15,44 +17,63 @@ import (
 	goPlugin "github.com/hashicorp/go-plugin"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // InitPlugins init plugins at plugins directory. It should be called on host process.
-func InitPlugins(pluginDir string) error {
+//
+// processes is the number of long-lived processes of each plugin, the drivers of
+// plugin are opened as sessions on them.
+func InitPlugins(pluginDir string, processes int) error {
 	if pluginDir == "" {
 		return nil
 	}
 
-	getServerHandle := func(path string, closeCh <-chan struct{}) (proto.DriverClient, error) {
-		client := goPlugin.NewClient(&goPlugin.ClientConfig{
-			HandshakeConfig: handshakeConfig,
-			Plugins: goPlugin.PluginSet{
-				filepath.Base(path): &driverPlugin{},
-			},
-			Cmd:              exec.Command(path),
-			AllowedProtocols: []goPlugin.Protocol{goPlugin.ProtocolGRPC},
-		})
-		go func() {
-			select {
-			case <-closeCh:
-				client.Kill()
-			}
-		}()
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	pluginsDir, pluginsProcesses = pluginDir, processes
 
-		gRPCClient, err := client.Client()
+	files, err := scanPlugins(pluginDir)
+	if err != nil {
+		return err
+	}
+	for path, info := range files {
+		p, rules, err := loadPlugin(path, info)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		rawI, err := gRPCClient.Dispense(filepath.Base(path))
-		if err != nil {
-			return nil, err
+		if isDriverRegistered(p.name) {
+			p.pool.close()
+			return fmt.Errorf("duplicated driver name %s of plugin %s", p.name, path)
 		}
-		srv := rawI.(proto.DriverClient)
-		return srv, nil
+		p.register(rules)
+		plugins[path] = p
+
+		log.Logger().WithFields(logrus.Fields{
+			"plugin_name": p.name,
+		}).Infoln("plugin inited")
 	}
 
-	var plugins []os.FileInfo
-	filepath.Walk(pluginDir, func(path string, info os.FileInfo, err error) error {
+	return nil
+}
+
+// ClosePlugins closes the processes of plugins. It should be called on host process
+// before exit.
+func ClosePlugins() {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	for _, p := range plugins {
+		p.pool.close()
+	}
+	goPlugin.CleanupClients()
+}
+
+// scanPlugins returns the executable files at plugins directory, the key is path.
+func scanPlugins(pluginDir string) (map[string]os.FileInfo, error) {
+	files := map[string]os.FileInfo{}
+	err := filepath.Walk(pluginDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return errors.Wrap(err, "init plugin")
 		}
@@ -60,95 +81,117 @@ func InitPlugins(pluginDir string) error {
 		if info.IsDir() || info.Mode()&0111 == 0 {
 			return nil
 		}
-		plugins = append(plugins, info)
+		files[path] = info
 		return nil
 	})
-	for _, p := range plugins {
-		binaryPath := filepath.Join(pluginDir, p.Name())
+	return files, err
+}
 
-		closeCh := make(chan struct{})
-		srv, err := getServerHandle(binaryPath, closeCh)
-		if err != nil {
-			return err
-		}
-		pluginMeta, err := srv.Metas(context.TODO(), &proto.Empty{})
-		if err != nil {
-			return err
-		}
-		close(closeCh)
+// loadPlugin starts the processes of plugin binary and gets the metas of it, the
+// plugin is not registered.
+func loadPlugin(path string, info os.FileInfo) (*plugin, []*Rule, error) {
+	pool, proc, err := newPluginPool(path, pluginsProcesses)
+	if err != nil {
+		return nil, nil, err
+	}
+	pluginMeta, err := proc.srv.Metas(context.TODO(), &proto.Empty{})
+	if pool.legacy {
+		proc.client.Kill()
+	}
+	if err != nil {
+		pool.close()
+		return nil, nil, err
+	}
 
-		// driverRules get from plugin when plugin initialize.
-		var driverRules []*Rule
-		for _, rule := range pluginMeta.Rules {
-			driverRules = append(driverRules, &Rule{
-				Category: rule.Category,
+	// driverRules get from plugin when plugin initialize.
+	var driverRules []*Rule
+	for _, rule := range pluginMeta.Rules {
+		driverRules = append(driverRules, &Rule{
+			Category: rule.Category,
+			Name:     rule.Name,
+			Desc:     rule.Desc,
+			Value:    rule.Value,
+			Level:    RuleLevel(rule.Level),
+		})
+	}
+	metas := &Metas{
+		Version:         pluginMeta.Version,
+		ProtocolVersion: proc.client.NegotiatedVersion(),
+	}
+	if len(pluginMeta.ProtocolVersions) == 0 {
+		metas.Capabilities = legacyPluginCapabilities
+	}
+	for _, c := range pluginMeta.Capabilities {
+		metas.Capabilities = append(metas.Capabilities, Capability(c))
+	}
+	return &plugin{
+		name:    pluginMeta.Name,
+		path:    path,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		metas:   metas,
+		pool:    pool,
+	}, driverRules, nil
+}
+
+// register registers the driver of plugin, the drivers opened after it are opened
+// on the processes of plugin.
+func (p *plugin) register(rules []*Rule) {
+	pool := p.pool
+	handler := func(log *logrus.Entry, config *Config) (Driver, error) {
+		// protoRules send to plugin for Audit.
+		var protoRules []*proto.Rule
+		for _, rule := range config.Rules {
+			protoRules = append(protoRules, &proto.Rule{
 				Name:     rule.Name,
 				Desc:     rule.Desc,
 				Value:    rule.Value,
-				Level:    RuleLevel(rule.Level),
+				Level:    string(rule.Level),
+				Category: rule.Category,
 			})
 		}
 
-		handler := func(log *logrus.Entry, config *Config) (Driver, error) {
-			pluginCloseCh := make(chan struct{})
-			srv, err := getServerHandle(binaryPath, pluginCloseCh)
-			if err != nil {
-				return nil, err
-			}
-
-			// protoRules send to plugin for Audit.
-			var protoRules []*proto.Rule
-			for _, rule := range config.Rules {
-				protoRules = append(protoRules, &proto.Rule{
-					Name:     rule.Name,
-					Desc:     rule.Desc,
-					Value:    rule.Value,
-					Level:    string(rule.Level),
-					Category: rule.Category,
-				})
-			}
-
-			initRequest := &proto.InitRequest{
-				Rules: protoRules,
-			}
-			if config.DSN != nil {
-				initRequest.Dsn = &proto.DSN{
-					Host:     config.DSN.Host,
-					Port:     config.DSN.Port,
-					User:     config.DSN.User,
-					Password: config.DSN.Password,
-
-					// database is to open.
-					Database: config.DSN.DatabaseName,
-				}
-			}
-
-			_, err = srv.Init(context.TODO(), initRequest)
-			if err != nil {
-				return nil, err
+		initRequest := &proto.InitRequest{
+			Rules: protoRules,
+		}
+		if config.DSN != nil {
+			initRequest.Dsn = &proto.DSN{
+				Host:     config.DSN.Host,
+				Port:     config.DSN.Port,
+				User:     config.DSN.User,
+				Password: config.DSN.Password,
+
+				// database is to open.
+				Database: config.DSN.DatabaseName,
 			}
-			return &driverPluginClient{srv, pluginCloseCh}, nil
-
 		}
 
-		Register(pluginMeta.Name, handler, driverRules)
-
-		log.Logger().WithFields(logrus.Fields{
-			"plugin_name": pluginMeta.Name,
-		}).Infoln("plugin inited")
+		c := &driverPluginClient{
+			log:         log,
+			pool:        pool,
+			initRequest: initRequest,
+		}
+		if err := c.open(context.TODO()); err != nil {
+			return nil, err
+		}
+		return c, nil
 	}
 
-	return nil
+	Register(p.name, handler, rules, p.metas)
 }
 
 // ServePlugin start plugin process service. It should be called on plugin process.
 func ServePlugin(r Registerer, newDriver func(cfg *Config) Driver) {
 	name := r.Name()
+	pluginSet := goPlugin.PluginSet{
+		name: &driverPlugin{Srv: &driverGRPCServer{r: r, newDriver: newDriver, sessions: map[string]Driver{}}},
+	}
 	goPlugin.Serve(&goPlugin.ServeConfig{
 		HandshakeConfig: handshakeConfig,
 
-		Plugins: goPlugin.PluginSet{
-			name: &driverPlugin{Srv: &driverGRPCServer{r: r, newDriver: newDriver}},
+		VersionedPlugins: map[int]goPlugin.PluginSet{
+			protocolVersionLegacy:  pluginSet,
+			protocolVersionSession: pluginSet,
 		},
 
 		// A non-nil value here enables gRPC serving for this plugin...
@@ -156,6 +199,22 @@ func ServePlugin(r Registerer, newDriver func(cfg *Config) Driver) {
 	})
 }
 
+const (
+	// protocolVersionLegacy is the protocol of the plugins built before session,
+	// each driver runs on its own plugin process.
+	protocolVersionLegacy = 1
+	// protocolVersionSession is the protocol which opens the drivers as sessions
+	// on the long-lived plugin processes.
+	protocolVersionSession = 2
+)
+
+// legacyPluginCapabilities are the capabilities of the plugin which doesn't report
+// capabilities, they are supported by all plugins built before capability.
+var legacyPluginCapabilities = []Capability{CapabilityOfflineAudit, CapabilityTransaction}
+
+// handshakeConfig is the handshake of plugin, the protocol is negotiated by the
+// versioned plugins, ProtocolVersion is used by the plugin which is not versioned.
+// The cookie is kept for the plugins built before.
 var handshakeConfig = goPlugin.HandshakeConfig{
 	ProtocolVersion:  1,
 	MagicCookieKey:   "BASIC_PLUGIN",
@@ -164,19 +223,67 @@ var handshakeConfig = goPlugin.HandshakeConfig{
 
 // driverPluginClient implement Driver. It use for hide gRPC detail, just like DriverGRPCServer.
 type driverPluginClient struct {
-	plugin proto.DriverClient
+	log  *logrus.Entry
+	pool *pluginPool
+
+	// initRequest is kept for opening the session again if the plugin process
+	// is crashed.
+	initRequest *proto.InitRequest
+	proc        *pluginProcess
+	session     *proto.Session
+
+	// auditBatchUnimplemented is set if the plugin is built before AuditBatch RPC,
+	// then the SQLs are audited by Audit one by one.
+	auditBatchUnimplemented bool
+}
 
-	// driverQuitCh pruduce a singal for telling caller that it's time to Client.Kill() plugin process.
-	driverQuitCh chan struct{}
+// open opens the session on a process of pool.
+func (s *driverPluginClient) open(ctx context.Context) error {
+	proc, err := s.pool.get()
+	if err != nil {
+		return err
+	}
+	resp, err := proc.srv.Init(ctx, s.initRequest)
+	if err != nil {
+		s.pool.release(proc)
+		return err
+	}
+	s.proc, s.session = proc, resp.GetSession()
+	return nil
+}
+
+// plugin returns the client of plugin process which the session is opened on. If
+// the process is crashed, the session is opened again on the restarted one, the
+// SQL context kept by the crashed session is lost.
+func (s *driverPluginClient) plugin(ctx context.Context) (proto.DriverClient, error) {
+	if s.proc != nil && s.proc.exited() {
+		s.log.Warnf("plugin process is exited, open session again")
+		s.pool.release(s.proc)
+		s.proc, s.session = nil, nil
+	}
+	if s.proc == nil {
+		if err := s.open(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return s.proc.srv, nil
 }
 
 func (s *driverPluginClient) Close(ctx context.Context) {
-	s.plugin.Close(ctx, &proto.Empty{})
-	close(s.driverQuitCh)
+	if s.proc == nil {
+		return
+	}
+	s.proc.srv.Close(ctx, &proto.SessionRequest{Session: s.session})
+	s.pool.release(s.proc)
+	s.proc, s.session = nil, nil
 }
 
 func (s *driverPluginClient) Ping(ctx context.Context) error {
-	_, err := s.plugin.Ping(ctx, &proto.Empty{})
+	plugin, err := s.plugin(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = plugin.Ping(ctx, &proto.SessionRequest{Session: s.session})
 	return err
 }
 
@@ -202,7 +309,11 @@ func (s *dbDriverResult) RowsAffected() (int64, error) {
 }
 
 func (s *driverPluginClient) Exec(ctx context.Context, query string) (driver.Result, error) {
-	resp, err := s.plugin.Exec(ctx, &proto.ExecRequest{Query: query})
+	plugin, err := s.plugin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := plugin.Exec(ctx, &proto.ExecRequest{Session: s.session, Query: query})
 	if err != nil {
 		return nil, err
 	}
@@ -215,7 +326,11 @@ func (s *driverPluginClient) Exec(ctx context.Context, query string) (driver.Res
 }
 
 func (s *driverPluginClient) Tx(ctx context.Context, queries ...string) ([]driver.Result, error) {
-	resp, err := s.plugin.Tx(ctx, &proto.TxRequest{Queries: queries})
+	plugin, err := s.plugin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := plugin.Tx(ctx, &proto.TxRequest{Session: s.session, Queries: queries})
 	if err != nil {
 		return nil, err
 	}
@@ -233,7 +348,11 @@ func (s *driverPluginClient) Tx(ctx context.Context, queries ...string) ([]drive
 }
 
 func (s *driverPluginClient) Schemas(ctx context.Context) ([]string, error) {
-	resp, err := s.plugin.Databases(ctx, &proto.Empty{})
+	plugin, err := s.plugin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := plugin.Databases(ctx, &proto.SessionRequest{Session: s.session})
 	if err != nil {
 		return nil, err
 	}
@@ -241,7 +360,11 @@ func (s *driverPluginClient) Schemas(ctx context.Context) ([]string, error) {
 }
 
 func (s *driverPluginClient) Parse(ctx context.Context, sqlText string) ([]Node, error) {
-	resp, err := s.plugin.Parse(ctx, &proto.ParseRequest{SqlText: sqlText})
+	plugin, err := s.plugin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := plugin.Parse(ctx, &proto.ParseRequest{Session: s.session, SqlText: sqlText})
 	if err != nil {
 		return nil, err
 	}
@@ -258,23 +381,75 @@ func (s *driverPluginClient) Parse(ctx context.Context, sqlText string) ([]Node,
 }
 
 func (s *driverPluginClient) Audit(ctx context.Context, sql string) (*AuditResult, error) {
-	resp, err := s.plugin.Audit(ctx, &proto.AuditRequest{Sql: sql})
+	plugin, err := s.plugin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := plugin.Audit(ctx, &proto.AuditRequest{Session: s.session, Sql: sql})
 	if err != nil {
 		return nil, err
 	}
+	return convertAuditResponse(resp), nil
+}
 
+func (s *driverPluginClient) AuditBatch(ctx context.Context, sqls []string) ([]*AuditResult, error) {
+	if !s.auditBatchUnimplemented {
+		plugin, err := s.plugin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := plugin.AuditBatch(ctx, &proto.AuditBatchRequest{Session: s.session, Sqls: sqls})
+		// the SQLs are audited one by one if the request or response of the batch
+		// exceeds the gRPC message size limit.
+		if status.Code(err) == codes.Unimplemented {
+			s.auditBatchUnimplemented = true
+		} else if status.Code(err) == codes.ResourceExhausted {
+			s.log.Warnf("audit batch of %d SQLs exceeds message size limit, audit them one by one", len(sqls))
+		} else if err != nil {
+			return nil, err
+		} else {
+			if len(resp.Responses) != len(sqls) {
+				return nil, fmt.Errorf("audit batch: got %d results of %d SQLs", len(resp.Responses), len(sqls))
+			}
+			ret := make([]*AuditResult, 0, len(sqls))
+			for _, r := range resp.Responses {
+				ret = append(ret, convertAuditResponse(r))
+			}
+			return ret, nil
+		}
+	}
+
+	ret := make([]*AuditResult, 0, len(sqls))
+	for _, sql := range sqls {
+		result, err := s.Audit(ctx, sql)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, result)
+	}
+	return ret, nil
+}
+
+func convertAuditResponse(resp *proto.AuditResponse) *AuditResult {
 	ret := &AuditResult{}
 	for _, result := range resp.Results {
-		ret.results = append(ret.results, &auditResult{
-			level:   RuleLevel(result.Level),
-			message: result.Message,
+		ret.AddItem(&AuditResultItem{
+			RuleName: result.RuleName,
+			Category: result.Category,
+			Level:    RuleLevel(result.Level),
+			Message:  result.Message,
+			Prefix:   result.Prefix,
 		})
 	}
-	return ret, nil
+	return ret
 }
 
 func (s *driverPluginClient) GenRollbackSQL(ctx context.Context, sql string) (string, string, error) {
-	resp, err := s.plugin.GenRollbackSQL(ctx, &proto.GenRollbackSQLRequest{Sql: sql})
+	plugin, err := s.plugin(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := plugin.GenRollbackSQL(ctx, &proto.GenRollbackSQLRequest{Session: s.session, Sql: sql})
 	if err != nil {
 		return "", "", err
 	}
@@ -286,13 +461,44 @@ func (s *driverPluginClient) GenRollbackSQL(ctx context.Context, sql string) (st
 type driverGRPCServer struct {
 	newDriver func(cfg *Config) Driver
 
+	mu sync.Mutex
+	// sessions are the drivers opened by Init, the key is session id.
+	sessions map[string]Driver
+	// impl is the driver opened by the last Init, it's used by the host which
+	// is built before session and sends no session.
 	impl Driver
 
 	// Registerer provide some plugin info to host process.
 	r Registerer
 }
 
-func (d *driverGRPCServer) Init(ctx context.Context, req *proto.InitRequest) (*proto.Empty, error) {
+// getDriver returns the driver of session.
+func (d *driverGRPCServer) getDriver(session *proto.Session) (Driver, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if session.GetId() == "" {
+		if d.impl == nil {
+			return nil, status.Error(codes.FailedPrecondition, "driver is not initialized")
+		}
+		return d.impl, nil
+	}
+	impl, ok := d.sessions[session.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "session %s is not exist", session.GetId())
+	}
+	return impl, nil
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (d *driverGRPCServer) Init(ctx context.Context, req *proto.InitRequest) (_ *proto.InitResponse, err error) {
 	var driverRules []*Rule
 	for _, rule := range req.GetRules() {
 		driverRules = append(driverRules, &Rule{
@@ -319,21 +525,57 @@ func (d *driverGRPCServer) Init(ctx context.Context, req *proto.InitRequest) (*p
 	if err != nil {
 		return nil, errors.Wrap(err, "init config")
 	}
-	d.impl = d.newDriver(cfg)
-	return &proto.Empty{}, nil
+	id, err := newSessionID()
+	if err != nil {
+		return nil, errors.Wrap(err, "new session id")
+	}
+
+	// the process serves other sessions, so it should not exit if the driver is
+	// failed to open.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("new driver panic: %v", r)
+		}
+	}()
+	impl := d.newDriver(cfg)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sessions[id] = impl
+	d.impl = impl
+	return &proto.InitResponse{Session: &proto.Session{Id: id}}, nil
 }
 
-func (d *driverGRPCServer) Close(ctx context.Context, req *proto.Empty) (*proto.Empty, error) {
-	d.impl.Close(ctx)
+func (d *driverGRPCServer) Close(ctx context.Context, req *proto.SessionRequest) (*proto.Empty, error) {
+	impl, err := d.getDriver(req.GetSession())
+	if err != nil {
+		return &proto.Empty{}, err
+	}
+	impl.Close(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sessions, req.GetSession().GetId())
+	if d.impl == impl {
+		d.impl = nil
+	}
 	return &proto.Empty{}, nil
 }
 
-func (d *driverGRPCServer) Ping(ctx context.Context, req *proto.Empty) (*proto.Empty, error) {
-	return &proto.Empty{}, d.impl.Ping(ctx)
+func (d *driverGRPCServer) Ping(ctx context.Context, req *proto.SessionRequest) (*proto.Empty, error) {
+	impl, err := d.getDriver(req.GetSession())
+	if err != nil {
+		return &proto.Empty{}, err
+	}
+	return &proto.Empty{}, impl.Ping(ctx)
 }
 
 func (d *driverGRPCServer) Exec(ctx context.Context, req *proto.ExecRequest) (*proto.ExecResponse, error) {
-	result, err := d.impl.Exec(ctx, req.GetQuery())
+	impl, err := d.getDriver(req.GetSession())
+	if err != nil {
+		return &proto.ExecResponse{}, err
+	}
+	result, err := impl.Exec(ctx, req.GetQuery())
 	if err != nil {
 		return &proto.ExecResponse{}, nil
 	}
@@ -353,7 +595,11 @@ func (d *driverGRPCServer) Exec(ctx context.Context, req *proto.ExecRequest) (*p
 }
 
 func (d *driverGRPCServer) Tx(ctx context.Context, req *proto.TxRequest) (*proto.TxResponse, error) {
-	resluts, err := d.impl.Tx(ctx, req.GetQueries()...)
+	impl, err := d.getDriver(req.GetSession())
+	if err != nil {
+		return &proto.TxResponse{}, err
+	}
+	resluts, err := impl.Tx(ctx, req.GetQueries()...)
 	if err != nil {
 		return &proto.TxResponse{}, nil
 	}
@@ -378,13 +624,21 @@ func (d *driverGRPCServer) Tx(ctx context.Context, req *proto.TxRequest) (*proto
 	return txResp, nil
 }
 
-func (d *driverGRPCServer) Databases(ctx context.Context, req *proto.Empty) (*proto.DatabasesResponse, error) {
-	databases, err := d.impl.Schemas(ctx)
+func (d *driverGRPCServer) Databases(ctx context.Context, req *proto.SessionRequest) (*proto.DatabasesResponse, error) {
+	impl, err := d.getDriver(req.GetSession())
+	if err != nil {
+		return &proto.DatabasesResponse{}, err
+	}
+	databases, err := impl.Schemas(ctx)
 	return &proto.DatabasesResponse{Databases: databases}, err
 }
 
 func (d *driverGRPCServer) Parse(ctx context.Context, req *proto.ParseRequest) (*proto.ParseResponse, error) {
-	nodes, err := d.impl.Parse(ctx, req.GetSqlText())
+	impl, err := d.getDriver(req.GetSession())
+	if err != nil {
+		return &proto.ParseResponse{}, err
+	}
+	nodes, err := impl.Parse(ctx, req.GetSqlText())
 	if err != nil {
 		return &proto.ParseResponse{}, err
 	}
@@ -401,23 +655,55 @@ func (d *driverGRPCServer) Parse(ctx context.Context, req *proto.ParseRequest) (
 }
 
 func (d *driverGRPCServer) Audit(ctx context.Context, req *proto.AuditRequest) (*proto.AuditResponse, error) {
-	auditResluts, err := d.impl.Audit(ctx, req.GetSql())
+	impl, err := d.getDriver(req.GetSession())
+	if err != nil {
+		return &proto.AuditResponse{}, err
+	}
+	auditResluts, err := impl.Audit(ctx, req.GetSql())
 	if err != nil {
 		return &proto.AuditResponse{}, nil
 	}
 
+	return convertAuditResult(auditResluts), nil
+}
+
+func (d *driverGRPCServer) AuditBatch(ctx context.Context, req *proto.AuditBatchRequest) (*proto.AuditBatchResponse, error) {
+	impl, err := d.getDriver(req.GetSession())
+	if err != nil {
+		return &proto.AuditBatchResponse{}, err
+	}
+	auditResluts, err := impl.AuditBatch(ctx, req.GetSqls())
+	if err != nil {
+		return &proto.AuditBatchResponse{}, err
+	}
+
+	resp := &proto.AuditBatchResponse{}
+	for _, result := range auditResluts {
+		resp.Responses = append(resp.Responses, convertAuditResult(result))
+	}
+	return resp, nil
+}
+
+func convertAuditResult(auditResult *AuditResult) *proto.AuditResponse {
 	resp := &proto.AuditResponse{}
-	for _, result := range auditResluts.results {
+	for _, result := range auditResult.Items() {
 		resp.Results = append(resp.Results, &proto.AuditResult{
-			Level:   string(result.level),
-			Message: result.message,
+			Level:    string(result.Level),
+			Message:  result.Message,
+			RuleName: result.RuleName,
+			Category: result.Category,
+			Prefix:   result.Prefix,
 		})
 	}
-	return resp, nil
+	return resp
 }
 
 func (d *driverGRPCServer) GenRollbackSQL(ctx context.Context, req *proto.GenRollbackSQLRequest) (*proto.GenRollbackSQLResponse, error) {
-	rollbackSQL, reason, err := d.impl.GenRollbackSQL(ctx, req.GetSql())
+	impl, err := d.getDriver(req.GetSession())
+	if err != nil {
+		return &proto.GenRollbackSQLResponse{}, err
+	}
+	rollbackSQL, reason, err := impl.GenRollbackSQL(ctx, req.GetSql())
 	return &proto.GenRollbackSQLResponse{
 		Sql:    rollbackSQL,
 		Reason: reason,
@@ -437,10 +723,22 @@ func (d *driverGRPCServer) Metas(ctx context.Context, req *proto.Empty) (*proto.
 		})
 	}
 
-	return &proto.MetasResponse{
+	resp := &proto.MetasResponse{
 		Name:  d.r.Name(),
 		Rules: protoRules,
-	}, nil
+	}
+	for _, v := range []int{protocolVersionLegacy, protocolVersionSession} {
+		resp.ProtocolVersions = append(resp.ProtocolVersions, int32(v))
+	}
+	capabilities := legacyPluginCapabilities
+	if r, ok := d.r.(MetasRegisterer); ok {
+		resp.Version = r.Version()
+		capabilities = r.Capabilities()
+	}
+	for _, c := range capabilities {
+		resp.Capabilities = append(resp.Capabilities, string(c))
+	}
+	return resp, nil
 }
 
 // driverPlugin implements goPlugin.GRPCPlugin
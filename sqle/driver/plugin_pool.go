@@ -0,0 +1,304 @@
+package driver
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver/proto"
+	"github.com/actiontech/sqle/sqle/log"
+
+	goPlugin "github.com/hashicorp/go-plugin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultPluginProcesses is the default number of processes of each plugin.
+	DefaultPluginProcesses = 1
+
+	pluginHealthCheckInterval = 10 * time.Second
+)
+
+// pluginClient is the client which manages the plugin process, it's implemented
+// by *goPlugin.Client.
+type pluginClient interface {
+	Exited() bool
+	Kill()
+	NegotiatedVersion() int
+}
+
+// pluginProcess is a long-lived plugin process, the drivers of the plugin are
+// opened as sessions on it.
+type pluginProcess struct {
+	client    pluginClient
+	rpcClient goPlugin.ClientProtocol
+	srv       proto.DriverClient
+
+	// sessions is the number of drivers opened on the process, it's guarded by
+	// the mutex of pool.
+	sessions int
+}
+
+// startPluginProcess starts the process of plugin binary, it's replaced in tests.
+var startPluginProcess = startGoPluginProcess
+
+func startGoPluginProcess(path string) (*pluginProcess, error) {
+	name := filepath.Base(path)
+	pluginSet := goPlugin.PluginSet{
+		name: &driverPlugin{},
+	}
+	client := goPlugin.NewClient(&goPlugin.ClientConfig{
+		HandshakeConfig: handshakeConfig,
+		VersionedPlugins: map[int]goPlugin.PluginSet{
+			protocolVersionLegacy:  pluginSet,
+			protocolVersionSession: pluginSet,
+		},
+		Cmd:              exec.Command(path),
+		AllowedProtocols: []goPlugin.Protocol{goPlugin.ProtocolGRPC},
+		Managed:          true,
+	})
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, err
+	}
+	rawI, err := rpcClient.Dispense(name)
+	if err != nil {
+		client.Kill()
+		return nil, err
+	}
+	return &pluginProcess{
+		client:    client,
+		rpcClient: rpcClient,
+		srv:       rawI.(proto.DriverClient),
+	}, nil
+}
+
+func (p *pluginProcess) exited() bool {
+	return p.client.Exited()
+}
+
+// supportSession returns false if the plugin is built before session, then the
+// process can only serve one driver.
+func (p *pluginProcess) supportSession() bool {
+	return p.client.NegotiatedVersion() >= protocolVersionSession
+}
+
+// pluginPool keeps the long-lived processes of a plugin, the drivers are opened as
+// sessions on the process which has the fewest sessions. The crashed process is
+// restarted by health check, or when a driver is opened on it.
+//
+// The processes are started, pinged and killed without holding the mutex, so a
+// slow plugin doesn't block the drivers opened on the other processes.
+type pluginPool struct {
+	path string
+
+	mu sync.Mutex
+	// startedCond is broadcast when a process of pool is started or failed to start.
+	startedCond *sync.Cond
+	processes   []*pluginProcess
+	// starting marks the processes which are being started.
+	starting []bool
+	// legacy is set if the plugin doesn't support session, then each driver runs
+	// on its own process, which is killed when the driver is closed.
+	legacy bool
+	closed bool
+
+	closeCh chan struct{}
+}
+
+// newPluginPool starts the first process of plugin, which is returned for getting
+// the metas of plugin.
+func newPluginPool(path string, size int) (*pluginPool, *pluginProcess, error) {
+	if size <= 0 {
+		size = DefaultPluginProcesses
+	}
+	proc, err := startPluginProcess(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &pluginPool{
+		path:      path,
+		processes: make([]*pluginProcess, size),
+		starting:  make([]bool, size),
+		closeCh:   make(chan struct{}),
+	}
+	p.startedCond = sync.NewCond(&p.mu)
+	if proc.supportSession() {
+		p.processes[0] = proc
+		go p.healthCheck()
+	} else {
+		p.legacy = true
+	}
+	return p, proc, nil
+}
+
+func (p *pluginPool) logger() *logrus.Entry {
+	return log.NewEntry().WithField("plugin_path", p.path)
+}
+
+// get returns the process to open a driver on, the caller must release it after
+// the driver is closed.
+func (p *pluginPool) get() (*pluginProcess, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.legacy {
+		if p.closed {
+			return nil, fmt.Errorf("plugin %s is closed", p.path)
+		}
+		p.mu.Unlock()
+		proc, err := startPluginProcess(p.path)
+		p.mu.Lock()
+		if err != nil {
+			return nil, err
+		}
+		proc.sessions++
+		return proc, nil
+	}
+
+	for {
+		if p.closed {
+			return nil, fmt.Errorf("plugin %s is closed", p.path)
+		}
+
+		// the idle process is used first, then the process is started if the pool
+		// is not full, otherwise the process with the fewest sessions is used.
+		var proc *pluginProcess
+		stopped := -1
+		for idx, curr := range p.processes {
+			if p.starting[idx] {
+				continue
+			}
+			if curr == nil || curr.exited() {
+				if stopped == -1 {
+					stopped = idx
+				}
+				continue
+			}
+			if proc == nil || curr.sessions < proc.sessions {
+				proc = curr
+			}
+		}
+		if stopped != -1 && (proc == nil || proc.sessions > 0) {
+			var err error
+			proc, err = p.restart(stopped)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if proc != nil {
+			proc.sessions++
+			return proc, nil
+		}
+		// all processes are being started.
+		p.startedCond.Wait()
+	}
+}
+
+// release releases the process got by get.
+func (p *pluginPool) release(proc *pluginProcess) {
+	p.mu.Lock()
+	proc.sessions--
+	kill := p.legacy || (p.closed && proc.sessions == 0)
+	p.mu.Unlock()
+
+	if kill {
+		proc.client.Kill()
+	}
+}
+
+// restart starts a new process at the index of processes, the old one is killed.
+// It must be called with the mutex held, the mutex is released during starting.
+func (p *pluginPool) restart(idx int) (*pluginProcess, error) {
+	old := p.processes[idx]
+	p.processes[idx] = nil
+	p.starting[idx] = true
+	p.mu.Unlock()
+
+	if old != nil {
+		old.client.Kill()
+	}
+	proc, err := startPluginProcess(p.path)
+
+	p.mu.Lock()
+	p.starting[idx] = false
+	p.startedCond.Broadcast()
+	if err != nil {
+		return nil, err
+	}
+	if p.closed {
+		p.mu.Unlock()
+		proc.client.Kill()
+		p.mu.Lock()
+		return nil, fmt.Errorf("plugin %s is closed", p.path)
+	}
+	p.processes[idx] = proc
+	return proc, nil
+}
+
+func (p *pluginPool) healthCheck() {
+	ticker := time.NewTicker(pluginHealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closeCh:
+			return
+		case <-ticker.C:
+			p.checkProcesses()
+		}
+	}
+}
+
+// checkProcesses restarts the process which is crashed or not responding, the
+// drivers on it open their sessions again on the new process.
+func (p *pluginPool) checkProcesses() {
+	p.mu.Lock()
+	processes := append([]*pluginProcess{}, p.processes...)
+	p.mu.Unlock()
+
+	for idx, proc := range processes {
+		if proc == nil {
+			continue
+		}
+		if !proc.exited() && proc.rpcClient.Ping() == nil {
+			continue
+		}
+
+		p.mu.Lock()
+		// the process may be restarted by get during ping.
+		if !p.closed && p.processes[idx] == proc {
+			p.logger().Warnf("plugin process is unhealthy, restart it")
+			if _, err := p.restart(idx); err != nil {
+				p.logger().Errorf("restart plugin process error: %v", err)
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// close kills the processes which have no session, the others are killed when
+// their drivers are closed.
+func (p *pluginPool) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.closeCh)
+	idle := []*pluginProcess{}
+	for _, proc := range p.processes {
+		if proc != nil && proc.sessions == 0 {
+			idle = append(idle, proc)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, proc := range idle {
+		proc.client.Kill()
+	}
+}
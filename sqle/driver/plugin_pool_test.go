@@ -0,0 +1,262 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver/proto"
+	"github.com/actiontech/sqle/sqle/log"
+
+	goPlugin "github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type fakePluginClient struct {
+	mu      sync.Mutex
+	exited  bool
+	killed  bool
+	version int
+}
+
+func (c *fakePluginClient) Exited() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.exited || c.killed
+}
+
+func (c *fakePluginClient) Kill() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.killed = true
+}
+
+func (c *fakePluginClient) NegotiatedVersion() int {
+	return c.version
+}
+
+func (c *fakePluginClient) isKilled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.killed
+}
+
+func (c *fakePluginClient) exit() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exited = true
+}
+
+type fakeRPCClient struct {
+	goPlugin.ClientProtocol
+	pingErr error
+}
+
+func (c *fakeRPCClient) Ping() error {
+	return c.pingErr
+}
+
+// fakeDriverClient is the plugin server of fake process, the binary content of
+// plugin is "<name>:<version>".
+type fakeDriverClient struct {
+	proto.DriverClient
+	name    string
+	version string
+
+	mu    sync.Mutex
+	inits int
+}
+
+func (c *fakeDriverClient) Metas(ctx context.Context, in *proto.Empty, opts ...grpc.CallOption) (*proto.MetasResponse, error) {
+	return &proto.MetasResponse{Name: c.name, Version: c.version, ProtocolVersions: []int32{protocolVersionSession}}, nil
+}
+
+func (c *fakeDriverClient) Init(ctx context.Context, in *proto.InitRequest, opts ...grpc.CallOption) (*proto.InitResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inits++
+	return &proto.InitResponse{Session: &proto.Session{Id: c.version}}, nil
+}
+
+func (c *fakeDriverClient) Close(ctx context.Context, in *proto.SessionRequest, opts ...grpc.CallOption) (*proto.Empty, error) {
+	return &proto.Empty{}, nil
+}
+
+func (c *fakeDriverClient) Ping(ctx context.Context, in *proto.SessionRequest, opts ...grpc.CallOption) (*proto.Empty, error) {
+	return &proto.Empty{}, nil
+}
+
+// fakePluginProcesses replaces startPluginProcess by starting fake processes, it
+// returns the processes started.
+func fakePluginProcesses(t *testing.T, version int) *[]*pluginProcess {
+	processes := &[]*pluginProcess{}
+	var mu sync.Mutex
+	origin := startPluginProcess
+	startPluginProcess = func(path string) (*pluginProcess, error) {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.SplitN(string(content), ":", 2)
+		proc := &pluginProcess{
+			client:    &fakePluginClient{version: version},
+			rpcClient: &fakeRPCClient{},
+			srv:       &fakeDriverClient{name: name[0], version: name[len(name)-1]},
+		}
+		mu.Lock()
+		*processes = append(*processes, proc)
+		mu.Unlock()
+		return proc, nil
+	}
+	t.Cleanup(func() {
+		startPluginProcess = origin
+	})
+	return processes
+}
+
+func writeFakePlugin(t *testing.T, path, content string) {
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0755))
+}
+
+func newFakePluginPool(t *testing.T, size, version int) (*pluginPool, *[]*pluginProcess) {
+	processes := fakePluginProcesses(t, version)
+	path := t.TempDir() + "/fake"
+	writeFakePlugin(t, path, "fake:v1")
+	pool, _, err := newPluginPool(path, size)
+	assert.NoError(t, err)
+	t.Cleanup(pool.close)
+	return pool, processes
+}
+
+func TestPluginPool_get(t *testing.T) {
+	pool, processes := newFakePluginPool(t, 2, protocolVersionSession)
+
+	// the idle process is used first, then the process is started.
+	proc1, err := pool.get()
+	assert.NoError(t, err)
+	proc2, err := pool.get()
+	assert.NoError(t, err)
+	assert.Len(t, *processes, 2)
+	assert.NotSame(t, proc1, proc2)
+
+	// the process with the fewest sessions is used if the pool is full.
+	pool.release(proc2)
+	proc3, err := pool.get()
+	assert.NoError(t, err)
+	assert.Same(t, proc2, proc3)
+	assert.Len(t, *processes, 2)
+
+	// the crashed process is restarted when a driver is opened on it.
+	proc1.client.(*fakePluginClient).exit()
+	proc4, err := pool.get()
+	assert.NoError(t, err)
+	assert.Len(t, *processes, 3)
+	assert.Same(t, (*processes)[2], proc4)
+	assert.True(t, proc1.client.(*fakePluginClient).isKilled())
+
+	// the process is killed when the pool is closed and its drivers are closed.
+	pool.close()
+	_, err = pool.get()
+	assert.Error(t, err)
+	assert.False(t, proc4.client.(*fakePluginClient).isKilled())
+	pool.release(proc4)
+	assert.True(t, proc4.client.(*fakePluginClient).isKilled())
+}
+
+func TestPluginPool_getWhileStarting(t *testing.T) {
+	pool, processes := newFakePluginPool(t, 2, protocolVersionSession)
+	proc1, err := pool.get()
+	assert.NoError(t, err)
+
+	// the process is started without holding the mutex of pool.
+	start, started := startPluginProcess, make(chan struct{})
+	startPluginProcess = func(path string) (*pluginProcess, error) {
+		<-started
+		return start(path)
+	}
+	done := make(chan *pluginProcess)
+	go func() {
+		proc, err := pool.get()
+		assert.NoError(t, err)
+		done <- proc
+	}()
+	for starting := false; !starting; time.Sleep(time.Millisecond) {
+		pool.mu.Lock()
+		starting = pool.starting[1]
+		pool.mu.Unlock()
+	}
+
+	pool.release(proc1)
+	proc3, err := pool.get()
+	assert.NoError(t, err)
+	assert.Same(t, proc1, proc3)
+
+	close(started)
+	proc2 := <-done
+	assert.Len(t, *processes, 2)
+	assert.Same(t, (*processes)[1], proc2)
+}
+
+func TestPluginPool_checkProcesses(t *testing.T) {
+	pool, processes := newFakePluginPool(t, 2, protocolVersionSession)
+	proc1, err := pool.get()
+	assert.NoError(t, err)
+	proc2, err := pool.get()
+	assert.NoError(t, err)
+
+	proc1.client.(*fakePluginClient).exit()
+	proc2.rpcClient.(*fakeRPCClient).pingErr = errors.New("not responding")
+	pool.checkProcesses()
+	assert.Len(t, *processes, 4)
+	assert.True(t, proc2.client.(*fakePluginClient).isKilled())
+	assert.Same(t, (*processes)[2], pool.processes[0])
+	assert.Same(t, (*processes)[3], pool.processes[1])
+
+	// the healthy process is not restarted.
+	pool.checkProcesses()
+	assert.Len(t, *processes, 4)
+}
+
+func TestPluginPool_legacy(t *testing.T) {
+	pool, processes := newFakePluginPool(t, 2, protocolVersionLegacy)
+	assert.True(t, pool.legacy)
+
+	// each driver runs on its own process, which is killed when it's released.
+	proc1, err := pool.get()
+	assert.NoError(t, err)
+	proc2, err := pool.get()
+	assert.NoError(t, err)
+	assert.Len(t, *processes, 3)
+	assert.NotSame(t, proc1, proc2)
+	pool.release(proc1)
+	assert.True(t, proc1.client.(*fakePluginClient).isKilled())
+	assert.False(t, proc2.client.(*fakePluginClient).isKilled())
+}
+
+func TestDriverPluginClient_reopen(t *testing.T) {
+	pool, processes := newFakePluginPool(t, 1, protocolVersionSession)
+	c := &driverPluginClient{
+		log:         log.NewEntry(),
+		pool:        pool,
+		initRequest: &proto.InitRequest{},
+	}
+	assert.NoError(t, c.open(context.TODO()))
+	proc := c.proc
+
+	// the session is opened again on the restarted process.
+	proc.client.(*fakePluginClient).exit()
+	assert.NoError(t, c.Ping(context.TODO()))
+	assert.Len(t, *processes, 2)
+	assert.Same(t, (*processes)[1], c.proc)
+	assert.Equal(t, 1, c.proc.srv.(*fakeDriverClient).inits)
+	assert.Equal(t, 0, proc.sessions)
+
+	c.Close(context.TODO())
+	assert.Nil(t, c.proc)
+	assert.Equal(t, 0, (*processes)[1].sessions)
+}
@@ -0,0 +1,155 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/log"
+
+	"github.com/sirupsen/logrus"
+)
+
+// plugin is a plugin binary loaded at plugins directory.
+type plugin struct {
+	// name is the driver name of plugin.
+	name string
+	path string
+
+	// modTime and size are the stamp of binary when it's loaded, the plugin is
+	// upgraded if they are changed.
+	modTime time.Time
+	size    int64
+
+	metas *Metas
+	pool  *pluginPool
+}
+
+func (p *plugin) changed(info os.FileInfo) bool {
+	return !p.modTime.Equal(info.ModTime()) || p.size != info.Size()
+}
+
+var (
+	pluginsMu        sync.Mutex
+	pluginsDir       string
+	pluginsProcesses int
+
+	// plugins are the loaded plugins, the key is path of binary.
+	plugins = map[string]*plugin{}
+	// failedPlugins are the binaries failed to load, the key is path of binary.
+	// They are not loaded again until they are changed.
+	failedPlugins = map[string]*plugin{}
+)
+
+// PluginReloadResult is the drivers changed by ReloadPlugins.
+type PluginReloadResult struct {
+	Loaded   []string
+	Upgraded []string
+	Unloaded []string
+	// Failed is the error of binaries failed to load, the key is path of binary.
+	Failed map[string]error
+
+	// Rules are the rules of loaded and upgraded drivers.
+	Rules map[string][]*Rule
+}
+
+// Changed returns true if any driver is changed or failed.
+func (r *PluginReloadResult) Changed() bool {
+	return len(r.Loaded) > 0 || len(r.Upgraded) > 0 || len(r.Unloaded) > 0 || len(r.Failed) > 0
+}
+
+// ReloadPlugins loads the plugins at plugins directory again. The new binary is
+// loaded, the changed binary is upgraded and the removed binary is unloaded. The
+// processes of upgraded or unloaded plugin are drained, the drivers opened on them
+// are kept until closed, but no driver is opened on them anymore.
+//
+// The binary which is failed to load is skipped until it's changed, unless
+// retryFailed is set.
+func ReloadPlugins(retryFailed bool) (*PluginReloadResult, error) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	result := &PluginReloadResult{
+		Failed: map[string]error{},
+		Rules:  map[string][]*Rule{},
+	}
+	if pluginsDir == "" {
+		return result, nil
+	}
+	files, err := scanPlugins(pluginsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for path, info := range files {
+		old, exist := plugins[path]
+		if exist && !old.changed(info) {
+			continue
+		}
+		if failed, ok := failedPlugins[path]; ok && !retryFailed && !failed.changed(info) {
+			continue
+		}
+		delete(failedPlugins, path)
+
+		p, rules, err := loadPlugin(path, info)
+		if err == nil && p.name != old.getName() && isDriverRegistered(p.name) {
+			p.pool.close()
+			err = fmt.Errorf("duplicated driver name %s", p.name)
+		}
+		if err != nil {
+			failedPlugins[path] = &plugin{path: path, modTime: info.ModTime(), size: info.Size()}
+			result.Failed[path] = err
+			continue
+		}
+
+		switch {
+		case !exist:
+			result.Loaded = append(result.Loaded, p.name)
+		case p.name == old.name:
+			result.Upgraded = append(result.Upgraded, p.name)
+			old.pool.close()
+		default:
+			Unregister(old.name)
+			old.pool.close()
+			result.Unloaded = append(result.Unloaded, old.name)
+			result.Loaded = append(result.Loaded, p.name)
+		}
+		p.register(rules)
+		plugins[path] = p
+		result.Rules[p.name] = rules
+	}
+
+	for path, p := range plugins {
+		if _, ok := files[path]; ok {
+			continue
+		}
+		Unregister(p.name)
+		p.pool.close()
+		delete(plugins, path)
+		result.Unloaded = append(result.Unloaded, p.name)
+	}
+	for path := range failedPlugins {
+		if _, ok := files[path]; !ok {
+			delete(failedPlugins, path)
+		}
+	}
+
+	if result.Changed() {
+		log.Logger().WithFields(logrus.Fields{
+			"loaded":   result.Loaded,
+			"upgraded": result.Upgraded,
+			"unloaded": result.Unloaded,
+			"failed":   result.Failed,
+		}).Infoln("plugins reloaded")
+	}
+	return result, nil
+}
+
+// getName returns the driver name of plugin, it's empty if the plugin is nil.
+func (p *plugin) getName() string {
+	if p == nil {
+		return ""
+	}
+	return p.name
+}
@@ -0,0 +1,64 @@
+package driver
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadPlugins(t *testing.T) {
+	processes := fakePluginProcesses(t, protocolVersionSession)
+	dir := t.TempDir()
+	path := dir + "/fake"
+	writeFakePlugin(t, path, "fake_reload:v1")
+	assert.NoError(t, InitPlugins(dir, 1))
+	t.Cleanup(func() {
+		ClosePlugins()
+		for _, p := range plugins {
+			Unregister(p.name)
+		}
+		pluginsDir, plugins, failedPlugins = "", map[string]*plugin{}, map[string]*plugin{}
+	})
+	assert.Equal(t, "v1", AllDriverMetas()["fake_reload"].Version)
+
+	d1, err := NewDriver(log.NewEntry(), "fake_reload", &Config{})
+	assert.NoError(t, err)
+	proc1 := (*processes)[0]
+	assert.Same(t, proc1, d1.(*driverPluginClient).proc)
+
+	// nothing is changed if the binary is not changed.
+	result, err := ReloadPlugins(false)
+	assert.NoError(t, err)
+	assert.False(t, result.Changed())
+
+	// the changed binary is upgraded, the driver opened is kept on the old process
+	// until it's closed, and the new driver is opened on the new process.
+	writeFakePlugin(t, path, "fake_reload:v2.0")
+	result, err = ReloadPlugins(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fake_reload"}, result.Upgraded)
+	assert.Equal(t, "v2.0", AllDriverMetas()["fake_reload"].Version)
+	assert.False(t, proc1.client.(*fakePluginClient).isKilled())
+
+	d2, err := NewDriver(log.NewEntry(), "fake_reload", &Config{})
+	assert.NoError(t, err)
+	assert.Len(t, *processes, 2)
+	assert.Same(t, (*processes)[1], d2.(*driverPluginClient).proc)
+	assert.NoError(t, d1.Ping(context.TODO()))
+	d1.Close(context.TODO())
+	assert.True(t, proc1.client.(*fakePluginClient).isKilled())
+
+	// the removed binary is unloaded.
+	assert.NoError(t, os.Remove(path))
+	result, err = ReloadPlugins(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fake_reload"}, result.Unloaded)
+	_, err = NewDriver(log.NewEntry(), "fake_reload", &Config{})
+	assert.Error(t, err)
+	d2.Close(context.TODO())
+	assert.True(t, (*processes)[1].client.(*fakePluginClient).isKilled())
+}
@@ -0,0 +1,18 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertAuditResult(t *testing.T) {
+	result := NewInspectResults()
+	result.AddWithRule(&Rule{Name: "rule1", Category: "category1", Level: RuleLevelWarn}, "message1")
+	result.AddItem(&AuditResultItem{Level: RuleLevelNotice, Message: "message2", Prefix: "[osc]"})
+
+	// the findings are kept by the plugin protocol.
+	ret := convertAuditResponse(convertAuditResult(result))
+	assert.Equal(t, result.Items(), ret.Items())
+	assert.Equal(t, "[warn]message1\n[osc]message2", ret.Message())
+}
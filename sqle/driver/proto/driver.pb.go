@@ -12,6 +12,9 @@ It has these top-level messages:
 	Rule
 	InitRequest
 	Empty
+	Session
+	InitResponse
+	SessionRequest
 	ExecRequest
 	ExecResponse
 	TxRequest
@@ -23,6 +26,8 @@ It has these top-level messages:
 	AuditRequest
 	AuditResult
 	AuditResponse
+	AuditBatchRequest
+	AuditBatchResponse
 	GenRollbackSQLRequest
 	GenRollbackSQLResponse
 	MetasResponse
@@ -177,14 +182,63 @@ func (m *Empty) String() string            { return proto1.CompactTextString(m)
 func (*Empty) ProtoMessage()               {}
 func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
+type Session struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *Session) Reset()                    { *m = Session{} }
+func (m *Session) String() string            { return proto1.CompactTextString(m) }
+func (*Session) ProtoMessage()               {}
+func (*Session) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *Session) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type InitResponse struct {
+	Session *Session `protobuf:"bytes,1,opt,name=session" json:"session,omitempty"`
+}
+
+func (m *InitResponse) Reset()                    { *m = InitResponse{} }
+func (m *InitResponse) String() string            { return proto1.CompactTextString(m) }
+func (*InitResponse) ProtoMessage()               {}
+func (*InitResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *InitResponse) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+type SessionRequest struct {
+	Session *Session `protobuf:"bytes,1,opt,name=session" json:"session,omitempty"`
+}
+
+func (m *SessionRequest) Reset()                    { *m = SessionRequest{} }
+func (m *SessionRequest) String() string            { return proto1.CompactTextString(m) }
+func (*SessionRequest) ProtoMessage()               {}
+func (*SessionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *SessionRequest) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
 type ExecRequest struct {
-	Query string `protobuf:"bytes,1,opt,name=query" json:"query,omitempty"`
+	Query   string   `protobuf:"bytes,1,opt,name=query" json:"query,omitempty"`
+	Session *Session `protobuf:"bytes,2,opt,name=session" json:"session,omitempty"`
 }
 
 func (m *ExecRequest) Reset()                    { *m = ExecRequest{} }
 func (m *ExecRequest) String() string            { return proto1.CompactTextString(m) }
 func (*ExecRequest) ProtoMessage()               {}
-func (*ExecRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*ExecRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 func (m *ExecRequest) GetQuery() string {
 	if m != nil {
@@ -193,6 +247,13 @@ func (m *ExecRequest) GetQuery() string {
 	return ""
 }
 
+func (m *ExecRequest) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
 type ExecResponse struct {
 	LastInsertId      int64  `protobuf:"varint,1,opt,name=lastInsertId" json:"lastInsertId,omitempty"`
 	LastInsertIdError string `protobuf:"bytes,2,opt,name=lastInsertIdError" json:"lastInsertIdError,omitempty"`
@@ -203,7 +264,7 @@ type ExecResponse struct {
 func (m *ExecResponse) Reset()                    { *m = ExecResponse{} }
 func (m *ExecResponse) String() string            { return proto1.CompactTextString(m) }
 func (*ExecResponse) ProtoMessage()               {}
-func (*ExecResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*ExecResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 func (m *ExecResponse) GetLastInsertId() int64 {
 	if m != nil {
@@ -235,12 +296,13 @@ func (m *ExecResponse) GetRowsAffectedError() string {
 
 type TxRequest struct {
 	Queries []string `protobuf:"bytes,1,rep,name=queries" json:"queries,omitempty"`
+	Session *Session `protobuf:"bytes,2,opt,name=session" json:"session,omitempty"`
 }
 
 func (m *TxRequest) Reset()                    { *m = TxRequest{} }
 func (m *TxRequest) String() string            { return proto1.CompactTextString(m) }
 func (*TxRequest) ProtoMessage()               {}
-func (*TxRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*TxRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
 func (m *TxRequest) GetQueries() []string {
 	if m != nil {
@@ -249,6 +311,13 @@ func (m *TxRequest) GetQueries() []string {
 	return nil
 }
 
+func (m *TxRequest) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
 type TxResponse struct {
 	Resluts []*ExecResponse `protobuf:"bytes,1,rep,name=resluts" json:"resluts,omitempty"`
 }
@@ -256,7 +325,7 @@ type TxResponse struct {
 func (m *TxResponse) Reset()                    { *m = TxResponse{} }
 func (m *TxResponse) String() string            { return proto1.CompactTextString(m) }
 func (*TxResponse) ProtoMessage()               {}
-func (*TxResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*TxResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
 func (m *TxResponse) GetResluts() []*ExecResponse {
 	if m != nil {
@@ -272,7 +341,7 @@ type DatabasesResponse struct {
 func (m *DatabasesResponse) Reset()                    { *m = DatabasesResponse{} }
 func (m *DatabasesResponse) String() string            { return proto1.CompactTextString(m) }
 func (*DatabasesResponse) ProtoMessage()               {}
-func (*DatabasesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*DatabasesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
 
 func (m *DatabasesResponse) GetDatabases() []string {
 	if m != nil {
@@ -282,13 +351,14 @@ func (m *DatabasesResponse) GetDatabases() []string {
 }
 
 type ParseRequest struct {
-	SqlText string `protobuf:"bytes,1,opt,name=sqlText" json:"sqlText,omitempty"`
+	SqlText string   `protobuf:"bytes,1,opt,name=sqlText" json:"sqlText,omitempty"`
+	Session *Session `protobuf:"bytes,2,opt,name=session" json:"session,omitempty"`
 }
 
 func (m *ParseRequest) Reset()                    { *m = ParseRequest{} }
 func (m *ParseRequest) String() string            { return proto1.CompactTextString(m) }
 func (*ParseRequest) ProtoMessage()               {}
-func (*ParseRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (*ParseRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
 func (m *ParseRequest) GetSqlText() string {
 	if m != nil {
@@ -297,6 +367,13 @@ func (m *ParseRequest) GetSqlText() string {
 	return ""
 }
 
+func (m *ParseRequest) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
 type Node struct {
 	Text        string `protobuf:"bytes,1,opt,name=text" json:"text,omitempty"`
 	Type        string `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
@@ -306,7 +383,7 @@ type Node struct {
 func (m *Node) Reset()                    { *m = Node{} }
 func (m *Node) String() string            { return proto1.CompactTextString(m) }
 func (*Node) ProtoMessage()               {}
-func (*Node) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*Node) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
 func (m *Node) GetText() string {
 	if m != nil {
@@ -336,7 +413,7 @@ type ParseResponse struct {
 func (m *ParseResponse) Reset()                    { *m = ParseResponse{} }
 func (m *ParseResponse) String() string            { return proto1.CompactTextString(m) }
 func (*ParseResponse) ProtoMessage()               {}
-func (*ParseResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*ParseResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
 func (m *ParseResponse) GetNodes() []*Node {
 	if m != nil {
@@ -346,13 +423,14 @@ func (m *ParseResponse) GetNodes() []*Node {
 }
 
 type AuditRequest struct {
-	Sql string `protobuf:"bytes,1,opt,name=sql" json:"sql,omitempty"`
+	Sql     string   `protobuf:"bytes,1,opt,name=sql" json:"sql,omitempty"`
+	Session *Session `protobuf:"bytes,2,opt,name=session" json:"session,omitempty"`
 }
 
 func (m *AuditRequest) Reset()                    { *m = AuditRequest{} }
 func (m *AuditRequest) String() string            { return proto1.CompactTextString(m) }
 func (*AuditRequest) ProtoMessage()               {}
-func (*AuditRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*AuditRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
 func (m *AuditRequest) GetSql() string {
 	if m != nil {
@@ -361,15 +439,26 @@ func (m *AuditRequest) GetSql() string {
 	return ""
 }
 
+func (m *AuditRequest) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
 type AuditResult struct {
-	Message string `protobuf:"bytes,1,opt,name=message" json:"message,omitempty"`
-	Level   string `protobuf:"bytes,2,opt,name=level" json:"level,omitempty"`
+	Message  string `protobuf:"bytes,1,opt,name=message" json:"message,omitempty"`
+	Level    string `protobuf:"bytes,2,opt,name=level" json:"level,omitempty"`
+	RuleName string `protobuf:"bytes,3,opt,name=rule_name" json:"rule_name,omitempty"`
+	Category string `protobuf:"bytes,4,opt,name=category" json:"category,omitempty"`
+	// prefix replaces the level prefix of message, e.g. "[osc]".
+	Prefix string `protobuf:"bytes,5,opt,name=prefix" json:"prefix,omitempty"`
 }
 
 func (m *AuditResult) Reset()                    { *m = AuditResult{} }
 func (m *AuditResult) String() string            { return proto1.CompactTextString(m) }
 func (*AuditResult) ProtoMessage()               {}
-func (*AuditResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (*AuditResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
 func (m *AuditResult) GetMessage() string {
 	if m != nil {
@@ -385,6 +474,27 @@ func (m *AuditResult) GetLevel() string {
 	return ""
 }
 
+func (m *AuditResult) GetRuleName() string {
+	if m != nil {
+		return m.RuleName
+	}
+	return ""
+}
+
+func (m *AuditResult) GetCategory() string {
+	if m != nil {
+		return m.Category
+	}
+	return ""
+}
+
+func (m *AuditResult) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
 type AuditResponse struct {
 	Results []*AuditResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
 }
@@ -392,7 +502,7 @@ type AuditResponse struct {
 func (m *AuditResponse) Reset()                    { *m = AuditResponse{} }
 func (m *AuditResponse) String() string            { return proto1.CompactTextString(m) }
 func (*AuditResponse) ProtoMessage()               {}
-func (*AuditResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (*AuditResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
 
 func (m *AuditResponse) GetResults() []*AuditResult {
 	if m != nil {
@@ -401,14 +511,56 @@ func (m *AuditResponse) GetResults() []*AuditResult {
 	return nil
 }
 
+type AuditBatchRequest struct {
+	Sqls    []string `protobuf:"bytes,1,rep,name=sqls" json:"sqls,omitempty"`
+	Session *Session `protobuf:"bytes,2,opt,name=session" json:"session,omitempty"`
+}
+
+func (m *AuditBatchRequest) Reset()                    { *m = AuditBatchRequest{} }
+func (m *AuditBatchRequest) String() string            { return proto1.CompactTextString(m) }
+func (*AuditBatchRequest) ProtoMessage()               {}
+func (*AuditBatchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *AuditBatchRequest) GetSqls() []string {
+	if m != nil {
+		return m.Sqls
+	}
+	return nil
+}
+
+func (m *AuditBatchRequest) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+type AuditBatchResponse struct {
+	// responses are in the same order as sqls in request.
+	Responses []*AuditResponse `protobuf:"bytes,1,rep,name=responses" json:"responses,omitempty"`
+}
+
+func (m *AuditBatchResponse) Reset()                    { *m = AuditBatchResponse{} }
+func (m *AuditBatchResponse) String() string            { return proto1.CompactTextString(m) }
+func (*AuditBatchResponse) ProtoMessage()               {}
+func (*AuditBatchResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *AuditBatchResponse) GetResponses() []*AuditResponse {
+	if m != nil {
+		return m.Responses
+	}
+	return nil
+}
+
 type GenRollbackSQLRequest struct {
-	Sql string `protobuf:"bytes,1,opt,name=sql" json:"sql,omitempty"`
+	Sql     string   `protobuf:"bytes,1,opt,name=sql" json:"sql,omitempty"`
+	Session *Session `protobuf:"bytes,2,opt,name=session" json:"session,omitempty"`
 }
 
 func (m *GenRollbackSQLRequest) Reset()                    { *m = GenRollbackSQLRequest{} }
 func (m *GenRollbackSQLRequest) String() string            { return proto1.CompactTextString(m) }
 func (*GenRollbackSQLRequest) ProtoMessage()               {}
-func (*GenRollbackSQLRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (*GenRollbackSQLRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
 
 func (m *GenRollbackSQLRequest) GetSql() string {
 	if m != nil {
@@ -417,6 +569,13 @@ func (m *GenRollbackSQLRequest) GetSql() string {
 	return ""
 }
 
+func (m *GenRollbackSQLRequest) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
 type GenRollbackSQLResponse struct {
 	Sql    string `protobuf:"bytes,1,opt,name=sql" json:"sql,omitempty"`
 	Reason string `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
@@ -425,7 +584,7 @@ type GenRollbackSQLResponse struct {
 func (m *GenRollbackSQLResponse) Reset()                    { *m = GenRollbackSQLResponse{} }
 func (m *GenRollbackSQLResponse) String() string            { return proto1.CompactTextString(m) }
 func (*GenRollbackSQLResponse) ProtoMessage()               {}
-func (*GenRollbackSQLResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (*GenRollbackSQLResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
 
 func (m *GenRollbackSQLResponse) GetSql() string {
 	if m != nil {
@@ -444,12 +603,19 @@ func (m *GenRollbackSQLResponse) GetReason() string {
 type MetasResponse struct {
 	Name  string  `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
 	Rules []*Rule `protobuf:"bytes,2,rep,name=rules" json:"rules,omitempty"`
+	// version is the version of plugin.
+	Version string `protobuf:"bytes,3,opt,name=version" json:"version,omitempty"`
+	// protocol_versions are the versions of plugin protocol which the plugin
+	// supports, it's empty if the plugin is built before capability.
+	ProtocolVersions []int32 `protobuf:"varint,4,rep,name=protocol_versions" json:"protocol_versions,omitempty"`
+	// capabilities are the features which the plugin supports, see Capability.
+	Capabilities []string `protobuf:"bytes,5,rep,name=capabilities" json:"capabilities,omitempty"`
 }
 
 func (m *MetasResponse) Reset()                    { *m = MetasResponse{} }
 func (m *MetasResponse) String() string            { return proto1.CompactTextString(m) }
 func (*MetasResponse) ProtoMessage()               {}
-func (*MetasResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+func (*MetasResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
 
 func (m *MetasResponse) GetName() string {
 	if m != nil {
@@ -465,11 +631,35 @@ func (m *MetasResponse) GetRules() []*Rule {
 	return nil
 }
 
+func (m *MetasResponse) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *MetasResponse) GetProtocolVersions() []int32 {
+	if m != nil {
+		return m.ProtocolVersions
+	}
+	return nil
+}
+
+func (m *MetasResponse) GetCapabilities() []string {
+	if m != nil {
+		return m.Capabilities
+	}
+	return nil
+}
+
 func init() {
 	proto1.RegisterType((*DSN)(nil), "proto.DSN")
 	proto1.RegisterType((*Rule)(nil), "proto.Rule")
 	proto1.RegisterType((*InitRequest)(nil), "proto.InitRequest")
 	proto1.RegisterType((*Empty)(nil), "proto.Empty")
+	proto1.RegisterType((*Session)(nil), "proto.Session")
+	proto1.RegisterType((*InitResponse)(nil), "proto.InitResponse")
+	proto1.RegisterType((*SessionRequest)(nil), "proto.SessionRequest")
 	proto1.RegisterType((*ExecRequest)(nil), "proto.ExecRequest")
 	proto1.RegisterType((*ExecResponse)(nil), "proto.ExecResponse")
 	proto1.RegisterType((*TxRequest)(nil), "proto.TxRequest")
@@ -481,6 +671,8 @@ func init() {
 	proto1.RegisterType((*AuditRequest)(nil), "proto.AuditRequest")
 	proto1.RegisterType((*AuditResult)(nil), "proto.AuditResult")
 	proto1.RegisterType((*AuditResponse)(nil), "proto.AuditResponse")
+	proto1.RegisterType((*AuditBatchRequest)(nil), "proto.AuditBatchRequest")
+	proto1.RegisterType((*AuditBatchResponse)(nil), "proto.AuditBatchResponse")
 	proto1.RegisterType((*GenRollbackSQLRequest)(nil), "proto.GenRollbackSQLRequest")
 	proto1.RegisterType((*GenRollbackSQLResponse)(nil), "proto.GenRollbackSQLResponse")
 	proto1.RegisterType((*MetasResponse)(nil), "proto.MetasResponse")
@@ -503,14 +695,22 @@ type DriverClient interface {
 	// It will pass some necessary info to plugin server. In the begginning,
 	// we consider that put this info to the executable binary environment.
 	// We put all communication on gRPC for unification in the end.
-	Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (*Empty, error)
-	Close(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
-	Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	//
+	// Init opens a session with the DSN and rules, the following methods are
+	// called with the session, so one plugin process serves many drivers. The
+	// plugins built before session return no session, and serve one driver.
+	Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (*InitResponse, error)
+	Close(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error)
+	Ping(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error)
 	Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (*ExecResponse, error)
 	Tx(ctx context.Context, in *TxRequest, opts ...grpc.CallOption) (*TxResponse, error)
-	Databases(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*DatabasesResponse, error)
+	Databases(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*DatabasesResponse, error)
 	Parse(ctx context.Context, in *ParseRequest, opts ...grpc.CallOption) (*ParseResponse, error)
 	Audit(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (*AuditResponse, error)
+	// AuditBatch audits the SQLs in order in one call, it saves the round-trips of
+	// Audit for large task. The plugins built before it return Unimplemented, the
+	// caller should fall back to Audit.
+	AuditBatch(ctx context.Context, in *AuditBatchRequest, opts ...grpc.CallOption) (*AuditBatchResponse, error)
 	GenRollbackSQL(ctx context.Context, in *GenRollbackSQLRequest, opts ...grpc.CallOption) (*GenRollbackSQLResponse, error)
 }
 
@@ -531,8 +731,8 @@ func (c *driverClient) Metas(ctx context.Context, in *Empty, opts ...grpc.CallOp
 	return out, nil
 }
 
-func (c *driverClient) Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
+func (c *driverClient) Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (*InitResponse, error) {
+	out := new(InitResponse)
 	err := grpc.Invoke(ctx, "/proto.Driver/Init", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
@@ -540,7 +740,7 @@ func (c *driverClient) Init(ctx context.Context, in *InitRequest, opts ...grpc.C
 	return out, nil
 }
 
-func (c *driverClient) Close(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+func (c *driverClient) Close(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
 	err := grpc.Invoke(ctx, "/proto.Driver/Close", in, out, c.cc, opts...)
 	if err != nil {
@@ -549,7 +749,7 @@ func (c *driverClient) Close(ctx context.Context, in *Empty, opts ...grpc.CallOp
 	return out, nil
 }
 
-func (c *driverClient) Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+func (c *driverClient) Ping(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
 	err := grpc.Invoke(ctx, "/proto.Driver/Ping", in, out, c.cc, opts...)
 	if err != nil {
@@ -576,7 +776,7 @@ func (c *driverClient) Tx(ctx context.Context, in *TxRequest, opts ...grpc.CallO
 	return out, nil
 }
 
-func (c *driverClient) Databases(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*DatabasesResponse, error) {
+func (c *driverClient) Databases(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*DatabasesResponse, error) {
 	out := new(DatabasesResponse)
 	err := grpc.Invoke(ctx, "/proto.Driver/Databases", in, out, c.cc, opts...)
 	if err != nil {
@@ -603,6 +803,15 @@ func (c *driverClient) Audit(ctx context.Context, in *AuditRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *driverClient) AuditBatch(ctx context.Context, in *AuditBatchRequest, opts ...grpc.CallOption) (*AuditBatchResponse, error) {
+	out := new(AuditBatchResponse)
+	err := grpc.Invoke(ctx, "/proto.Driver/AuditBatch", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *driverClient) GenRollbackSQL(ctx context.Context, in *GenRollbackSQLRequest, opts ...grpc.CallOption) (*GenRollbackSQLResponse, error) {
 	out := new(GenRollbackSQLResponse)
 	err := grpc.Invoke(ctx, "/proto.Driver/GenRollbackSQL", in, out, c.cc, opts...)
@@ -621,14 +830,22 @@ type DriverServer interface {
 	// It will pass some necessary info to plugin server. In the begginning,
 	// we consider that put this info to the executable binary environment.
 	// We put all communication on gRPC for unification in the end.
-	Init(context.Context, *InitRequest) (*Empty, error)
-	Close(context.Context, *Empty) (*Empty, error)
-	Ping(context.Context, *Empty) (*Empty, error)
+	//
+	// Init opens a session with the DSN and rules, the following methods are
+	// called with the session, so one plugin process serves many drivers. The
+	// plugins built before session return no session, and serve one driver.
+	Init(context.Context, *InitRequest) (*InitResponse, error)
+	Close(context.Context, *SessionRequest) (*Empty, error)
+	Ping(context.Context, *SessionRequest) (*Empty, error)
 	Exec(context.Context, *ExecRequest) (*ExecResponse, error)
 	Tx(context.Context, *TxRequest) (*TxResponse, error)
-	Databases(context.Context, *Empty) (*DatabasesResponse, error)
+	Databases(context.Context, *SessionRequest) (*DatabasesResponse, error)
 	Parse(context.Context, *ParseRequest) (*ParseResponse, error)
 	Audit(context.Context, *AuditRequest) (*AuditResponse, error)
+	// AuditBatch audits the SQLs in order in one call, it saves the round-trips of
+	// Audit for large task. The plugins built before it return Unimplemented, the
+	// caller should fall back to Audit.
+	AuditBatch(context.Context, *AuditBatchRequest) (*AuditBatchResponse, error)
 	GenRollbackSQL(context.Context, *GenRollbackSQLRequest) (*GenRollbackSQLResponse, error)
 }
 
@@ -673,7 +890,7 @@ func _Driver_Init_Handler(srv interface{}, ctx context.Context, dec func(interfa
 }
 
 func _Driver_Close_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+	in := new(SessionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -685,13 +902,13 @@ func _Driver_Close_Handler(srv interface{}, ctx context.Context, dec func(interf
 		FullMethod: "/proto.Driver/Close",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DriverServer).Close(ctx, req.(*Empty))
+		return srv.(DriverServer).Close(ctx, req.(*SessionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
 func _Driver_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+	in := new(SessionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -703,7 +920,7 @@ func _Driver_Ping_Handler(srv interface{}, ctx context.Context, dec func(interfa
 		FullMethod: "/proto.Driver/Ping",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DriverServer).Ping(ctx, req.(*Empty))
+		return srv.(DriverServer).Ping(ctx, req.(*SessionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -745,7 +962,7 @@ func _Driver_Tx_Handler(srv interface{}, ctx context.Context, dec func(interface
 }
 
 func _Driver_Databases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+	in := new(SessionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -757,7 +974,7 @@ func _Driver_Databases_Handler(srv interface{}, ctx context.Context, dec func(in
 		FullMethod: "/proto.Driver/Databases",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DriverServer).Databases(ctx, req.(*Empty))
+		return srv.(DriverServer).Databases(ctx, req.(*SessionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -798,6 +1015,24 @@ func _Driver_Audit_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Driver_AuditBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuditBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServer).AuditBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Driver/AuditBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServer).AuditBatch(ctx, req.(*AuditBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Driver_GenRollbackSQL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GenRollbackSQLRequest)
 	if err := dec(in); err != nil {
@@ -856,6 +1091,10 @@ var _Driver_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Audit",
 			Handler:    _Driver_Audit_Handler,
 		},
+		{
+			MethodName: "AuditBatch",
+			Handler:    _Driver_AuditBatch_Handler,
+		},
 		{
 			MethodName: "GenRollbackSQL",
 			Handler:    _Driver_GenRollbackSQL_Handler,
@@ -868,51 +1107,65 @@ var _Driver_serviceDesc = grpc.ServiceDesc{
 func init() { proto1.RegisterFile("driver.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 735 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0xdd, 0x4e, 0xdb, 0x4a,
-	0x10, 0x56, 0xe2, 0x18, 0xc8, 0x38, 0x1c, 0xc1, 0x1e, 0x0e, 0xb2, 0x22, 0x8e, 0x14, 0x16, 0x55,
-	0x0a, 0x2a, 0x05, 0x35, 0x5c, 0x22, 0x2e, 0xa0, 0xa1, 0x15, 0x52, 0x89, 0xa8, 0xe1, 0xaa, 0x77,
-	0x26, 0x1e, 0xd2, 0xa8, 0xc6, 0x9b, 0xec, 0xae, 0x43, 0xf2, 0x5a, 0x7d, 0xb2, 0x3e, 0x42, 0xb5,
-	0x7f, 0x8e, 0x4d, 0xa0, 0x57, 0x9e, 0x9f, 0x6f, 0xe7, 0x9b, 0x1d, 0x7f, 0xb3, 0xd0, 0x4a, 0xf8,
-	0x78, 0x86, 0xfc, 0x78, 0xc2, 0x99, 0x64, 0xc4, 0xd7, 0x1f, 0xba, 0x00, 0xaf, 0x7f, 0x37, 0x20,
-	0x04, 0x1a, 0x3f, 0x98, 0x90, 0x61, 0xad, 0x53, 0xeb, 0x36, 0x23, 0x6d, 0xab, 0xd8, 0x84, 0x71,
-	0x19, 0xd6, 0x4d, 0x4c, 0xd9, 0x2a, 0x96, 0x0b, 0xe4, 0xa1, 0x67, 0x62, 0xca, 0x26, 0x6d, 0xd8,
-	0x98, 0xc4, 0x42, 0x3c, 0x33, 0x9e, 0x84, 0x0d, 0x1d, 0x2f, 0x7c, 0x95, 0x4b, 0x62, 0x19, 0x3f,
-	0xc4, 0x02, 0x43, 0xdf, 0xe4, 0x9c, 0x4f, 0x67, 0xd0, 0x88, 0xf2, 0x14, 0x55, 0xcd, 0x2c, 0x7e,
-	0x42, 0xc7, 0xad, 0x6c, 0x15, 0x4b, 0x50, 0x0c, 0x1d, 0xb7, 0xb2, 0xc9, 0x0e, 0xf8, 0xb3, 0x38,
-	0xcd, 0xd1, 0x92, 0x1b, 0x47, 0x45, 0x53, 0x9c, 0x61, 0x6a, 0xa9, 0x8d, 0xa3, 0x78, 0x87, 0xb1,
-	0xc4, 0x11, 0xe3, 0x0b, 0xc7, 0xeb, 0x7c, 0x3a, 0x80, 0xe0, 0x3a, 0x1b, 0xcb, 0x08, 0xa7, 0x39,
-	0x0a, 0x49, 0xf6, 0xc0, 0x4b, 0x44, 0xa6, 0xd9, 0x83, 0x1e, 0x98, 0xe9, 0x1c, 0xf7, 0xef, 0x06,
-	0x91, 0x0a, 0x93, 0x7d, 0xf0, 0x79, 0x9e, 0xa2, 0x08, 0xbd, 0x8e, 0xd7, 0x0d, 0x7a, 0x81, 0xcd,
-	0xab, 0xc6, 0x23, 0x93, 0xa1, 0xeb, 0xe0, 0x5f, 0x3d, 0x4d, 0xe4, 0x82, 0x1e, 0x40, 0x70, 0x35,
-	0xc7, 0xa1, 0x2b, 0xbc, 0x03, 0xfe, 0x34, 0x47, 0xbe, 0xb0, 0x17, 0x33, 0x0e, 0xfd, 0x55, 0x83,
-	0x96, 0x41, 0x89, 0x09, 0xcb, 0x04, 0x12, 0x0a, 0xad, 0x34, 0x16, 0xf2, 0x3a, 0x13, 0xc8, 0xe5,
-	0x75, 0xa2, 0xd1, 0x5e, 0x54, 0x89, 0x91, 0x23, 0xd8, 0x2e, 0xfb, 0x57, 0x9c, 0x33, 0x6e, 0x67,
-	0xb3, 0x9a, 0x50, 0x15, 0x39, 0x7b, 0x16, 0x17, 0x8f, 0x8f, 0x38, 0x94, 0x98, 0xe8, 0x79, 0x79,
-	0x51, 0x25, 0xa6, 0x2a, 0x96, 0x7d, 0x53, 0xd1, 0x8c, 0x70, 0x35, 0x41, 0xdf, 0x41, 0xf3, 0x7e,
-	0xee, 0xee, 0x15, 0xc2, 0xba, 0xba, 0xca, 0x18, 0x45, 0x58, 0xeb, 0x78, 0xdd, 0x66, 0xe4, 0x5c,
-	0x7a, 0x06, 0xa0, 0x60, 0xf6, 0x62, 0x1f, 0x60, 0x9d, 0xa3, 0x48, 0x73, 0x69, 0x70, 0x41, 0xef,
-	0x5f, 0x3b, 0xbc, 0xf2, 0xf5, 0x23, 0x87, 0xa1, 0x1f, 0x61, 0xbb, 0x6f, 0xa5, 0x21, 0x8a, 0x1a,
-	0x7b, 0xd0, 0x74, 0x7a, 0x71, 0x6c, 0xcb, 0x00, 0xed, 0x42, 0xeb, 0x36, 0xe6, 0x02, 0x4b, 0x9d,
-	0x89, 0x69, 0x7a, 0x8f, 0x73, 0x27, 0x64, 0xe7, 0xd2, 0x5b, 0x68, 0x0c, 0x58, 0xa2, 0x75, 0x25,
-	0x97, 0x69, 0x6d, 0xeb, 0xd8, 0x62, 0x82, 0x4e, 0x6b, 0xca, 0x26, 0x1d, 0x08, 0x1e, 0xc7, 0xd9,
-	0x08, 0xf9, 0x84, 0x8f, 0x33, 0x69, 0x15, 0x57, 0x0e, 0xd1, 0x1e, 0x6c, 0x5a, 0x6e, 0xdb, 0xea,
-	0x3e, 0xf8, 0x19, 0x4b, 0xd0, 0x5d, 0xd6, 0x29, 0x45, 0xd1, 0x46, 0x26, 0x43, 0x3b, 0xd0, 0xba,
-	0xc8, 0x93, 0xa5, 0xf4, 0xb6, 0xc0, 0x13, 0xd3, 0xd4, 0x36, 0xa3, 0x4c, 0x7a, 0x0e, 0x81, 0x45,
-	0x88, 0x3c, 0xd5, 0x17, 0x7a, 0x42, 0x21, 0xe2, 0x91, 0xdb, 0x0e, 0xe7, 0x2e, 0x65, 0x5f, 0x2f,
-	0xc9, 0x9e, 0x9e, 0xc3, 0xa6, 0x3b, 0x6e, 0x9a, 0x3a, 0xd2, 0xff, 0x20, 0x4f, 0x8b, 0x7f, 0x40,
-	0x6c, 0x5b, 0x25, 0x96, 0xc8, 0x41, 0xe8, 0x21, 0xfc, 0xf7, 0x05, 0xb3, 0x88, 0xa5, 0xe9, 0x43,
-	0x3c, 0xfc, 0x79, 0xf7, 0xed, 0xeb, 0xdb, 0x8d, 0x5e, 0xc2, 0xee, 0x4b, 0xa8, 0xa5, 0x5c, 0xc1,
-	0x92, 0x5d, 0x58, 0xe3, 0x18, 0x0b, 0x96, 0xd9, 0x66, 0xad, 0x47, 0x3f, 0xc3, 0xe6, 0x0d, 0xca,
-	0x78, 0xf9, 0xb7, 0x5f, 0x7b, 0x09, 0x8a, 0x05, 0xac, 0xbf, 0xb5, 0x80, 0xbd, 0xdf, 0x1e, 0xac,
-	0xf5, 0xf5, 0xdb, 0x46, 0xde, 0x83, 0xaf, 0x4b, 0x92, 0x96, 0xd3, 0x9a, 0xda, 0xcc, 0xf6, 0x8e,
-	0xf5, 0xaa, 0x74, 0x5d, 0x68, 0xa8, 0x87, 0x80, 0xb8, 0x99, 0x94, 0x5e, 0x85, 0x76, 0xe5, 0x3c,
-	0x39, 0x00, 0xff, 0x53, 0xca, 0x04, 0xbe, 0x28, 0x5b, 0x05, 0x51, 0x68, 0xdc, 0x8e, 0xb3, 0xd1,
-	0x5f, 0x31, 0x27, 0xd0, 0x50, 0xea, 0x2f, 0x28, 0x4b, 0xef, 0x45, 0xfb, 0xb5, 0xf5, 0x20, 0x87,
-	0x50, 0xbf, 0x9f, 0x93, 0x2d, 0x9b, 0x2a, 0x96, 0xb0, 0xbd, 0x5d, 0x8a, 0x58, 0xe8, 0x29, 0x34,
-	0x8b, 0x05, 0x7a, 0xd1, 0x44, 0xe8, 0x9e, 0xb5, 0x95, 0x05, 0xeb, 0x81, 0xaf, 0x65, 0x4c, 0x1c,
-	0x7b, 0x79, 0xa1, 0x8a, 0xb9, 0x55, 0x95, 0xde, 0x03, 0x5f, 0xcb, 0xa7, 0x38, 0x53, 0x16, 0x75,
-	0x71, 0xa6, 0x2a, 0xc4, 0x1b, 0xf8, 0xa7, 0xaa, 0x17, 0xb2, 0x67, 0x71, 0xaf, 0x2a, 0xae, 0xfd,
-	0xff, 0x1b, 0x59, 0x53, 0xee, 0x12, 0xbe, 0x6f, 0x1c, 0x9f, 0x9c, 0x69, 0xc8, 0xc3, 0x9a, 0xfe,
-	0x9c, 0xfe, 0x09, 0x00, 0x00, 0xff, 0xff, 0x87, 0xce, 0xf0, 0xb0, 0xe0, 0x06, 0x00, 0x00,
+	// 949 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0x5f, 0x4f, 0xe3, 0x46,
+	0x10, 0x57, 0x62, 0x1b, 0xc8, 0x24, 0x20, 0xb2, 0xc7, 0x21, 0x9f, 0x4b, 0xa5, 0x9c, 0x9f, 0x52,
+	0x71, 0x05, 0x35, 0x7d, 0xa9, 0x7a, 0xed, 0x03, 0x14, 0xd4, 0x52, 0x95, 0x94, 0x73, 0x50, 0x1f,
+	0xfa, 0x72, 0x32, 0xf1, 0xc0, 0x59, 0x35, 0xb6, 0xb3, 0xbb, 0xce, 0x25, 0x5f, 0xa2, 0xdf, 0xa5,
+	0xfd, 0x2e, 0xfd, 0x3e, 0xd5, 0xfe, 0x73, 0xd6, 0x10, 0xaa, 0x43, 0xea, 0x93, 0x67, 0x7e, 0x33,
+	0x9e, 0x7f, 0xbb, 0xf3, 0x5b, 0xe8, 0x25, 0x34, 0x9d, 0x23, 0x3d, 0x2a, 0x69, 0xc1, 0x0b, 0xe2,
+	0xc9, 0x4f, 0xb8, 0x04, 0xe7, 0x6c, 0x32, 0x26, 0x04, 0xdc, 0x0f, 0x05, 0xe3, 0x7e, 0x6b, 0xd0,
+	0x1a, 0x76, 0x22, 0x29, 0x0b, 0xac, 0x2c, 0x28, 0xf7, 0xdb, 0x0a, 0x13, 0xb2, 0xc0, 0x2a, 0x86,
+	0xd4, 0x77, 0x14, 0x26, 0x64, 0x12, 0xc0, 0x56, 0x19, 0x33, 0xf6, 0xb1, 0xa0, 0x89, 0xef, 0x4a,
+	0xbc, 0xd6, 0x85, 0x2d, 0x89, 0x79, 0x7c, 0x13, 0x33, 0xf4, 0x3d, 0x65, 0x33, 0x7a, 0x38, 0x07,
+	0x37, 0xaa, 0x32, 0x14, 0x31, 0xf3, 0xf8, 0x1e, 0x4d, 0x6e, 0x21, 0x0b, 0x2c, 0x41, 0x36, 0x35,
+	0xb9, 0x85, 0x4c, 0xf6, 0xc0, 0x9b, 0xc7, 0x59, 0x85, 0x3a, 0xb9, 0x52, 0x04, 0x9a, 0xe1, 0x1c,
+	0x33, 0x9d, 0x5a, 0x29, 0x22, 0xef, 0x34, 0xe6, 0x78, 0x57, 0xd0, 0xa5, 0xc9, 0x6b, 0xf4, 0x70,
+	0x0c, 0xdd, 0x8b, 0x3c, 0xe5, 0x11, 0xce, 0x2a, 0x64, 0x9c, 0x1c, 0x80, 0x93, 0xb0, 0x5c, 0x66,
+	0xef, 0x8e, 0x40, 0x4d, 0xe7, 0xe8, 0x6c, 0x32, 0x8e, 0x04, 0x4c, 0x5e, 0x83, 0x47, 0xab, 0x0c,
+	0x99, 0xef, 0x0c, 0x9c, 0x61, 0x77, 0xd4, 0xd5, 0x76, 0x51, 0x78, 0xa4, 0x2c, 0xe1, 0x26, 0x78,
+	0xe7, 0xf7, 0x25, 0x5f, 0x86, 0xaf, 0x60, 0x73, 0x82, 0x8c, 0xa5, 0x45, 0x4e, 0x76, 0xa0, 0x9d,
+	0x26, 0xba, 0xa3, 0x76, 0x9a, 0x84, 0xdf, 0x40, 0x4f, 0xe5, 0x64, 0x65, 0x91, 0x33, 0x24, 0x43,
+	0xd8, 0x64, 0xca, 0x55, 0x27, 0xde, 0xd1, 0x81, 0x75, 0x80, 0xc8, 0x98, 0xc3, 0x6f, 0x61, 0xc7,
+	0x60, 0xba, 0xe0, 0x4f, 0xff, 0xf7, 0x12, 0xba, 0xe7, 0x0b, 0x9c, 0x9a, 0x1f, 0xf7, 0xc0, 0x9b,
+	0x55, 0x48, 0x97, 0xba, 0x2e, 0xa5, 0xd8, 0xe1, 0xda, 0xff, 0x1d, 0xee, 0xef, 0x16, 0xf4, 0x54,
+	0x3c, 0xdd, 0x45, 0x08, 0xbd, 0x2c, 0x66, 0xfc, 0x22, 0x67, 0x48, 0xf9, 0x85, 0xea, 0xd7, 0x89,
+	0x1a, 0x18, 0x79, 0x03, 0x7d, 0x5b, 0x3f, 0xa7, 0xb4, 0xa0, 0xfa, 0x58, 0x1f, 0x1b, 0x44, 0x44,
+	0x5a, 0x7c, 0x64, 0x27, 0xb7, 0xb7, 0x38, 0xe5, 0x98, 0xc8, 0xa3, 0x76, 0xa2, 0x06, 0x26, 0x22,
+	0xda, 0xba, 0x8a, 0xa8, 0x4e, 0xff, 0xb1, 0x21, 0xfc, 0x15, 0x3a, 0xd7, 0x0b, 0x33, 0x01, 0x1f,
+	0x36, 0x45, 0xd3, 0x29, 0x32, 0xbf, 0x35, 0x70, 0x86, 0x9d, 0xc8, 0xa8, 0xcf, 0x98, 0xc2, 0x5b,
+	0x80, 0xeb, 0x45, 0x3d, 0x82, 0x2f, 0x61, 0x93, 0x22, 0xcb, 0x2a, 0xae, 0x22, 0x76, 0x47, 0x2f,
+	0xf4, 0x7f, 0xf6, 0xa0, 0x22, 0xe3, 0x13, 0x7e, 0x05, 0xfd, 0x33, 0x7d, 0xff, 0x59, 0x1d, 0xe3,
+	0x00, 0x3a, 0x66, 0x29, 0x4c, 0x5d, 0x2b, 0x20, 0x8c, 0xa0, 0x77, 0x15, 0x53, 0x86, 0x56, 0x0f,
+	0x6c, 0x96, 0x5d, 0xe3, 0xc2, 0x6c, 0xab, 0x51, 0x9f, 0xd1, 0xc3, 0x15, 0xb8, 0xe3, 0x22, 0x91,
+	0x6b, 0xc6, 0x57, 0x81, 0xa4, 0x2c, 0xb1, 0x65, 0x89, 0x66, 0xf5, 0x84, 0x4c, 0x06, 0xd0, 0xbd,
+	0x4d, 0xf3, 0x3b, 0xa4, 0x25, 0x4d, 0x73, 0xae, 0x17, 0xd0, 0x86, 0xc2, 0x11, 0x6c, 0xeb, 0x2a,
+	0x75, 0x53, 0xaf, 0xc1, 0xcb, 0x8b, 0x04, 0xcd, 0x58, 0xcc, 0xe2, 0x88, 0xb4, 0x91, 0xb2, 0x84,
+	0x3f, 0x43, 0xef, 0xa4, 0x4a, 0x56, 0x9b, 0xb8, 0x0b, 0x0e, 0x9b, 0x65, 0xba, 0x18, 0x21, 0x3e,
+	0xa3, 0xa3, 0x3f, 0x5b, 0xd0, 0xd5, 0xc1, 0x58, 0x95, 0xc9, 0x29, 0xdd, 0x23, 0x63, 0xf1, 0x9d,
+	0xe1, 0x15, 0xa3, 0xae, 0x08, 0xa3, 0x6d, 0x13, 0xc6, 0x67, 0xd0, 0x11, 0xdb, 0xfc, 0x5e, 0x32,
+	0x91, 0xea, 0x6f, 0x4b, 0x00, 0x63, 0xc1, 0x46, 0x36, 0x9b, 0xb8, 0x4d, 0x36, 0x21, 0xfb, 0xb0,
+	0x51, 0x52, 0xbc, 0x4d, 0x17, 0x9a, 0x67, 0xb4, 0x16, 0x7e, 0x0f, 0xdb, 0xa6, 0x1e, 0x35, 0x90,
+	0x37, 0xf2, 0xa6, 0x54, 0x59, 0x7d, 0x53, 0x88, 0xee, 0xc5, 0x2a, 0x3b, 0x32, 0x2e, 0xe1, 0x3b,
+	0xe8, 0x4b, 0xfc, 0x34, 0xe6, 0xd3, 0x0f, 0x66, 0x40, 0x04, 0x5c, 0x36, 0xcb, 0xcc, 0x1d, 0x91,
+	0xf2, 0x33, 0x46, 0xf4, 0x13, 0x10, 0x3b, 0xa4, 0x2e, 0x6b, 0x04, 0x1d, 0xaa, 0x65, 0x53, 0xd8,
+	0xde, 0x83, 0xc2, 0xa4, 0x31, 0x5a, 0xb9, 0x85, 0x13, 0x78, 0xf9, 0x23, 0xe6, 0x51, 0x91, 0x65,
+	0x37, 0xf1, 0xf4, 0x8f, 0xc9, 0xbb, 0x5f, 0xfe, 0x8f, 0x13, 0x3c, 0x85, 0xfd, 0x87, 0x41, 0x75,
+	0x89, 0x8f, 0xa3, 0xee, 0xc3, 0x06, 0xc5, 0x98, 0xe9, 0xa0, 0x9d, 0x48, 0x6b, 0xe1, 0x5f, 0x2d,
+	0xd8, 0xbe, 0x44, 0x1e, 0xaf, 0x76, 0x6b, 0xdd, 0xe3, 0x52, 0x73, 0x7a, 0xfb, 0x29, 0x4e, 0x17,
+	0xd7, 0x67, 0x8e, 0x54, 0x96, 0xad, 0x2e, 0x83, 0x51, 0xc9, 0x21, 0xf4, 0xa5, 0xfb, 0xb4, 0xc8,
+	0xde, 0x6b, 0x8c, 0xf9, 0xee, 0xc0, 0x19, 0x7a, 0xd1, 0xae, 0x31, 0xfc, 0xa6, 0x71, 0x41, 0x67,
+	0xd3, 0xb8, 0x8c, 0x6f, 0xd2, 0x2c, 0xe5, 0x82, 0x74, 0x3c, 0x79, 0x70, 0x0d, 0x6c, 0xf4, 0x8f,
+	0x0b, 0x1b, 0x67, 0xf2, 0x65, 0x26, 0x87, 0xe0, 0xc9, 0xea, 0x49, 0xcf, 0x90, 0x88, 0x78, 0x57,
+	0x02, 0x73, 0x1e, 0xcd, 0xce, 0x8e, 0xc1, 0x15, 0x4f, 0x0a, 0x31, 0xd7, 0xc8, 0x7a, 0xd3, 0x82,
+	0x17, 0x0d, 0xac, 0xbe, 0x80, 0xde, 0x0f, 0x59, 0xc1, 0x90, 0xbc, 0x7c, 0x70, 0x04, 0xfa, 0xa7,
+	0x46, 0x52, 0x72, 0x08, 0xee, 0x55, 0x9a, 0xdf, 0x7d, 0x9a, 0xf3, 0x31, 0xb8, 0x82, 0xef, 0xea,
+	0x5a, 0xac, 0x57, 0x27, 0x58, 0x47, 0x88, 0xe4, 0x0b, 0x68, 0x5f, 0x2f, 0xc8, 0xae, 0x36, 0xd5,
+	0x04, 0x1d, 0xf4, 0x2d, 0x44, 0xbb, 0x7e, 0x07, 0x9d, 0x9a, 0x32, 0x9f, 0xaa, 0xc6, 0x37, 0xcf,
+	0xf6, 0x23, 0x6e, 0x1d, 0x81, 0x27, 0x79, 0x89, 0x98, 0x32, 0x6c, 0x2e, 0x0d, 0xf6, 0x9a, 0xe0,
+	0xea, 0x1f, 0x79, 0xf5, 0xeb, 0x7f, 0x6c, 0x96, 0x0a, 0xd6, 0x6e, 0x07, 0x39, 0x01, 0x58, 0x2d,
+	0x17, 0xf1, 0x6d, 0x1f, 0x7b, 0x85, 0x83, 0x57, 0x6b, 0x2c, 0x3a, 0xc4, 0x25, 0xec, 0x34, 0x17,
+	0x80, 0x1c, 0x68, 0xe7, 0xb5, 0xcb, 0x16, 0x7c, 0xfe, 0x84, 0x55, 0x85, 0x3b, 0x85, 0xdf, 0xb7,
+	0x8e, 0x8e, 0xdf, 0x4a, 0x97, 0x9b, 0x0d, 0xf9, 0xf9, 0xfa, 0xdf, 0x01, 0x00, 0x24, 0xb7, 0xca,
+	0xba, 0x03, 0x0a, 0x00, 0x00,
 }
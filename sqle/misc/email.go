@@ -44,10 +44,6 @@ func SendEmailIfConfigureSMTP(workflowId string) error {
 	if len(emails) == 0 {
 		return nil
 	}
-	message := gomail.NewMessage()
-	message.SetHeader("From", smtpC.Username)
-	message.SetHeader("To", emails...)
-	message.SetHeader("Subject", `SQL工单审批请求`)
 	body := fmt.Sprintf(`
 您有一个SQL工单待%v:
 - 工单主题: %v
@@ -55,6 +51,41 @@ func SendEmailIfConfigureSMTP(workflowId string) error {
 - 申请人: %v
 `, model.GetWorkflowStepTypeDesc(workflow.CurrentStep().Template.Typ),
 		workflow.Subject, workflow.Desc, workflow.CreateUserName())
+	return sendEmail(smtpC, emails, `SQL工单审批请求`, body)
+}
+
+// SendAuditTaskEmailIfConfigureSMTP notifies the user who creates the task that the
+// audit of task is finished, auditErr is the error of the audit if it's failed.
+func SendAuditTaskEmailIfConfigureSMTP(email string, task *model.Task, auditErr error) error {
+	if email == "" {
+		return nil
+	}
+	smtpC, exist, err := model.GetStorage().GetSMTPConfiguration()
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return nil
+	}
+
+	result := fmt.Sprintf("审核通过率: %.2f%%", task.PassRate*100)
+	if auditErr != nil {
+		result = fmt.Sprintf("审核失败: %v", auditErr)
+	}
+	body := fmt.Sprintf(`
+您的SQL审核任务已审核完成:
+- 任务ID: %v
+- 数据源: %v
+- %v
+`, task.ID, task.InstanceName(), result)
+	return sendEmail(smtpC, []string{email}, `SQL审核任务完成通知`, body)
+}
+
+func sendEmail(smtpC *model.SMTPConfiguration, emails []string, subject, body string) error {
+	message := gomail.NewMessage()
+	message.SetHeader("From", smtpC.Username)
+	message.SetHeader("To", emails...)
+	message.SetHeader("Subject", subject)
 	message.SetBody("text/html",
 		strings.Replace(body, "\n", "<br/>\n", -1))
 
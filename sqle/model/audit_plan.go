@@ -49,7 +49,8 @@ type AuditPlanReport struct {
 
 type AuditPlanReportSQL struct {
 	Model
-	AuditResult string `json:"audit_result" gorm:"type:text"`
+	AuditResult  string       `json:"audit_result" gorm:"type:text"`
+	AuditResults AuditResults `json:"audit_results" gorm:"type:json"`
 
 	AuditPlanSQLID    uint `json:"audit_plan_sql_id" gorm:"index"`
 	AuditPlanReportID uint `json:"audit_plan_report_id" gorm:"index"`
@@ -156,7 +156,8 @@ func (s *Storage) GetAuditPlanReportsByReq(data map[string]interface{}) (
 }
 
 type AuditPlanReportSQLListDetail struct {
-	AuditResult string `json:"audit_result"`
+	AuditResult  string       `json:"audit_result"`
+	AuditResults AuditResults `json:"audit_results"`
 
 	Fingerprint          string `json:"fingerprint"`
 	LastReceiveText      string `json:"last_sql"`
@@ -164,7 +165,7 @@ type AuditPlanReportSQLListDetail struct {
 }
 
 var auditPlanReportSQLQueryTpl = `
-SELECT audit_plan_report_sqls.audit_result, 
+SELECT audit_plan_report_sqls.audit_result, audit_plan_report_sqls.audit_results,
 audit_plan_sqls.fingerprint, audit_plan_sqls.last_sql, audit_plan_sqls.last_receive_timestamp
 
 {{- template "body" . -}} 
@@ -144,10 +144,10 @@ func TestStorage_GetAuditPlanReportSQLsByReq(t *testing.T) {
 	assert.NoError(t, err)
 	defer mockDB.Close()
 	InitMockStorage(mockDB)
-	mock.ExpectPrepare(fmt.Sprintf(`SELECT audit_plan_report_sqls.audit_result, audit_plan_sqls.fingerprint, audit_plan_sqls.last_sql, audit_plan_sqls.last_receive_timestamp %v LIMIT ? OFFSET ?`, tableAndRowOfSQL)).
+	mock.ExpectPrepare(fmt.Sprintf(`SELECT audit_plan_report_sqls.audit_result, audit_plan_report_sqls.audit_results, audit_plan_sqls.fingerprint, audit_plan_sqls.last_sql, audit_plan_sqls.last_receive_timestamp %v LIMIT ? OFFSET ?`, tableAndRowOfSQL)).
 		ExpectQuery().WithArgs("audit_plan_for_jave_repo", 1, 100, 10).WillReturnRows(sqlmock.NewRows([]string{
-		"audit_result", "fingerprint", "last_sql", "last_receive_timestamp",
-	}).AddRow("FAKE AUDIT RESULT", "select * from t1 where id = ?", "select * from t1 where id = 1", "2021-09-01T13:46:13+08:00"))
+		"audit_result", "audit_results", "fingerprint", "last_sql", "last_receive_timestamp",
+	}).AddRow("FAKE AUDIT RESULT", `[{"rule_name":"fake_rule","category":"","level":"error","message":"FAKE AUDIT RESULT"}]`, "select * from t1 where id = ?", "select * from t1 where id = 1", "2021-09-01T13:46:13+08:00"))
 
 	mock.ExpectPrepare(fmt.Sprintf(`SELECT COUNT(*) %v`, tableAndRowOfSQL)).
 		ExpectQuery().WithArgs("audit_plan_for_jave_repo", 1).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow("2"))
@@ -160,6 +160,7 @@ func TestStorage_GetAuditPlanReportSQLsByReq(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(2), count)
 	assert.Len(t, reslut, 1)
+	assert.Equal(t, AuditResults{{RuleName: "fake_rule", Level: "error", Message: "FAKE AUDIT RESULT"}}, reslut[0].AuditResults)
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
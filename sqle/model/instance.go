@@ -1,8 +1,11 @@
 package model
 
 import (
+	sqlDriver "database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/actiontech/sqle/sqle/errors"
 	"github.com/actiontech/sqle/sqle/log"
@@ -23,6 +26,9 @@ type Instance struct {
 	SecretPassword     string `json:"secret_password" gorm:"column:db_password; not null"`
 	Desc               string `json:"desc" example:"this is a instance"`
 	WorkflowTemplateId uint   `json:"workflow_template_id"`
+	// MaintenancePeriods is the time windows in which SQLs are allowed to be executed
+	// on the instance, there is no limitation if it's empty.
+	MaintenancePeriods Periods `json:"maintenance_periods" gorm:"type:json"`
 
 	// relation table
 	Roles            []*Role           `json:"-" gorm:"many2many:instance_role;"`
@@ -172,3 +178,85 @@ func (s *Storage) GetInstanceNamesByWorkflowTemplateId(id uint) ([]string, error
 	}
 	return names, nil
 }
+
+// Period is a time window in a day, it crosses midnight if the end is before the start,
+// e.g. 22:00-02:00.
+type Period struct {
+	StartHour   int `json:"start_hour"`
+	StartMinute int `json:"start_minute"`
+	EndHour     int `json:"end_hour"`
+	EndMinute   int `json:"end_minute"`
+}
+
+func (p *Period) startMinutes() int {
+	return p.StartHour*60 + p.StartMinute
+}
+
+func (p *Period) endMinutes() int {
+	return p.EndHour*60 + p.EndMinute
+}
+
+func (p *Period) IsValid() bool {
+	return p.StartHour >= 0 && p.StartHour <= 23 && p.StartMinute >= 0 && p.StartMinute <= 59 &&
+		p.EndHour >= 0 && p.EndHour <= 23 && p.EndMinute >= 0 && p.EndMinute <= 59 &&
+		p.startMinutes() != p.endMinutes()
+}
+
+// IsWithinScope checks whether t is in the period, the period is in local time of sqled,
+// so t is converted to local time first.
+func (p *Period) IsWithinScope(t time.Time) bool {
+	t = t.In(time.Local)
+	minutes := t.Hour()*60 + t.Minute()
+	start, end := p.startMinutes(), p.endMinutes()
+	if start < end {
+		return minutes >= start && minutes < end
+	}
+	return minutes >= start || minutes < end
+}
+
+func (p *Period) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", p.StartHour, p.StartMinute, p.EndHour, p.EndMinute)
+}
+
+type Periods []*Period
+
+// IsWithinScope checks whether t is in one of the periods, it's always true if
+// there is no period.
+func (ps Periods) IsWithinScope(t time.Time) bool {
+	if len(ps) == 0 {
+		return true
+	}
+	for _, p := range ps {
+		if p.IsWithinScope(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ps Periods) String() string {
+	periods := make([]string, 0, len(ps))
+	for _, p := range ps {
+		periods = append(periods, p.String())
+	}
+	return strings.Join(periods, ",")
+}
+
+func (ps Periods) Value() (sqlDriver.Value, error) {
+	b, err := json.Marshal(ps)
+	return string(b), err
+}
+
+func (ps *Periods) Scan(input interface{}) error {
+	switch v := input.(type) {
+	case nil:
+		*ps = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, ps)
+	case string:
+		return json.Unmarshal([]byte(v), ps)
+	default:
+		return fmt.Errorf("unsupported type %T for periods", input)
+	}
+}
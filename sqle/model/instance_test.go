@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriods_IsWithinScope(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2021, 9, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	assert.True(t, Periods{}.IsWithinScope(at(12, 0)))
+
+	day := Periods{{StartHour: 9, StartMinute: 30, EndHour: 11, EndMinute: 0}}
+	assert.True(t, day.IsWithinScope(at(9, 30)))
+	assert.True(t, day.IsWithinScope(at(10, 59)))
+	assert.False(t, day.IsWithinScope(at(11, 0)))
+	assert.False(t, day.IsWithinScope(at(9, 29)))
+
+	// the period crosses midnight.
+	night := Periods{{StartHour: 22, StartMinute: 0, EndHour: 2, EndMinute: 0}}
+	assert.True(t, night.IsWithinScope(at(23, 0)))
+	assert.True(t, night.IsWithinScope(at(1, 59)))
+	assert.False(t, night.IsWithinScope(at(2, 0)))
+	assert.False(t, night.IsWithinScope(at(12, 0)))
+
+	both := append(day, night...)
+	assert.True(t, both.IsWithinScope(at(10, 0)))
+	assert.True(t, both.IsWithinScope(at(0, 0)))
+	assert.False(t, both.IsWithinScope(at(15, 0)))
+	// the time in other location is converted to local time.
+	assert.True(t, both.IsWithinScope(at(10, 0).In(time.FixedZone("UTC-14", -14*3600))))
+	assert.False(t, both.IsWithinScope(at(15, 0).In(time.FixedZone("UTC+14", 14*3600))))
+	assert.Equal(t, "09:30-11:00,22:00-02:00", both.String())
+
+	assert.False(t, (&Period{StartHour: 24}).IsValid())
+	assert.False(t, (&Period{StartHour: 1, EndHour: 1}).IsValid())
+	assert.True(t, (&Period{StartHour: 23, StartMinute: 59}).IsValid())
+}
+
+func TestPeriods_Scan(t *testing.T) {
+	var ps Periods
+	assert.NoError(t, ps.Scan(nil))
+	assert.Len(t, ps, 0)
+
+	assert.NoError(t, ps.Scan([]byte(`[{"start_hour":22,"start_minute":0,"end_hour":2,"end_minute":30}]`)))
+	assert.Equal(t, Periods{{StartHour: 22, EndHour: 2, EndMinute: 30}}, ps)
+
+	v, err := ps.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"start_hour":22,"start_minute":0,"end_hour":2,"end_minute":30}]`, v)
+}
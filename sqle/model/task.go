@@ -3,21 +3,28 @@ package model
 import (
 	"bytes"
 	"database/sql"
+	sqlDriver "database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/actiontech/sqle/sqle/driver"
 	"github.com/actiontech/sqle/sqle/errors"
 
 	"github.com/jinzhu/gorm"
 )
 
 const (
-	TaskStatusInit             = "initialized"
-	TaskStatusAudited          = "audited"
-	TaskStatusExecuting        = "executing"
-	TaskStatusExecuteSucceeded = "exec_succeeded"
-	TaskStatusExecuteFailed    = "exec_failed"
+	TaskStatusInit               = "initialized"
+	TaskStatusAuditing           = "auditing"
+	TaskStatusAuditFailed        = "audit_failed"
+	TaskStatusAudited            = "audited"
+	TaskStatusExecuting          = "executing"
+	TaskStatusExecuteSucceeded   = "exec_succeeded"
+	TaskStatusExecuteFailed      = "exec_failed"
+	TaskStatusExecuteInterrupted = "exec_interrupted"
+	TaskStatusExecuteCancelled   = "exec_cancelled"
 )
 
 const (
@@ -39,6 +46,10 @@ type Task struct {
 	Status       string  `json:"status" gorm:"default:\"initialized\""`
 	CreateUserId uint
 
+	// AuditedSQLCount is the count of SQLs which have been audited, it's updated
+	// during the audit to show the progress.
+	AuditedSQLCount uint `json:"audited_sql_count" gorm:"column:audited_sql_count"`
+
 	CreateUser   *User          `gorm:"foreignkey:CreateUserId"`
 	Instance     *Instance      `json:"-" gorm:"foreignkey:InstanceId"`
 	ExecuteSQLs  []*ExecuteSQL  `json:"-" gorm:"foreignkey:TaskId"`
@@ -63,6 +74,8 @@ const (
 	SQLExecuteStatusDoing       = "doing"
 	SQLExecuteStatusFailed      = "failed"
 	SQLExecuteStatusSucceeded   = "succeeded"
+	SQLExecuteStatusInterrupted = "interrupted"
+	SQLExecuteStatusCancelled   = "cancelled"
 )
 
 type BaseSQL struct {
@@ -94,6 +107,10 @@ func (s *BaseSQL) GetExecStatusDesc() string {
 		return "执行失败"
 	case SQLExecuteStatusSucceeded:
 		return "执行成功"
+	case SQLExecuteStatusInterrupted:
+		return "执行中断"
+	case SQLExecuteStatusCancelled:
+		return "执行取消"
 	default:
 		return "未知"
 	}
@@ -108,6 +125,12 @@ type ExecuteSQL struct {
 	AuditFingerprint string `json:"audit_fingerprint" gorm:"index;type:char(32)"`
 	// AuditLevel has four level: error, warn, notice, normal.
 	AuditLevel string `json:"audit_level"`
+	// AuditResults is the structured form of AuditResult, each finding keeps
+	// the rule which produced it.
+	AuditResults AuditResults `json:"audit_results" gorm:"type:json"`
+	// ExecProgress is the latest progress reported by driver when the SQL is
+	// executing, such as the chunks of DML which have been executed.
+	ExecProgress string `json:"exec_progress" gorm:"type:text"`
 }
 
 func (s ExecuteSQL) TableName() string {
@@ -127,6 +150,14 @@ func (s *ExecuteSQL) GetAuditStatusDesc() string {
 	}
 }
 
+// SetAuditResult saves the driver audit result to the audit level, the audit
+// result text and the structured audit results.
+func (s *ExecuteSQL) SetAuditResult(result *driver.AuditResult) {
+	s.AuditLevel = string(result.Level())
+	s.AuditResult = result.Message()
+	s.AuditResults = NewAuditResults(result)
+}
+
 func (s *ExecuteSQL) GetAuditResultDesc() string {
 	if s.AuditResult == "" {
 		return "审核通过"
@@ -134,6 +165,64 @@ func (s *ExecuteSQL) GetAuditResultDesc() string {
 	return s.AuditResult
 }
 
+// GetAuditRuleNamesDesc returns names of the rules which the SQL violated, one
+// name per line.
+func (s *ExecuteSQL) GetAuditRuleNamesDesc() string {
+	names := []string{}
+	for _, result := range s.AuditResults {
+		if result.RuleName != "" {
+			names = append(names, result.RuleName)
+		}
+	}
+	return strings.Join(names, "\n")
+}
+
+// AuditResult is one finding of SQL audit, RuleName is empty when the finding
+// isn't produced by a rule.
+type AuditResult struct {
+	RuleName string `json:"rule_name"`
+	Category string `json:"category"`
+	Level    string `json:"level"`
+	Message  string `json:"message"`
+	// Prefix replaces the level prefix of Message, e.g. "[osc]".
+	Prefix string `json:"prefix,omitempty"`
+}
+
+type AuditResults []AuditResult
+
+func NewAuditResults(result *driver.AuditResult) AuditResults {
+	results := AuditResults{}
+	for _, item := range result.Items() {
+		results = append(results, AuditResult{
+			RuleName: item.RuleName,
+			Category: item.Category,
+			Level:    string(item.Level),
+			Message:  item.Message,
+			Prefix:   item.Prefix,
+		})
+	}
+	return results
+}
+
+func (a AuditResults) Value() (sqlDriver.Value, error) {
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+func (a *AuditResults) Scan(input interface{}) error {
+	switch v := input.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("unsupported type %T for audit results", input)
+	}
+}
+
 type RollbackSQL struct {
 	BaseSQL
 	ExecuteSQLId uint `gorm:"index;column:execute_sql_id"`
@@ -143,6 +232,41 @@ func (s RollbackSQL) TableName() string {
 	return "rollback_sql_detail"
 }
 
+// RollbackSQLEditRecord records an edit of rollback SQL, the rollback SQL generated
+// by sqle can be edited by approver before it is executed.
+type RollbackSQLEditRecord struct {
+	Model
+	TaskId        uint   `json:"task_id" gorm:"index"`
+	RollbackSQLId uint   `json:"rollback_sql_id" gorm:"index"`
+	UserId        uint   `json:"user_id"`
+	OriginContent string `json:"origin_sql" gorm:"type:text"`
+	Content       string `json:"sql" gorm:"type:text"`
+	Reason        string `json:"reason"`
+
+	User *User `gorm:"foreignkey:UserId"`
+}
+
+func (r RollbackSQLEditRecord) TableName() string {
+	return "rollback_sql_edit_records"
+}
+
+// RollbackRetention is the data kept on instance for rollback after the SQL is
+// executed, such as the old table kept by online DDL. It's cleaned by CleanSQL
+// after ExpiredAt.
+type RollbackRetention struct {
+	Model
+	TaskId        uint      `json:"task_id" gorm:"index"`
+	RollbackSQLId uint      `json:"rollback_sql_id"`
+	InstanceId    uint      `json:"instance_id"`
+	Schema        string    `json:"schema"`
+	CleanSQL      string    `json:"clean_sql" gorm:"type:text"`
+	ExpiredAt     time.Time `json:"expired_at" gorm:"index"`
+
+	// RecycleBinObject is the name of dropped object if the retention is the tables
+	// moved to recycle bin.
+	RecycleBinObject string `json:"recycle_bin_object"`
+}
+
 func (t *Task) HasDoingAudit() bool {
 	if t.ExecuteSQLs != nil {
 		for _, commitSQL := range t.ExecuteSQLs {
@@ -168,7 +292,9 @@ func (t *Task) HasDoingExecute() bool {
 func (t *Task) IsExecuteFailed() bool {
 	if t.ExecuteSQLs != nil {
 		for _, commitSQL := range t.ExecuteSQLs {
-			if commitSQL.ExecStatus == SQLExecuteStatusFailed {
+			if commitSQL.ExecStatus == SQLExecuteStatusFailed ||
+				commitSQL.ExecStatus == SQLExecuteStatusInterrupted ||
+				commitSQL.ExecStatus == SQLExecuteStatusCancelled {
 				return true
 			}
 		}
@@ -196,6 +322,13 @@ func (s *Storage) GetTaskById(taskId string) (*Task, bool, error) {
 	return task, true, errors.New(errors.ConnectStorageError, err)
 }
 
+// GetTaskSQLCount returns the count of SQLs in the task.
+func (s *Storage) GetTaskSQLCount(taskId uint) (uint, error) {
+	var count uint
+	err := s.db.Model(&ExecuteSQL{}).Where("task_id = ?", taskId).Count(&count).Error
+	return count, errors.New(errors.ConnectStorageError, err)
+}
+
 func (s *Storage) GetTaskDetailById(taskId string) (*Task, bool, error) {
 	task := &Task{}
 	err := s.db.Where("id = ?", taskId).Preload("Instance").
@@ -252,6 +385,13 @@ func (s *Storage) UpdateRollbackSQLs(rollbackSQLs []*RollbackSQL) error {
 	return errors.New(errors.ConnectStorageError, tx.Commit().Error)
 }
 
+// DeleteRollbackSQLsByExecuteSQLId deletes the rollback SQLs of the execute SQL, they
+// are generated again when the task is re-audited.
+func (s *Storage) DeleteRollbackSQLsByExecuteSQLId(executeSQLId uint) error {
+	err := s.db.Where("execute_sql_id = ?", executeSQLId).Delete(&RollbackSQL{}).Error
+	return errors.New(errors.ConnectStorageError, err)
+}
+
 func (s *Storage) UpdateTaskStatusById(taskId uint, status string) error {
 	err := s.db.Model(&Task{}).Where("id = ?", taskId).Update(map[string]string{
 		"status": status,
@@ -306,6 +446,113 @@ func (s *Storage) UpdateRollbackSQLById(rollbackSQLId string, attrs ...interface
 	return errors.New(errors.ConnectStorageError, err)
 }
 
+func (s *Storage) GetRollbackSQLById(taskId, rollbackSQLId string) (*RollbackSQL, bool, error) {
+	rollbackSQL := &RollbackSQL{}
+	err := s.db.Where("task_id = ? AND id = ?", taskId, rollbackSQLId).First(rollbackSQL).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return rollbackSQL, true, errors.New(errors.ConnectStorageError, err)
+}
+
+// EditRollbackSQL updates the content of the rollback SQL which has not been executed,
+// and records the edit. It returns false if the rollback SQL has been executed.
+func (s *Storage) EditRollbackSQL(rollbackSQL *RollbackSQL, content string, userId uint, reason string) (bool, error) {
+	tx := s.db.Begin()
+	db := tx.Model(&RollbackSQL{}).Where("id = ? AND exec_status = ?", rollbackSQL.ID, SQLExecuteStatusInitialized).
+		Update("content", content)
+	if db.Error != nil {
+		tx.Rollback()
+		return false, errors.New(errors.ConnectStorageError, db.Error)
+	}
+	if db.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	record := &RollbackSQLEditRecord{
+		TaskId:        rollbackSQL.TaskId,
+		RollbackSQLId: rollbackSQL.ID,
+		UserId:        userId,
+		OriginContent: rollbackSQL.Content,
+		Content:       content,
+		Reason:        reason,
+	}
+	if err := tx.Save(record).Error; err != nil {
+		tx.Rollback()
+		return false, errors.New(errors.ConnectStorageError, err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, errors.New(errors.ConnectStorageError, err)
+	}
+	rollbackSQL.Content = content
+	return true, nil
+}
+
+// SaveExecRollback replaces the rollback SQL of executeSQL which is decided during the
+// execution, and saves the retention if it's not nil. The rollback SQL generated in
+// batches is replaced as a whole.
+func (s *Storage) SaveExecRollback(executeSQL *ExecuteSQL, content string, retention *RollbackRetention) error {
+	tx := s.db.Begin()
+	rollbackSQL := &RollbackSQL{}
+	err := tx.Where("execute_sql_id = ?", executeSQL.ID).Order("id").First(rollbackSQL).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		tx.Rollback()
+		return errors.New(errors.ConnectStorageError, err)
+	}
+	if err == nil {
+		err = tx.Where("execute_sql_id = ? AND id != ?", executeSQL.ID, rollbackSQL.ID).Delete(&RollbackSQL{}).Error
+		if err != nil {
+			tx.Rollback()
+			return errors.New(errors.ConnectStorageError, err)
+		}
+	}
+	rollbackSQL.TaskId = executeSQL.TaskId
+	rollbackSQL.ExecuteSQLId = executeSQL.ID
+	rollbackSQL.Content = content
+	if err := tx.Save(rollbackSQL).Error; err != nil {
+		tx.Rollback()
+		return errors.New(errors.ConnectStorageError, err)
+	}
+	if retention != nil {
+		retention.TaskId = executeSQL.TaskId
+		retention.RollbackSQLId = rollbackSQL.ID
+		if err := tx.Save(retention).Error; err != nil {
+			tx.Rollback()
+			return errors.New(errors.ConnectStorageError, err)
+		}
+	}
+	return errors.New(errors.ConnectStorageError, tx.Commit().Error)
+}
+
+func (s *Storage) GetExpiredRollbackRetentions(now time.Time) ([]*RollbackRetention, error) {
+	retentions := []*RollbackRetention{}
+	err := s.db.Where("expired_at < ?", now).Order("id").Find(&retentions).Error
+	return retentions, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetRecycleBinObjectsByInstanceId(instanceId uint) ([]*RollbackRetention, error) {
+	retentions := []*RollbackRetention{}
+	err := s.db.Where("instance_id = ? AND recycle_bin_object != ''", instanceId).
+		Order("id desc").Find(&retentions).Error
+	return retentions, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetRecycleBinObject(instanceId uint, id string) (*RollbackRetention, bool, error) {
+	retention := &RollbackRetention{}
+	err := s.db.Where("instance_id = ? AND recycle_bin_object != '' AND id = ?", instanceId, id).
+		First(retention).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return retention, true, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetRollbackSQLEditRecordsByTaskId(taskId string) ([]*RollbackSQLEditRecord, error) {
+	records := []*RollbackSQLEditRecord{}
+	err := s.db.Where("task_id = ?", taskId).Preload("User").Order("id").Find(&records).Error
+	return records, errors.New(errors.ConnectStorageError, err)
+}
+
 func (s *Storage) GetRelatedDDLTask(task *Task) ([]Task, error) {
 	tasks := []Task{}
 	err := s.db.Where(Task{
@@ -324,18 +571,25 @@ func (s *Storage) GetTaskByInstanceId(instanceId uint) ([]Task, error) {
 }
 
 type TaskSQLDetail struct {
-	Number      uint           `json:"number"`
-	ExecSQL     string         `json:"exec_sql"`
-	AuditResult string         `json:"audit_result"`
-	AuditLevel  string         `json:"audit_level"`
-	AuditStatus string         `json:"audit_status"`
-	ExecResult  string         `json:"exec_result"`
-	ExecStatus  string         `json:"exec_status"`
-	RollbackSQL sql.NullString `json:"rollback_sql"`
+	Number       uint           `json:"number"`
+	ExecSQL      string         `json:"exec_sql"`
+	AuditResult  string         `json:"audit_result"`
+	AuditResults AuditResults   `json:"audit_results"`
+	AuditLevel   string         `json:"audit_level"`
+	AuditStatus  string         `json:"audit_status"`
+	ExecResult   string         `json:"exec_result"`
+	ExecStatus   string         `json:"exec_status"`
+	ExecProgress sql.NullString `json:"exec_progress"`
+	RollbackSQL  sql.NullString `json:"rollback_sql"`
+
+	RollbackSQLId         sql.NullInt64  `json:"rollback_sql_id"`
+	RollbackSQLExecStatus sql.NullString `json:"rollback_sql_exec_status"`
 }
 
 var taskSQLsQueryTpl = `SELECT e_sql.number, e_sql.content AS exec_sql, r_sql.content AS rollback_sql,
-e_sql.audit_result, e_sql.audit_level, e_sql.audit_status, e_sql.exec_result, e_sql.exec_status
+r_sql.id AS rollback_sql_id, r_sql.exec_status AS rollback_sql_exec_status,
+e_sql.audit_result, e_sql.audit_results, e_sql.audit_level, e_sql.audit_status, e_sql.exec_result, e_sql.exec_status,
+e_sql.exec_progress
 
 {{- template "body" . -}}
 
@@ -365,13 +619,17 @@ AND e_sql.exec_status = :filter_exec_status
 AND e_sql.audit_status = :filter_audit_status
 {{- end }}
 
+{{- if .filter_audit_rule_name }}
+AND JSON_CONTAINS(e_sql.audit_results, JSON_OBJECT('rule_name', :filter_audit_rule_name))
+{{- end }}
+
 {{- if .no_duplicate }}
 AND e_sql.id IN (
 SELECT SQL_BIG_RESULT MIN(id) AS id FROM execute_sql_detail WHERE task_id = :task_id 
 GROUP BY audit_fingerprint, IFNULL(audit_fingerprint, id) ORDER BY null
 )
 {{- end }}
-ORDER BY e_sql.id
+ORDER BY e_sql.id, r_sql.id
 {{- end }}
 `
 
@@ -400,6 +658,14 @@ func (s *Storage) DeleteTask(task *Task) error {
 		if err != nil {
 			return err
 		}
+		_, err = tx.Exec("DELETE FROM task_actions WHERE task_id = ?", task.ID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("DELETE FROM rollback_sql_edit_records WHERE task_id = ?", task.ID)
+		if err != nil {
+			return err
+		}
 		return nil
 	})
 }
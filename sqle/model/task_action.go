@@ -0,0 +1,132 @@
+package model
+
+import (
+	sqlDriver "database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/actiontech/sqle/sqle/errors"
+)
+
+const (
+	TaskActionStatusQueued      = "queued"
+	TaskActionStatusDoing       = "doing"
+	TaskActionStatusFinished    = "finished"
+	TaskActionStatusInterrupted = "interrupted"
+)
+
+// TaskAction is the persisted form of an action of task (audit, execute, rollback),
+// it's used to recover the actions which are queued or running when sqled exits.
+type TaskAction struct {
+	Model
+	TaskId uint   `json:"task_id" gorm:"index;not null"`
+	Type   int    `json:"type" gorm:"not null"`
+	Status string `json:"status" gorm:"index;default:\"queued\""`
+	Error  string `json:"error" gorm:"type:text"`
+
+	Params TaskActionParams `json:"params" gorm:"type:json"`
+}
+
+func (a TaskAction) TableName() string {
+	return "task_actions"
+}
+
+// TaskActionParams is the parameters of action which are persisted with it,
+// so the action is done in the same way after recovered.
+type TaskActionParams struct {
+	// RollbackSQLIds is the rollback SQLs chosen to rollback, all rollback SQLs
+	// of the task are rollbacked if it's empty.
+	RollbackSQLIds []uint `json:"rollback_sql_ids,omitempty"`
+}
+
+func (p TaskActionParams) Value() (sqlDriver.Value, error) {
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+func (p *TaskActionParams) Scan(input interface{}) error {
+	switch v := input.(type) {
+	case nil:
+		*p = TaskActionParams{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return fmt.Errorf("unsupported type %T for task action params", input)
+	}
+}
+
+func (s *Storage) GetUnfinishedTaskActions() ([]*TaskAction, error) {
+	actions := []*TaskAction{}
+	err := s.db.Where("status IN (?)", []string{TaskActionStatusQueued, TaskActionStatusDoing}).
+		Order("id").Find(&actions).Error
+	return actions, errors.New(errors.ConnectStorageError, err)
+}
+
+// ClaimTaskAction changes the action status from queued to doing, it returns false
+// if the action has been claimed by others, so an action will be done only once
+// even if it is queued by more than one sqled process.
+func (s *Storage) ClaimTaskAction(action *TaskAction) (bool, error) {
+	db := s.db.Model(&TaskAction{}).Where("id = ? AND status = ?", action.ID, TaskActionStatusQueued).
+		Update("status", TaskActionStatusDoing)
+	if db.Error != nil {
+		return false, errors.New(errors.ConnectStorageError, db.Error)
+	}
+	if db.RowsAffected == 0 {
+		return false, nil
+	}
+	action.Status = TaskActionStatusDoing
+	return true, nil
+}
+
+func (s *Storage) UpdateTaskActionStatus(action *TaskAction, status, errMsg string) error {
+	action.Status = status
+	action.Error = errMsg
+	err := s.db.Model(&TaskAction{}).Where("id = ?", action.ID).Update(map[string]interface{}{
+		"status": status,
+		"error":  errMsg,
+	}).Error
+	return errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetTasksByStatus(status string) ([]*Task, error) {
+	tasks := []*Task{}
+	err := s.db.Where("status = ?", status).Find(&tasks).Error
+	return tasks, errors.New(errors.ConnectStorageError, err)
+}
+
+// InterruptTaskExecution marks the task which was executing when sqled exits as
+// interrupted. The SQLs which had succeeded have been applied, the SQLs which
+// were still doing are marked as interrupted, it's unknown whether they are applied.
+func (s *Storage) InterruptTaskExecution(taskId uint, result string) error {
+	tx := s.db.Begin()
+	err := tx.Model(&Task{}).Where("id = ? AND status = ?", taskId, TaskStatusExecuting).
+		Update("status", TaskStatusExecuteInterrupted).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New(errors.ConnectStorageError, err)
+	}
+	err = tx.Table(ExecuteSQL{}.TableName()).
+		Where("task_id = ? AND exec_status = ?", taskId, SQLExecuteStatusDoing).
+		Update(map[string]interface{}{
+			"exec_status": SQLExecuteStatusInterrupted,
+			"exec_result": result,
+		}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New(errors.ConnectStorageError, err)
+	}
+	err = tx.Table(RollbackSQL{}.TableName()).
+		Where("task_id = ? AND exec_status = ?", taskId, SQLExecuteStatusDoing).
+		Update(map[string]interface{}{
+			"exec_status": SQLExecuteStatusInterrupted,
+			"exec_result": result,
+		}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.New(errors.ConnectStorageError, err)
+	}
+	return errors.New(errors.ConnectStorageError, tx.Commit().Error)
+}
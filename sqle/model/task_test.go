@@ -0,0 +1,24 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditResults(t *testing.T) {
+	result := driver.NewInspectResults()
+	result.AddWithRule(&driver.Rule{Name: "rule1", Category: "category1", Level: driver.RuleLevelWarn}, "message1")
+	result.AddItem(&driver.AuditResultItem{Level: driver.RuleLevelNotice, Message: "message2", Prefix: "[osc]"})
+
+	// the prefix of finding is persisted.
+	value, err := NewAuditResults(result).Value()
+	assert.NoError(t, err)
+	results := AuditResults{}
+	assert.NoError(t, results.Scan([]byte(value.(string))))
+	assert.Equal(t, AuditResults{
+		{RuleName: "rule1", Category: "category1", Level: "warn", Message: "message1"},
+		{Level: "notice", Message: "message2", Prefix: "[osc]"},
+	}, results)
+}
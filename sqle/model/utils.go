@@ -109,6 +109,9 @@ func (s *Storage) AutoMigrate() error {
 		&Task{},
 		&ExecuteSQL{},
 		&RollbackSQL{},
+		&RollbackSQLEditRecord{},
+		&RollbackRetention{},
+		&TaskAction{},
 		&SqlWhitelist{},
 		&User{},
 		&Role{},
@@ -177,6 +177,16 @@ type WorkflowRecord struct {
 	TaskId                uint `gorm:"index"`
 	CurrentWorkflowStepId uint
 	Status                string `gorm:"default:\"on_process\""`
+	// ScheduledAt is the time to execute the task after the workflow is approved,
+	// the task is executed at once after approving if it's nil.
+	ScheduledAt    *time.Time
+	ScheduleUserId uint
+	// ScheduleFailures and ScheduleError are the count and the last error of the
+	// failed attempts to execute the scheduled task, the next attempt is delayed
+	// until ScheduleRetryAt.
+	ScheduleFailures uint
+	ScheduleError    string `gorm:"type:text"`
+	ScheduleRetryAt  *time.Time
 
 	CurrentStep *WorkflowStep   `gorm:"foreignkey:CurrentWorkflowStepId"`
 	Steps       []*WorkflowStep `gorm:"foreignkey:WorkflowRecordId"`
@@ -186,6 +196,12 @@ const (
 	WorkflowStepStateInit    = "initialized"
 	WorkflowStepStateApprove = "approved"
 	WorkflowStepStateReject  = "rejected"
+	// WorkflowStepStateResume, WorkflowStepStateRetry and WorkflowStepStateRollback
+	// are the states of the steps which are appended to the finished workflow record
+	// when the execution is resumed, retried or rollbacked, they record who did it and why.
+	WorkflowStepStateResume   = "resumed"
+	WorkflowStepStateRetry    = "retried"
+	WorkflowStepStateRollback = "rollbacked"
 )
 
 type WorkflowStep struct {
@@ -402,6 +418,52 @@ func (s *Storage) UpdateWorkflowStatus(w *Workflow, operateStep *WorkflowStep) e
 	})
 }
 
+func (s *Storage) UpdateWorkflowSchedule(w *Workflow, userId uint, scheduleTime *time.Time) error {
+	err := s.db.Model(&WorkflowRecord{}).Where("id = ?", w.Record.ID).Update(map[string]interface{}{
+		"scheduled_at":      scheduleTime,
+		"schedule_user_id":  userId,
+		"schedule_failures": 0,
+		"schedule_error":    "",
+		"schedule_retry_at": nil,
+	}).Error
+	if err != nil {
+		return errors.New(errors.ConnectStorageError, err)
+	}
+	w.Record.ScheduledAt = scheduleTime
+	w.Record.ScheduleUserId = userId
+	w.Record.ScheduleFailures, w.Record.ScheduleError, w.Record.ScheduleRetryAt = 0, "", nil
+	return nil
+}
+
+// UpdateWorkflowRecordScheduleFailure records the failed attempt to execute the
+// scheduled task of record, the next attempt is at retryAt.
+func (s *Storage) UpdateWorkflowRecordScheduleFailure(record *WorkflowRecord, scheduleErr error, retryAt time.Time) error {
+	err := s.db.Model(&WorkflowRecord{}).Where("id = ?", record.ID).Update(map[string]interface{}{
+		"schedule_failures": record.ScheduleFailures + 1,
+		"schedule_error":    scheduleErr.Error(),
+		"schedule_retry_at": retryAt,
+	}).Error
+	if err != nil {
+		return errors.New(errors.ConnectStorageError, err)
+	}
+	record.ScheduleFailures++
+	record.ScheduleError, record.ScheduleRetryAt = scheduleErr.Error(), &retryAt
+	return nil
+}
+
+// GetNeedScheduledWorkflowRecords returns the records of the approved workflows whose
+// scheduled time is up and task has not been executed.
+func (s *Storage) GetNeedScheduledWorkflowRecords(now time.Time) ([]*WorkflowRecord, error) {
+	records := []*WorkflowRecord{}
+	err := s.db.Model(&WorkflowRecord{}).Select("workflow_records.*").
+		Joins("JOIN tasks ON tasks.id = workflow_records.task_id").
+		Where("workflow_records.status = ? AND workflow_records.scheduled_at <= ?", WorkflowStatusFinish, now).
+		Where("workflow_records.schedule_retry_at IS NULL OR workflow_records.schedule_retry_at <= ?", now).
+		Where("tasks.status = ? AND tasks.deleted_at IS NULL", TaskStatusAudited).
+		Scan(&records).Error
+	return records, errors.New(errors.ConnectStorageError, err)
+}
+
 func (s *Storage) getWorkflowStepsByRecordIds(ids []uint) ([]*WorkflowStep, error) {
 	steps := []*WorkflowStep{}
 	err := s.db.Where("workflow_record_id in (?)", ids).
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/pkg/driver/rollback"
 	"github.com/actiontech/sqle/sqle/utils"
 	"github.com/hashicorp/go-hclog"
 	"github.com/percona/go-mysql/query"
@@ -19,19 +20,19 @@ import (
 type Adaptor struct {
 	l hclog.Logger
 
-	cfg *driver.Config
-
 	dt Dialector
 
-	rules            []*driver.Rule
-	ruleToRawHandler map[string] /*rule name*/ rawSQLRuleHandler
-	ruleToASTHandler map[string] /*rule name*/ astSQLRuleHandler
+	rules              []*driver.Rule
+	ruleToRawHandler   map[string] /*rule name*/ rawSQLRuleHandler
+	ruleToASTHandler   map[string] /*rule name*/ astSQLRuleHandler
+	rollbackGenerators rollback.Generators
 
 	ao *adaptorOptions
 }
 
 type adaptorOptions struct {
 	sqlParser func(string) (interface{}, error)
+	version   string
 }
 
 type rawSQLRuleHandler func(ctx context.Context, rule *driver.Rule, rawSQL string) (string, error)
@@ -81,16 +82,21 @@ func (a *Adaptor) Serve(opts ...AdaptorOption) {
 	if len(a.ruleToASTHandler) != 0 && a.ao.sqlParser == nil {
 		panic("Add rule by AddRuleWithSQLParser(), but no SQL parser provided.")
 	}
+	if a.rollbackGenerators.HasAST() && a.ao.sqlParser == nil {
+		panic("Add rollback generator by AddRollbackGeneratorWithSQLParser(), but no SQL parser provided.")
+	}
 
 	r := &registererImpl{
-		dt:    a.dt,
-		rules: a.rules,
+		dt:       a.dt,
+		rules:    a.rules,
+		version:  a.ao.version,
+		rollback: a.rollbackGenerators.Len() != 0,
 	}
 
+	// the drivers opened by different sessions share the Adaptor, so the config
+	// is kept by driver.
 	newDriver := func(cfg *driver.Config) driver.Driver {
-		a.cfg = cfg
-
-		di := &driverImpl{a: a}
+		di := &driverImpl{a: a, cfg: cfg}
 
 		if cfg.DSN == nil {
 			return di
@@ -147,12 +153,22 @@ func WithSQLParser(parser func(sql string) (ast interface{}, err error)) Adaptor
 	})
 }
 
+// WithVersion define the plugin version, which is shown with the driver in SQLe.
+func WithVersion(version string) AdaptorOption {
+	return newOptionFunc(func(a *adaptorOptions) {
+		a.version = version
+	})
+}
+
 var _ driver.Driver = (*driverImpl)(nil)
-var _ driver.Registerer = (*registererImpl)(nil)
+var _ driver.MetasRegisterer = (*registererImpl)(nil)
 
 type registererImpl struct {
-	dt    Dialector
-	rules []*driver.Rule
+	dt      Dialector
+	rules   []*driver.Rule
+	version string
+	// rollback is set if any rollback generator is added.
+	rollback bool
 }
 
 func (r *registererImpl) Name() string {
@@ -163,8 +179,24 @@ func (r *registererImpl) Rules() []*driver.Rule {
 	return r.rules
 }
 
+func (r *registererImpl) Version() string {
+	return r.version
+}
+
+func (r *registererImpl) Capabilities() []driver.Capability {
+	capabilities := []driver.Capability{
+		driver.CapabilityOfflineAudit,
+		driver.CapabilityTransaction,
+	}
+	if r.rollback {
+		capabilities = append(capabilities, driver.CapabilityRollback)
+	}
+	return capabilities
+}
+
 type driverImpl struct {
 	a    *Adaptor
+	cfg  *driver.Config
 	db   *sql.DB
 	conn *sql.Conn
 }
@@ -296,14 +328,14 @@ func (d *driverImpl) Audit(ctx context.Context, sql string) (*driver.AuditResult
 	}
 
 	result := driver.NewInspectResults()
-	for _, rule := range d.a.cfg.Rules {
+	for _, rule := range d.cfg.Rules {
 		handler, ok := d.a.ruleToRawHandler[rule.Name]
 		if ok {
 			msg, err := handler(ctx, rule, sql)
 			if err != nil {
 				return nil, errors.Wrapf(err, "audit SQL %s in driver adaptor", sql)
 			}
-			result.Add(rule.Level, msg)
+			result.AddWithRule(rule, msg)
 		} else {
 			handler, ok := d.a.ruleToASTHandler[rule.Name]
 			if ok {
@@ -311,7 +343,7 @@ func (d *driverImpl) Audit(ctx context.Context, sql string) (*driver.AuditResult
 				if err != nil {
 					return nil, errors.Wrapf(err, "audit SQL %s in driver adaptor", sql)
 				}
-				result.Add(rule.Level, msg)
+				result.AddWithRule(rule, msg)
 			}
 		}
 	}
@@ -319,6 +351,29 @@ func (d *driverImpl) Audit(ctx context.Context, sql string) (*driver.AuditResult
 	return result, nil
 }
 
+func (d *driverImpl) AuditBatch(ctx context.Context, sqls []string) ([]*driver.AuditResult, error) {
+	results := make([]*driver.AuditResult, 0, len(sqls))
+	for _, sql := range sqls {
+		result, err := d.Audit(ctx, sql)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// GenRollbackSQL returns no rollback SQL and no reason in offline audit and for the
+// statement which isn't handled by any generator, like MySQL driver, so no notice is
+// added to the read-only statements.
 func (d *driverImpl) GenRollbackSQL(ctx context.Context, sql string) (string, string, error) {
-	return "", "", nil
+	if d.a.rollbackGenerators.Len() == 0 || d.conn == nil {
+		return "", "", nil
+	}
+
+	rollbackSQL, reason, err := d.a.rollbackGenerators.Generate(ctx, d.conn, sql, d.a.ao.sqlParser)
+	if err != nil {
+		return "", "", errors.Wrap(err, "generate rollback SQL in driver adaptor")
+	}
+	return rollbackSQL, reason, nil
 }
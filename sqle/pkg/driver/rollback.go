@@ -0,0 +1,28 @@
+package driver
+
+import "github.com/actiontech/sqle/sqle/pkg/driver/rollback"
+
+// The reasons why the rollback SQL is not generated. They are the same as the reasons
+// of MySQL driver, so the reasons of all drivers are alike to user.
+const (
+	RollbackReasonNotSupportStatement           = "暂不支持回滚该类型的语句"
+	RollbackReasonNotSupportMultiTableStatement = "暂不支持回滚多表的 DML 语句"
+	RollbackReasonNotSupportSubQueryStatement   = "暂不支持回滚带子查询的语句"
+	RollbackReasonNotSupportNoPrimaryKeyTable   = "不支持回滚没有主键或非空唯一键的表的DML语句"
+	RollbackReasonNotSupportExceedMaxRows       = "预计影响行数超过配置的最大值，不生成回滚语句"
+)
+
+// AddRollbackGenerator adds a generator of rollback SQL. The generators are called
+// in the order they are added, until one of them returns the rollback SQL or the
+// reason. The generator should return the reason only for the statement it
+// recognises, the statement which isn't handled by any generator has no rollback
+// SQL and no reason, e.g. SELECT.
+func (a *Adaptor) AddRollbackGenerator(h rollback.RawHandler) {
+	a.rollbackGenerators.AddRaw(h)
+}
+
+// AddRollbackGeneratorWithSQLParser is same as AddRollbackGenerator, but the SQL is
+// parsed by the SQL parser defined by WithSQLParser.
+func (a *Adaptor) AddRollbackGeneratorWithSQLParser(h rollback.ASTHandler) {
+	a.rollbackGenerators.AddAST(h)
+}
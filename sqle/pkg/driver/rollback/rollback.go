@@ -0,0 +1,83 @@
+// Package rollback keeps the rollback generators added to the driver adaptor, it
+// doesn't depend on the SQL parser of the adaptor.
+package rollback
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// RawHandler generates the rollback SQL of rawSQL, conn is the live connection of
+// database which the SQL will be executed on. It returns the reason if the rollback
+// SQL can't be generated, or both empty if the SQL isn't handled.
+type RawHandler func(ctx context.Context, conn *sql.Conn, rawSQL string) (rollbackSQL, reason string, err error)
+
+// ASTHandler is same as RawHandler, but the SQL is parsed by the SQL parser.
+type ASTHandler func(ctx context.Context, conn *sql.Conn, astSQL interface{}) (rollbackSQL, reason string, err error)
+
+// handler is one of the raw and AST handler.
+type handler struct {
+	raw RawHandler
+	ast ASTHandler
+}
+
+// Generators are the generators of rollback SQL, they are called in the order they
+// are added.
+type Generators struct {
+	handlers []*handler
+}
+
+func (g *Generators) AddRaw(h RawHandler) {
+	g.handlers = append(g.handlers, &handler{raw: h})
+}
+
+func (g *Generators) AddAST(h ASTHandler) {
+	g.handlers = append(g.handlers, &handler{ast: h})
+}
+
+func (g *Generators) Len() int {
+	return len(g.handlers)
+}
+
+// HasAST returns true if any AST handler is added.
+func (g *Generators) HasAST() bool {
+	for _, h := range g.handlers {
+		if h.ast != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate calls the generators in order, until one of them returns the rollback
+// SQL or the reason. The SQL is parsed by parse once before the first AST handler
+// is called. Both are empty if the SQL isn't handled by any generator.
+func (g *Generators) Generate(ctx context.Context, conn *sql.Conn, rawSQL string,
+	parse func(string) (interface{}, error)) (string, string, error) {
+	var astSQL interface{}
+	for _, h := range g.handlers {
+		var (
+			rollbackSQL, reason string
+			err                 error
+		)
+		if h.raw != nil {
+			rollbackSQL, reason, err = h.raw(ctx, conn, rawSQL)
+		} else {
+			if astSQL == nil {
+				if astSQL, err = parse(rawSQL); err != nil {
+					return "", "", errors.Wrap(err, "parse sql")
+				}
+			}
+			rollbackSQL, reason, err = h.ast(ctx, conn, astSQL)
+		}
+		if err != nil {
+			return "", "", errors.Wrapf(err, "generate rollback SQL of %s", rawSQL)
+		}
+		if rollbackSQL != "" || reason != "" {
+			return rollbackSQL, reason, nil
+		}
+	}
+	return "", "", nil
+}
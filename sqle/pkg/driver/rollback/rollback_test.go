@@ -0,0 +1,85 @@
+package rollback
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerators_Generate(t *testing.T) {
+	calls := []string{}
+	raw := func(name, rollbackSQL, reason string) RawHandler {
+		return func(ctx context.Context, conn *sql.Conn, rawSQL string) (string, string, error) {
+			calls = append(calls, name)
+			return rollbackSQL, reason, nil
+		}
+	}
+	ast := func(name, rollbackSQL, reason string) ASTHandler {
+		return func(ctx context.Context, conn *sql.Conn, astSQL interface{}) (string, string, error) {
+			calls = append(calls, name+":"+astSQL.(string))
+			return rollbackSQL, reason, nil
+		}
+	}
+	parses := 0
+	parse := func(rawSQL string) (interface{}, error) {
+		parses++
+		return "parsed " + rawSQL, nil
+	}
+
+	// the generators are called in order until one of them returns the rollback SQL.
+	g := &Generators{}
+	g.AddRaw(raw("raw1", "", ""))
+	g.AddAST(ast("ast1", "", ""))
+	g.AddAST(ast("ast2", "rollback", ""))
+	g.AddRaw(raw("raw2", "", "reason"))
+	assert.Equal(t, 4, g.Len())
+	assert.True(t, g.HasAST())
+	rollbackSQL, reason, err := g.Generate(context.TODO(), nil, "sql", parse)
+	assert.NoError(t, err)
+	assert.Equal(t, "rollback", rollbackSQL)
+	assert.Equal(t, "", reason)
+	assert.Equal(t, []string{"raw1", "ast1:parsed sql", "ast2:parsed sql"}, calls)
+	// the SQL is parsed once.
+	assert.Equal(t, 1, parses)
+
+	// the SQL is not parsed if it's handled before the AST generators.
+	calls, parses = []string{}, 0
+	g = &Generators{}
+	g.AddRaw(raw("raw1", "", "reason"))
+	g.AddAST(ast("ast1", "rollback", ""))
+	rollbackSQL, reason, err = g.Generate(context.TODO(), nil, "sql", parse)
+	assert.NoError(t, err)
+	assert.Equal(t, "", rollbackSQL)
+	assert.Equal(t, "reason", reason)
+	assert.Equal(t, []string{"raw1"}, calls)
+	assert.Equal(t, 0, parses)
+
+	// both are empty if the SQL isn't handled by any generator.
+	calls = []string{}
+	g = &Generators{}
+	g.AddRaw(raw("raw1", "", ""))
+	assert.False(t, g.HasAST())
+	rollbackSQL, reason, err = g.Generate(context.TODO(), nil, "sql", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", rollbackSQL)
+	assert.Equal(t, "", reason)
+
+	// the error of parser and generator is returned.
+	g = &Generators{}
+	g.AddAST(ast("ast1", "rollback", ""))
+	_, _, err = g.Generate(context.TODO(), nil, "sql", func(string) (interface{}, error) {
+		return nil, errors.New("syntax error")
+	})
+	assert.EqualError(t, err, "parse sql: syntax error")
+
+	g = &Generators{}
+	g.AddRaw(func(ctx context.Context, conn *sql.Conn, rawSQL string) (string, string, error) {
+		return "", "", errors.New("connection lost")
+	})
+	g.AddRaw(raw("raw2", "rollback", ""))
+	_, _, err = g.Generate(context.TODO(), nil, "sql", nil)
+	assert.EqualError(t, err, "generate rollback SQL of sql: connection lost")
+}
@@ -235,8 +235,46 @@ func (mgr *Manager) loadAuditPlans() error {
 	return mgr.addAuditPlansToScheduler(aps)
 }
 
-// TODO: runJob is a async task, it's report should send by channel.
+// runJob audits the SQLs collected by the audit plan and returns the report after
+// the audit is finished.
 func (mgr *Manager) runJob(ap *model.AuditPlan) *model.AuditPlanReport {
+	task, auditPlanSQLs := mgr.createJobTask(ap)
+	if task == nil {
+		return nil
+	}
+
+	task, err := server.GetSqled().AddTaskWaitResult(fmt.Sprintf("%v", task.ID), server.ActionTypeAudit)
+	if err != nil {
+		mgr.logger.WithField("name", ap.Name).Errorf("audit task error:%v\n", err)
+		return nil
+	}
+	return mgr.saveJobReport(ap, task, auditPlanSQLs)
+}
+
+// runJobAsync is same as runJob, but it returns without waiting for the audit, the
+// report is saved after the audit is finished.
+func (mgr *Manager) runJobAsync(ap *model.AuditPlan) {
+	task, auditPlanSQLs := mgr.createJobTask(ap)
+	if task == nil {
+		return
+	}
+
+	err := server.GetSqled().AddTaskWithCallback(fmt.Sprintf("%v", task.ID), server.ActionTypeAudit,
+		func(task *model.Task, err error) {
+			if err != nil {
+				mgr.logger.WithField("name", ap.Name).Errorf("audit task error:%v\n", err)
+				return
+			}
+			mgr.saveJobReport(ap, task, auditPlanSQLs)
+		})
+	if err != nil {
+		mgr.logger.WithField("name", ap.Name).Errorf("audit task error:%v\n", err)
+	}
+}
+
+// createJobTask creates the task of the SQLs collected by the audit plan, it returns
+// nil if there is no SQL or the task is failed to create.
+func (mgr *Manager) createJobTask(ap *model.AuditPlan) (*model.Task, []*model.AuditPlanSQL) {
 	task := &model.Task{
 		Schema:       ap.InstanceDatabase,
 		CreateUserId: ap.CreateUserID,
@@ -248,12 +286,12 @@ func (mgr *Manager) runJob(ap *model.AuditPlan) *model.AuditPlanReport {
 	auditPlanSQLs, err := mgr.persist.GetAuditPlanSQLs(ap.Name)
 	if err != nil {
 		mgr.logger.WithField("name", ap.Name).Errorf("get audit plan SQLs error:%v\n", err)
-		return nil
+		return nil, nil
 	}
 
 	if len(auditPlanSQLs) == 0 {
 		mgr.logger.WithField("name", ap.Name).Warnf("skip audit, %v", errNoSQLInAuditPlan)
-		return nil
+		return nil, nil
 	}
 
 	for i, sql := range auditPlanSQLs {
@@ -268,7 +306,7 @@ func (mgr *Manager) runJob(ap *model.AuditPlan) *model.AuditPlanReport {
 	instance, _, err := mgr.persist.GetInstanceByName(ap.InstanceName)
 	if err != nil {
 		mgr.logger.WithField("name", ap.Name).Errorf("get instance error:%v\n", err)
-		return nil
+		return nil, nil
 	}
 
 	task.InstanceId = instance.ID
@@ -276,24 +314,25 @@ func (mgr *Manager) runJob(ap *model.AuditPlan) *model.AuditPlanReport {
 	err = mgr.persist.Save(task)
 	if err != nil {
 		mgr.logger.WithField("name", ap.Name).Errorf("save audit plan task error:%v\n", err)
-		return nil
-	}
-
-	task, err = server.GetSqled().AddTaskWaitResult(fmt.Sprintf("%v", task.ID), server.ActionTypeAudit)
-	if err != nil {
-		mgr.logger.WithField("name", ap.Name).Errorf("audit task error:%v\n", err)
-		return nil
+		return nil, nil
 	}
+	return task, auditPlanSQLs
+}
 
+// saveJobReport saves the report of the audited task, the SQLs of task are in the
+// same order as auditPlanSQLs.
+func (mgr *Manager) saveJobReport(ap *model.AuditPlan, task *model.Task,
+	auditPlanSQLs []*model.AuditPlanSQL) *model.AuditPlanReport {
 	auditPlanReport := &model.AuditPlanReport{AuditPlanID: ap.ID}
 	for i, executeSQL := range task.ExecuteSQLs {
 		auditPlanReport.AuditPlanReportSQLs = append(auditPlanReport.AuditPlanReportSQLs, &model.AuditPlanReportSQL{
 			AuditPlanSQLID: auditPlanSQLs[i].ID,
 			AuditResult:    executeSQL.AuditResult,
+			AuditResults:   executeSQL.AuditResults,
 		})
 	}
 
-	err = mgr.persist.Save(auditPlanReport)
+	err := mgr.persist.Save(auditPlanReport)
 	if err != nil {
 		mgr.logger.WithField("name", ap.Name).Errorf("save audit plan report error:%v\n", err)
 		return nil
@@ -307,7 +346,7 @@ func (mgr *Manager) addAuditPlansToScheduler(aps []*model.AuditPlan) error {
 		ap := v
 
 		err := mgr.scheduler.addJob(ap, func() {
-			mgr.runJob(ap)
+			mgr.runJobAsync(ap)
 		})
 		if err != nil {
 			return err
@@ -1,10 +1,13 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/actiontech/sqle/sqle/driver"
 	"github.com/actiontech/sqle/sqle/log"
 	"github.com/actiontech/sqle/sqle/model"
 
@@ -20,6 +23,7 @@ func (s *Sqled) cleanLoop() {
 	entry := log.NewEntry().WithField("type", "cron")
 	s.CleanExpiredWorkflows(entry)
 	s.CleanExpiredTasks(entry)
+	s.CleanExpiredRollbackRetentions(entry)
 	for {
 		select {
 		case <-s.exit:
@@ -27,6 +31,7 @@ func (s *Sqled) cleanLoop() {
 		case <-tick:
 			s.CleanExpiredWorkflows(entry)
 			s.CleanExpiredTasks(entry)
+			s.CleanExpiredRollbackRetentions(entry)
 		}
 	}
 }
@@ -81,3 +86,46 @@ func (s *Sqled) CleanExpiredTasks(entry *logrus.Entry) {
 		entry.Infof("clean task [%s] success", strings.Join(hasDeletedTaskIds, ", "))
 	}
 }
+
+// CleanExpiredRollbackRetentions cleans the data kept on instances for rollback after
+// the retention, such as the old tables kept by gh-ost. The retention is cleaned again
+// in next loop if it fails.
+func (s *Sqled) CleanExpiredRollbackRetentions(entry *logrus.Entry) {
+	st := model.GetStorage()
+	retentions, err := st.GetExpiredRollbackRetentions(time.Now())
+	if err != nil {
+		entry.Errorf("get rollback retentions from storage error: %v", err)
+		return
+	}
+	for _, retention := range retentions {
+		if err := cleanRollbackRetention(entry, retention); err != nil {
+			entry.Errorf("clean rollback retention %d of task %d error: %v", retention.ID, retention.TaskId, err)
+			continue
+		}
+		if err := st.HardDelete(retention); err != nil {
+			entry.Errorf("delete rollback retention %d error: %v", retention.ID, err)
+			continue
+		}
+		entry.Infof("clean rollback retention %d of task %d success, %s", retention.ID, retention.TaskId, retention.CleanSQL)
+	}
+}
+
+func cleanRollbackRetention(entry *logrus.Entry, retention *model.RollbackRetention) error {
+	instance, exist, err := model.GetStorage().GetInstanceById(fmt.Sprintf("%v", retention.InstanceId))
+	if err != nil {
+		return err
+	}
+	if !exist {
+		// the data is gone with the instance.
+		entry.Warnf("instance %d of rollback retention %d is not exist", retention.InstanceId, retention.ID)
+		return nil
+	}
+	d, err := newDriverWithAudit(entry, instance, retention.Schema, instance.DbType)
+	if err != nil {
+		return err
+	}
+	defer d.Close(context.TODO())
+	// the tables dropped by clean SQL are not moved to recycle bin again.
+	_, err = d.Exec(driver.WithRawExec(context.TODO()), retention.CleanSQL)
+	return err
+}
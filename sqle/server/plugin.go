@@ -0,0 +1,56 @@
+package server
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+)
+
+// pluginWatchInterval is the interval of checking the changes of plugins directory.
+const pluginWatchInterval = 10 * time.Second
+
+// ReloadPlugins reloads the plugins at plugins directory, and creates the rules
+// and default rule template of loaded and upgraded drivers.
+func ReloadPlugins(retryFailed bool) (*driver.PluginReloadResult, error) {
+	result, err := driver.ReloadPlugins(retryFailed)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rules) == 0 {
+		return result, nil
+	}
+
+	s := model.GetStorage()
+	if err := s.CreateRulesIfNotExist(result.Rules); err != nil {
+		return nil, err
+	}
+	if err := s.CreateDefaultTemplate(result.Rules); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// InitPluginWatcher reloads the plugins once the binaries at plugins directory are
+// added, changed or removed.
+func InitPluginWatcher() chan struct{} {
+	logger := log.NewEntry().WithField("type", "plugin_watcher")
+	exitCh := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(pluginWatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-exitCh:
+				logger.Infoln("plugin watcher stopped")
+				return
+			case <-ticker.C:
+				if _, err := ReloadPlugins(false); err != nil {
+					logger.Errorf("reload plugins error: %v", err)
+				}
+			}
+		}
+	}()
+	return exitCh
+}
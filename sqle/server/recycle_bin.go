@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RestoreRecycleBinObject moves the tables of the dropped object back from the recycle
+// bin by its rollback SQL. The rollback SQL is marked as executed and the object is
+// removed from recycle bin after restored.
+func (s *Sqled) RestoreRecycleBinObject(entry *logrus.Entry, instance *model.Instance,
+	object *model.RollbackRetention) error {
+
+	taskId := fmt.Sprintf("%d", object.TaskId)
+	if s.HasTask(taskId) {
+		return errors.New(errors.TaskRunning, fmt.Errorf("task %s of recycle bin object is running", taskId))
+	}
+	st := model.GetStorage()
+	rollbackSQL, exist, err := st.GetRollbackSQLById(taskId, fmt.Sprintf("%d", object.RollbackSQLId))
+	if err != nil {
+		return err
+	}
+	if !exist || rollbackSQL.Content == "" {
+		return errors.New(errors.DataNotExist, fmt.Errorf("rollback SQL of recycle bin object is not exist"))
+	}
+	if rollbackSQL.ExecStatus != model.SQLExecuteStatusInitialized {
+		return errors.New(errors.TaskActionDone, ErrActionRollbackOnRollbackedTask)
+	}
+
+	d, err := newDriverWithAudit(entry, instance, object.Schema, instance.DbType)
+	if err != nil {
+		return err
+	}
+	defer d.Close(context.TODO())
+	nodes, err := d.Parse(context.TODO(), rollbackSQL.Content)
+	if err != nil {
+		return err
+	}
+	ctx := driver.WithRawExec(context.TODO())
+	for _, node := range nodes {
+		if _, err := d.Exec(ctx, node.Text); err != nil {
+			return err
+		}
+	}
+	entry.Infof("recycle bin object %s is restored: %s", object.RecycleBinObject, rollbackSQL.Content)
+
+	rollbackSQL.ExecStatus = model.SQLExecuteStatusSucceeded
+	rollbackSQL.ExecResult = "restored from recycle bin"
+	if err := st.Save(rollbackSQL); err != nil {
+		return err
+	}
+	return st.HardDelete(object)
+}
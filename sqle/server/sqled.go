@@ -4,9 +4,14 @@ import (
 	"context"
 	_errors "errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
+	"github.com/actiontech/sqle/sqle/config"
 	"github.com/actiontech/sqle/sqle/driver"
 	_ "github.com/actiontech/sqle/sqle/driver/mysql"
 	"github.com/actiontech/sqle/sqle/errors"
@@ -31,22 +36,62 @@ type Sqled struct {
 	sync.Mutex
 	// exit is Sqled service exit signal.
 	exit chan struct{}
-	// currentTask record the current task before execution,
+	// currentTask record the current task and its action before execution,
 	// and delete it after execution.
-	currentTask map[string]struct{}
+	currentTask map[string]*action
 	// queue is a chan used to receive tasks.
 	queue chan *action
+	// finished is a chan used to receive the actions which are done,
+	// then the worker of the action can be used by pending actions.
+	finished chan *action
+
+	// pending keep the actions which are waiting for worker in FIFO order.
+	pending []*action
+	// running keep the actions which are running.
+	running map[*action]struct{}
+
+	maxWorkers          int
+	maxAuditWorkers     int
+	maxExecsPerInstance int
 }
 
-func InitSqled(exit chan struct{}) {
-	sqled = &Sqled{
-		exit:        exit,
-		currentTask: map[string]struct{}{},
-		queue:       make(chan *action, 1024),
-	}
+const (
+	DefaultMaxTaskWorkers          = 32
+	DefaultMaxAuditTaskWorkers     = 16
+	DefaultMaxExecTasksPerInstance = 1
+)
+
+func InitSqled(exit chan struct{}, cfg config.SqleConfig) {
+	sqled = newSqled(exit, cfg)
 	sqled.Start()
 }
 
+func newSqled(exit chan struct{}, cfg config.SqleConfig) *Sqled {
+	s := &Sqled{
+		exit:                exit,
+		currentTask:         map[string]*action{},
+		queue:               make(chan *action, 1024),
+		finished:            make(chan *action, 1024),
+		running:             map[*action]struct{}{},
+		maxWorkers:          cfg.MaxTaskWorkers,
+		maxAuditWorkers:     cfg.MaxAuditTaskWorkers,
+		maxExecsPerInstance: cfg.MaxExecTasksPerInstance,
+	}
+	if s.maxWorkers <= 0 {
+		s.maxWorkers = DefaultMaxTaskWorkers
+	}
+	if s.maxAuditWorkers <= 0 {
+		s.maxAuditWorkers = DefaultMaxAuditTaskWorkers
+	}
+	if s.maxAuditWorkers > s.maxWorkers {
+		s.maxAuditWorkers = s.maxWorkers
+	}
+	if s.maxExecsPerInstance <= 0 {
+		s.maxExecsPerInstance = DefaultMaxExecTasksPerInstance
+	}
+	return s
+}
+
 func (s *Sqled) HasTask(taskId string) bool {
 	s.Lock()
 	_, ok := s.currentTask[taskId]
@@ -55,24 +100,37 @@ func (s *Sqled) HasTask(taskId string) bool {
 }
 
 // addTask receive taskId and action type, using taskId and typ to create an action;
-// action will be validated, and sent to Sqled.queue.
+// action will be validated, persisted and sent to Sqled.queue.
 func (s *Sqled) addTask(taskId string, typ int) (*action, error) {
+	return s.addAction(taskId, &model.TaskAction{Type: typ, Status: model.TaskActionStatusQueued})
+}
+
+// addAction is same as addTask, and the record is the persisted action. The record
+// which is recovered from storage has been saved, a new record is saved here.
+func (s *Sqled) addAction(taskId string, record *model.TaskAction) (*action, error) {
 	var err error
 	var d driver.Driver
 	entry := log.NewEntry().WithField("task_id", taskId)
+	controller := &driver.ExecController{}
+	ctx, cancel := context.WithCancel(driver.WithExecController(context.Background(), controller))
 	action := &action{
-		typ:   typ,
-		entry: entry,
-		done:  make(chan struct{}),
+		typ:            record.Type,
+		rollbackSQLIds: record.Params.RollbackSQLIds,
+		entry:          entry,
+		ctx:            ctx,
+		cancel:         cancel,
+		controller:     controller,
+		done:           make(chan struct{}),
 	}
 
 	s.Lock()
 	_, taskRunning := s.currentTask[taskId]
 	if !taskRunning {
-		s.currentTask[taskId] = struct{}{}
+		s.currentTask[taskId] = action
 	}
 	s.Unlock()
 	if taskRunning {
+		cancel()
 		return action, errors.New(errors.TaskRunning, fmt.Errorf("task is running"))
 	}
 
@@ -96,21 +154,91 @@ func (s *Sqled) addTask(taskId string, typ int) (*action, error) {
 	}
 	action.driver = d
 
+	if record.ID == 0 {
+		record.TaskId = task.ID
+		if err = model.GetStorage().Save(record); err != nil {
+			d.Close(context.TODO())
+			goto Error
+		}
+	}
+	action.record = record
+
 	s.queue <- action
 	return action, nil
 
 Error:
+	cancel()
 	s.Lock()
 	delete(s.currentTask, taskId)
 	s.Unlock()
 	return action, err
 }
 
+// CancelTask cancels the action of the task. The pending action will not be done,
+// and the running action will be stopped, the SQL which is executing is killed.
+func (s *Sqled) CancelTask(taskId string) error {
+	s.Lock()
+	action, ok := s.currentTask[taskId]
+	var pending bool
+	if ok {
+		for i, a := range s.pending {
+			if a == action {
+				s.pending = append(s.pending[:i], s.pending[i+1:]...)
+				pending = true
+				break
+			}
+		}
+	}
+	s.Unlock()
+	if !ok {
+		return errors.New(errors.TaskActionInvalid, ErrActionNotRunning)
+	}
+
+	action.entry.Warn("action is cancelled")
+	action.cancel()
+	// the pending action has no worker, do it to finish it as cancelled.
+	if pending {
+		go s.do(action)
+	}
+	return nil
+}
+
+// SendExecCommand sends the command to the SQL which is executing by the action of
+// the task, such as throttling the online DDL.
+func (s *Sqled) SendExecCommand(taskId string, cmd driver.ExecCommand) error {
+	s.Lock()
+	action, ok := s.currentTask[taskId]
+	s.Unlock()
+	if !ok {
+		return errors.New(errors.TaskActionInvalid, ErrActionNotExecuting)
+	}
+
+	if err := action.controller.Send(cmd); err != nil {
+		if _errors.Is(err, driver.ErrExecCommandNotSupported) {
+			return errors.New(errors.TaskActionInvalid, err)
+		}
+		return err
+	}
+	action.entry.Infof("command %s is sent to the executing SQL", cmd)
+	return nil
+}
+
 func (s *Sqled) AddTask(taskId string, typ int) error {
 	_, err := s.addTask(taskId, typ)
 	return err
 }
 
+// AddRollbackTask rollbacks the task with the chosen rollback SQLs, all rollback
+// SQLs are rollbacked if rollbackSQLIds is empty.
+func (s *Sqled) AddRollbackTask(taskId string, rollbackSQLIds []uint) error {
+	_, err := s.addAction(taskId, &model.TaskAction{
+		Type:   ActionTypeRollback,
+		Status: model.TaskActionStatusQueued,
+		Params: model.TaskActionParams{RollbackSQLIds: rollbackSQLIds},
+	})
+	return err
+}
+
 func (s *Sqled) AddTaskWaitResult(taskId string, typ int) (*model.Task, error) {
 	action, err := s.addTask(taskId, typ)
 	if err != nil {
@@ -120,9 +248,118 @@ func (s *Sqled) AddTaskWaitResult(taskId string, typ int) (*model.Task, error) {
 	return action.task, action.err
 }
 
+// AddTaskWithCallback adds the action of the task without waiting for it, callback is
+// called with the task and the error of the action after the action is done if it's
+// not nil.
+func (s *Sqled) AddTaskWithCallback(taskId string, typ int, callback func(task *model.Task, err error)) error {
+	action, err := s.addTask(taskId, typ)
+	if err != nil || callback == nil {
+		return err
+	}
+	go func() {
+		<-action.done
+		callback(action.task, action.err)
+	}()
+	return nil
+}
+
+// EnvGracefulRestartPid is set to the pid of old sqled when sqled is restarted
+// gracefully, the new sqled recovers the actions after the old one exits.
+const EnvGracefulRestartPid = "SQLED_GRACEFUL_RESTART_PID"
+
 func (s *Sqled) Start() {
 	go s.taskLoop()
 	go s.cleanLoop()
+
+	pid, _ := strconv.Atoi(os.Getenv(EnvGracefulRestartPid))
+	if pid == 0 {
+		s.recoverActions()
+		return
+	}
+	os.Unsetenv(EnvGracefulRestartPid)
+	// the actions may be still running by the old sqled, they are not recovered
+	// until the old sqled exits.
+	go func() {
+		waitForProcessExit(pid)
+		s.recoverActions()
+	}()
+}
+
+func waitForProcessExit(pid int) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := syscall.Kill(pid, 0); err == syscall.ESRCH {
+			return
+		}
+	}
+}
+
+// recoverActions re-queues the actions which are not finished when sqled exits last time.
+// The execute and rollback actions which were running are not safe to redo, so these tasks
+// are marked as interrupted.
+func (s *Sqled) recoverActions() {
+	entry := log.NewEntry().WithField("type", "recover")
+	st := model.GetStorage()
+
+	records, err := st.GetUnfinishedTaskActions()
+	if err != nil {
+		entry.Errorf("get unfinished task actions error: %v", err)
+		return
+	}
+	for _, record := range records {
+		taskId := fmt.Sprintf("%d", record.TaskId)
+		// the action is added by this sqled after it started.
+		if s.HasTask(taskId) {
+			continue
+		}
+		if record.Status == model.TaskActionStatusDoing && record.Type != ActionTypeAudit {
+			if err := st.InterruptTaskExecution(record.TaskId, ErrActionInterrupted.Error()); err != nil {
+				entry.Errorf("interrupt task %s error: %v", taskId, err)
+				continue
+			}
+			if err := st.UpdateTaskActionStatus(record, model.TaskActionStatusInterrupted,
+				ErrActionInterrupted.Error()); err != nil {
+				entry.Errorf("update action of task %s error: %v", taskId, err)
+			}
+			entry.Warnf("task %s is interrupted", taskId)
+			continue
+		}
+
+		// audit is safe to redo.
+		if record.Status == model.TaskActionStatusDoing {
+			if err := st.UpdateTaskActionStatus(record, model.TaskActionStatusQueued, ""); err != nil {
+				entry.Errorf("update action of task %s error: %v", taskId, err)
+				continue
+			}
+		}
+		if _, err := s.addAction(taskId, record); err != nil {
+			entry.Errorf("recover action of task %s error: %v", taskId, err)
+			if err := st.UpdateTaskActionStatus(record, model.TaskActionStatusFinished, err.Error()); err != nil {
+				entry.Errorf("update action of task %s error: %v", taskId, err)
+			}
+			continue
+		}
+		entry.Infof("action of task %s is recovered", taskId)
+	}
+
+	// the task may be executed by sqled without persisted actions.
+	tasks, err := st.GetTasksByStatus(model.TaskStatusExecuting)
+	if err != nil {
+		entry.Errorf("get executing tasks error: %v", err)
+		return
+	}
+	for _, task := range tasks {
+		taskId := fmt.Sprintf("%d", task.ID)
+		if s.HasTask(taskId) {
+			continue
+		}
+		if err := st.InterruptTaskExecution(task.ID, ErrActionInterrupted.Error()); err != nil {
+			entry.Errorf("interrupt task %s error: %v", taskId, err)
+			continue
+		}
+		entry.Warnf("task %s is interrupted", taskId)
+	}
 }
 
 // taskLoop is a task loop used to receive action from queue.
@@ -132,25 +369,161 @@ func (s *Sqled) taskLoop() {
 		case <-s.exit:
 			return
 		case action := <-s.queue:
+			s.Lock()
+			s.pending = append(s.pending, action)
+			s.Unlock()
+		case action := <-s.finished:
+			s.Lock()
+			delete(s.running, action)
+			s.Unlock()
+		}
+		for _, action := range s.schedule() {
 			go s.do(action)
 		}
 	}
 }
 
+// schedule picks the pending actions which can run now and moves them to running.
+// The number of running actions is limited by maxWorkers, the audit actions are limited
+// by maxAuditWorkers, and the execute and rollback actions on the same instance are
+// limited by maxExecsPerInstance.
+func (s *Sqled) schedule() []*action {
+	s.Lock()
+	defer s.Unlock()
+
+	auditNum := 0
+	execNums := map[uint]int{}
+	for action := range s.running {
+		if action.typ == ActionTypeAudit {
+			auditNum++
+		} else {
+			execNums[action.task.InstanceId]++
+		}
+	}
+
+	var ready []*action
+	pending := s.pending[:0]
+	for _, action := range s.pending {
+		if len(s.running) >= s.maxWorkers {
+			pending = append(pending, action)
+			continue
+		}
+		if action.typ == ActionTypeAudit {
+			if auditNum >= s.maxAuditWorkers {
+				pending = append(pending, action)
+				continue
+			}
+			auditNum++
+		} else {
+			if execNums[action.task.InstanceId] >= s.maxExecsPerInstance {
+				pending = append(pending, action)
+				continue
+			}
+			execNums[action.task.InstanceId]++
+		}
+		s.running[action] = struct{}{}
+		ready = append(ready, action)
+	}
+	s.pending = pending
+	return ready
+}
+
+type QueueStatus struct {
+	PendingAuditNum   int
+	RunningAuditNum   int
+	PendingExecuteNum int
+	RunningExecuteNum int
+	MaxWorkers        int
+	MaxAuditWorkers   int
+	// Instances keep execute and rollback actions status on each instance.
+	Instances []*InstanceQueueStatus
+}
+
+type InstanceQueueStatus struct {
+	InstanceName string
+	PendingNum   int
+	RunningNum   int
+}
+
+// QueueStatus returns the number of pending and running actions.
+func (s *Sqled) QueueStatus() *QueueStatus {
+	s.Lock()
+	defer s.Unlock()
+
+	status := &QueueStatus{
+		MaxWorkers:      s.maxWorkers,
+		MaxAuditWorkers: s.maxAuditWorkers,
+		Instances:       []*InstanceQueueStatus{},
+	}
+	instances := map[uint]*InstanceQueueStatus{}
+	getInstance := func(action *action) *InstanceQueueStatus {
+		inst, ok := instances[action.task.InstanceId]
+		if !ok {
+			inst = &InstanceQueueStatus{InstanceName: action.task.InstanceName()}
+			instances[action.task.InstanceId] = inst
+			status.Instances = append(status.Instances, inst)
+		}
+		return inst
+	}
+	for _, action := range s.pending {
+		if action.typ == ActionTypeAudit {
+			status.PendingAuditNum++
+			continue
+		}
+		status.PendingExecuteNum++
+		getInstance(action).PendingNum++
+	}
+	for action := range s.running {
+		if action.typ == ActionTypeAudit {
+			status.RunningAuditNum++
+			continue
+		}
+		status.RunningExecuteNum++
+		getInstance(action).RunningNum++
+	}
+	return status
+}
+
 func (s *Sqled) do(action *action) error {
-	var err error
-	switch action.typ {
-	case ActionTypeAudit:
-		err = action.audit()
-	case ActionTypeExecute:
-		err = action.execute()
-	case ActionTypeRollback:
-		err = action.rollback()
+	st := model.GetStorage()
+	claimed, err := st.ClaimTaskAction(action.record)
+	if err != nil || !claimed {
+		if err == nil {
+			action.entry.Warn("action has been done by others, skip it")
+		}
+		action.err = err
+		s.finish(action)
+		return err
+	}
+
+	if action.ctx.Err() != nil {
+		err = ErrActionCancelled
+	} else {
+		switch action.typ {
+		case ActionTypeAudit:
+			err = action.audit()
+		case ActionTypeExecute, ActionTypeResumeExecute, ActionTypeRetryExecute:
+			err = action.execute()
+		case ActionTypeRollback:
+			err = action.rollback()
+		}
 	}
+	var errMsg string
 	if err != nil {
 		action.err = err
+		errMsg = err.Error()
+	}
+	if err := st.UpdateTaskActionStatus(action.record, model.TaskActionStatusFinished, errMsg); err != nil {
+		action.entry.Errorf("update action status error: %v", err)
 	}
 
+	s.finish(action)
+	return err
+}
+
+// finish releases the resources of action and notifies the waiter.
+func (s *Sqled) finish(action *action) {
+	action.cancel()
 	action.driver.Close(context.TODO())
 
 	s.Lock()
@@ -158,17 +531,25 @@ func (s *Sqled) do(action *action) error {
 	delete(s.currentTask, taskId)
 	s.Unlock()
 
+	close(action.done)
+
 	select {
-	case action.done <- struct{}{}:
-	default:
+	case s.finished <- action:
+	case <-s.exit:
 	}
-	return err
 }
 
 const (
 	ActionTypeAudit = iota + 1
 	ActionTypeExecute
 	ActionTypeRollback
+	// ActionTypeResumeExecute executes the SQLs which have not been executed
+	// on the task which is executed failed, the failed SQLs are skipped.
+	ActionTypeResumeExecute
+	// ActionTypeRetryExecute executes the failed or cancelled SQLs and the SQLs
+	// which have not been executed on the task which is executed failed. The
+	// interrupted SQLs are skipped, they may have been executed.
+	ActionTypeRetryExecute
 )
 
 // Action is an action for the task;
@@ -182,8 +563,22 @@ type action struct {
 	task  *model.Task
 	entry *logrus.Entry
 
+	// record is the persisted action.
+	record *model.TaskAction
+
+	// ctx is cancelled when the action is cancelled, it's passed to driver.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// controller sends the commands of user to the SQL which is executing.
+	controller *driver.ExecController
+
 	// typ is action type.
-	typ  int
+	typ int
+
+	// rollbackSQLIds is the rollback SQLs chosen by rollback action.
+	rollbackSQLIds []uint
+
 	err  error
 	done chan struct{}
 }
@@ -194,6 +589,15 @@ var (
 	ErrActionRollbackOnRollbackedTask    = _errors.New("task has been rollbacked, can not do rollback on it")
 	ErrActionRollbackOnExecuteFailedTask = _errors.New("task has been executed failed, can not do rollback on it")
 	ErrActionRollbackOnNonExecutedTask   = _errors.New("task has not been executed, can not do rollback on it")
+	ErrActionInterrupted                 = _errors.New("sqled exited while the action was running, the action is interrupted")
+	ErrActionCancelled                   = _errors.New("action is cancelled")
+	ErrActionNotRunning                  = _errors.New("task has no action running or waiting, can not cancel it")
+	ErrActionNotExecuting                = _errors.New("task has no action executing, can not send command to it")
+	ErrActionResumeOnNonFailedTask       = _errors.New("task has not been executed failed, can not resume or retry it")
+	ErrActionResumeOnRollbackedTask      = _errors.New("task has been rollbacked, can not resume or retry it")
+	ErrActionResumeWithoutSQL            = _errors.New("task has no SQL to resume or retry")
+	ErrActionRollbackSQLNotExist         = _errors.New("rollback SQL is not exist in task")
+	ErrActionRollbackWithoutSQL          = _errors.New("task has no rollback SQL to rollback")
 )
 
 // validation validate whether task can do action type(a.typ) or not.
@@ -210,7 +614,8 @@ func (a *action) validation(task *model.Task) error {
 			return errors.New(errors.TaskActionInvalid, ErrActionExecuteOnNonAuditedTask)
 		}
 	case ActionTypeRollback:
-		if task.HasDoingRollback() {
+		// the rollback SQLs which are not chosen can still be rollbacked later.
+		if len(a.rollbackSQLIds) == 0 && task.HasDoingRollback() {
 			return errors.New(errors.TaskActionDone, ErrActionRollbackOnRollbackedTask)
 		}
 		if task.IsExecuteFailed() {
@@ -219,37 +624,132 @@ func (a *action) validation(task *model.Task) error {
 		if !task.HasDoingExecute() {
 			return errors.New(errors.TaskActionInvalid, ErrActionRollbackOnNonExecutedTask)
 		}
+		rollbackSQLs, err := a.getRollbackSQLs(task)
+		if err != nil {
+			return err
+		}
+		if len(rollbackSQLs) == 0 {
+			return errors.New(errors.TaskActionInvalid, ErrActionRollbackWithoutSQL)
+		}
+	case ActionTypeResumeExecute, ActionTypeRetryExecute:
+		switch task.Status {
+		case model.TaskStatusExecuteFailed, model.TaskStatusExecuteInterrupted, model.TaskStatusExecuteCancelled:
+		default:
+			return errors.New(errors.TaskActionInvalid, ErrActionResumeOnNonFailedTask)
+		}
+		if task.HasDoingRollback() {
+			return errors.New(errors.TaskActionDone, ErrActionResumeOnRollbackedTask)
+		}
+		if len(a.getExecuteSQLs(task)) == 0 {
+			return errors.New(errors.TaskActionInvalid, ErrActionResumeWithoutSQL)
+		}
 	}
 	return nil
 }
 
-func (a *action) audit() (err error) {
-	st := model.GetStorage()
-	task := a.task
+// getExecuteSQLs returns the SQLs which will be executed by the action in order.
+func (a *action) getExecuteSQLs(task *model.Task) []*model.ExecuteSQL {
+	executeSQLs := []*model.ExecuteSQL{}
+	for _, executeSQL := range task.ExecuteSQLs {
+		switch a.typ {
+		case ActionTypeResumeExecute:
+			if executeSQL.ExecStatus != model.SQLExecuteStatusInitialized {
+				continue
+			}
+		case ActionTypeRetryExecute:
+			switch executeSQL.ExecStatus {
+			case model.SQLExecuteStatusInitialized, model.SQLExecuteStatusFailed, model.SQLExecuteStatusCancelled:
+			default:
+				continue
+			}
+		}
+		executeSQLs = append(executeSQLs, executeSQL)
+	}
+	return executeSQLs
+}
 
-	whitelist, _, err := st.GetSqlWhitelist(0, 0)
-	if err != nil {
-		return err
+// getRollbackSQLs returns the rollback SQLs which will be executed by the action in order.
+// The rollback SQLs are executed in reverse order of the execute SQLs, so the latest
+// change is rollbacked first.
+func (a *action) getRollbackSQLs(task *model.Task) ([]*model.RollbackSQL, error) {
+	chosen := map[uint]bool{}
+	for _, id := range a.rollbackSQLIds {
+		chosen[id] = false
 	}
-	for _, executeSQL := range task.ExecuteSQLs {
-		nodes, err := a.driver.Parse(context.TODO(), executeSQL.Content)
+	rollbackSQLs := []*model.RollbackSQL{}
+	for i := len(task.RollbackSQLs) - 1; i >= 0; i-- {
+		rollbackSQL := task.RollbackSQLs[i]
+		if len(chosen) > 0 {
+			if _, ok := chosen[rollbackSQL.ID]; !ok {
+				continue
+			}
+			chosen[rollbackSQL.ID] = true
+			if rollbackSQL.ExecStatus != model.SQLExecuteStatusInitialized {
+				return nil, errors.New(errors.TaskActionDone, ErrActionRollbackOnRollbackedTask)
+			}
+		}
+		if rollbackSQL.Content == "" {
+			continue
+		}
+		rollbackSQLs = append(rollbackSQLs, rollbackSQL)
+	}
+	for _, found := range chosen {
+		if !found {
+			return nil, errors.New(errors.TaskActionInvalid, ErrActionRollbackSQLNotExist)
+		}
+	}
+	return rollbackSQLs, nil
+}
+
+// auditBatchSize is the count of SQLs audited by driver in one batch, the audit
+// progress of task is updated after each batch. The batch is also limited to
+// auditBatchMaxBytes of SQL content, so the request to the driver plugin is kept
+// under the gRPC message size limit.
+const (
+	auditBatchSize     = 100
+	auditBatchMaxBytes = 1 << 20
+)
+
+// auditBatchEnd returns the end of the batch starting at start, the batch contains
+// one SQL at least.
+func auditBatchEnd(executeSQLs []*model.ExecuteSQL, start int) int {
+	end, size := start, 0
+	for end < len(executeSQLs) && end-start < auditBatchSize {
+		size += len(executeSQLs[end].Content)
+		if end > start && size > auditBatchMaxBytes {
+			break
+		}
+		end++
+	}
+	return end
+}
+
+// auditBatch audits the execute SQLs by one call of driver, the SQLs matched by
+// whitelist are not sent to driver.
+func (a *action) auditBatch(whitelist []model.SqlWhitelist, executeSQLs []*model.ExecuteSQL) ([]*driver.AuditResult, error) {
+	fingerprints := make([]string, len(executeSQLs))
+	results := make([]*driver.AuditResult, len(executeSQLs))
+	auditSQLs := []string{}
+	for idx, executeSQL := range executeSQLs {
+		nodes, err := a.driver.Parse(a.ctx, executeSQL.Content)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if len(nodes) != 1 {
-			return driver.ErrNodesCountExceedOne
+			return nil, driver.ErrNodesCountExceedOne
 		}
+		fingerprints[idx] = nodes[0].Fingerprint
 
 		var whitelistMatch bool
 		for _, wl := range whitelist {
 			if wl.MatchType == model.SQLWhitelistFPMatch {
-				wlNodes, err := a.driver.Parse(context.TODO(), wl.Value)
+				wlNodes, err := a.driver.Parse(a.ctx, wl.Value)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				if len(wlNodes) != 1 {
-					return driver.ErrNodesCountExceedOne
+					return nil, driver.ErrNodesCountExceedOne
 				}
 
 				if nodes[0].Fingerprint == wlNodes[0].Fingerprint {
@@ -262,26 +762,87 @@ func (a *action) audit() (err error) {
 			}
 		}
 
-		result := driver.NewInspectResults()
 		if whitelistMatch {
+			result := driver.NewInspectResults()
 			result.Add(driver.RuleLevelNormal, "白名单")
+			results[idx] = result
 		} else {
-			result, err = a.driver.Audit(context.TODO(), executeSQL.Content)
-			if err != nil {
-				return err
+			auditSQLs = append(auditSQLs, executeSQL.Content)
+		}
+	}
+
+	if len(auditSQLs) > 0 {
+		auditResults, err := a.driver.AuditBatch(a.ctx, auditSQLs)
+		if err != nil {
+			return nil, err
+		}
+		if len(auditResults) != len(auditSQLs) {
+			return nil, fmt.Errorf("audit batch: got %d results of %d SQLs", len(auditResults), len(auditSQLs))
+		}
+		for idx := range results {
+			if results[idx] == nil {
+				results[idx], auditResults = auditResults[0], auditResults[1:]
 			}
 		}
+	}
 
+	for idx, executeSQL := range executeSQLs {
+		result := results[idx]
 		executeSQL.AuditStatus = model.SQLAuditStatusFinished
-		executeSQL.AuditLevel = string(result.Level())
-		executeSQL.AuditResult = result.Message()
-		executeSQL.AuditFingerprint = utils.Md5String(string(append([]byte(result.Message()), []byte(nodes[0].Fingerprint)...)))
+		executeSQL.SetAuditResult(result)
+		executeSQL.AuditFingerprint = utils.Md5String(string(append([]byte(result.Message()), []byte(fingerprints[idx])...)))
 
 		a.entry.WithFields(logrus.Fields{
 			"SQL":    executeSQL.Content,
 			"level":  executeSQL.AuditLevel,
 			"result": executeSQL.AuditResult}).Info("audit finished")
 	}
+	return results, nil
+}
+
+func (a *action) audit() (err error) {
+	st := model.GetStorage()
+	task := a.task
+
+	task.Status, task.AuditedSQLCount = model.TaskStatusAuditing, 0
+	if err = st.UpdateTask(task, map[string]interface{}{
+		"audited_sql_count": task.AuditedSQLCount,
+		"status":            task.Status,
+	}); err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		task.Status = model.TaskStatusAuditFailed
+		if err := st.UpdateTask(task, map[string]interface{}{"status": task.Status}); err != nil {
+			a.entry.Errorf("update task error:%v", err)
+		}
+	}()
+
+	whitelist, _, err := st.GetSqlWhitelist(0, 0)
+	if err != nil {
+		return err
+	}
+	// auditResults keeps the driver audit result of each execute SQL, then
+	// the reason of rollback SQL can be added to it as a new finding.
+	auditResults := make([]*driver.AuditResult, 0, len(task.ExecuteSQLs))
+	for start, end := 0, 0; start < len(task.ExecuteSQLs); start = end {
+		end = auditBatchEnd(task.ExecuteSQLs, start)
+		results, err := a.auditBatch(whitelist, task.ExecuteSQLs[start:end])
+		if err != nil {
+			return err
+		}
+		auditResults = append(auditResults, results...)
+
+		task.AuditedSQLCount = uint(end)
+		if end < len(task.ExecuteSQLs) {
+			if err = st.UpdateTask(task, map[string]interface{}{"audited_sql_count": task.AuditedSQLCount}); err != nil {
+				return err
+			}
+		}
+	}
 
 	// skip generate if audit is static
 	if task.SQLSource == model.TaskSQLSourceFromMyBatisXMLFile || task.InstanceId == 0 {
@@ -293,30 +854,14 @@ func (a *action) audit() (err error) {
 		}
 		defer d.Close(context.TODO())
 
-		var rollbackSQLs []*model.RollbackSQL
-		for _, executeSQL := range task.ExecuteSQLs {
-			rollbackSQL, reason, err := d.GenRollbackSQL(context.TODO(), executeSQL.Content)
+		for idx, executeSQL := range task.ExecuteSQLs {
+			reason, err := a.genRollbackSQL(d, executeSQL)
 			if err != nil {
 				return err
 			}
-			result := driver.NewInspectResults()
-			result.Add(driver.RuleLevel(executeSQL.AuditLevel), executeSQL.AuditResult)
+			result := auditResults[idx]
 			result.Add(driver.RuleLevelNotice, reason)
-			executeSQL.AuditLevel = string(result.Level())
-			executeSQL.AuditResult = result.Message()
-
-			rollbackSQLs = append(rollbackSQLs, &model.RollbackSQL{
-				BaseSQL: model.BaseSQL{
-					TaskId:  executeSQL.TaskId,
-					Content: rollbackSQL,
-				},
-				ExecuteSQLId: executeSQL.ID,
-			})
-		}
-
-		if err = st.UpdateRollbackSQLs(rollbackSQLs); err != nil {
-			a.entry.Errorf("save rollback SQLs error:%v", err)
-			return err
+			executeSQL.SetAuditResult(result)
 		}
 	}
 
@@ -335,8 +880,9 @@ func (a *action) audit() (err error) {
 
 	task.Status = model.TaskStatusAudited
 	if err = st.UpdateTask(task, map[string]interface{}{
-		"pass_rate": task.PassRate,
-		"status":    task.Status,
+		"audited_sql_count": task.AuditedSQLCount,
+		"pass_rate":         task.PassRate,
+		"status":            task.Status,
 	}); err != nil {
 		a.entry.Errorf("update task error:%v", err)
 		return err
@@ -347,7 +893,7 @@ func (a *action) audit() (err error) {
 func (a *action) execute() (err error) {
 	task := a.task
 
-	a.entry.Info("start execution...")
+	a.entry.WithField("type", a.typ).Info("start execution...")
 
 	if err = model.GetStorage().UpdateTaskStatusById(task.ID, model.TaskStatusExecuting); nil != err {
 		return
@@ -355,11 +901,16 @@ func (a *action) execute() (err error) {
 
 	// txSQLs keep adjacent DMLs, execute in one transaction.
 	var txSQLs []*model.ExecuteSQL
+	executeSQLs := a.getExecuteSQLs(task)
 
 outerLoop:
-	for i, executeSQL := range task.ExecuteSQLs {
+	for i, executeSQL := range executeSQLs {
+		if a.ctx.Err() != nil {
+			err = ErrActionCancelled
+			break outerLoop
+		}
 		var nodes []driver.Node
-		if nodes, err = a.driver.Parse(context.TODO(), executeSQL.Content); err != nil {
+		if nodes, err = a.driver.Parse(a.ctx, executeSQL.Content); err != nil {
 			break outerLoop
 		}
 
@@ -367,7 +918,7 @@ outerLoop:
 		case driver.SQLTypeDML:
 			txSQLs = append(txSQLs, executeSQL)
 
-			if i == len(task.ExecuteSQLs)-1 {
+			if i == len(executeSQLs)-1 {
 				if err = a.execSQLs(txSQLs); err != nil {
 					break outerLoop
 				}
@@ -392,15 +943,11 @@ outerLoop:
 
 	taskStatus := model.TaskStatusExecuteSucceeded
 
-	if err != nil {
+	if a.ctx.Err() != nil {
+		taskStatus = model.TaskStatusExecuteCancelled
+	} else if err != nil || task.IsExecuteFailed() {
+		// the failed SQLs skipped by resuming keep the task failed.
 		taskStatus = model.TaskStatusExecuteFailed
-	} else {
-		for _, sql := range task.ExecuteSQLs {
-			if sql.ExecStatus == model.SQLExecuteStatusFailed {
-				taskStatus = model.TaskStatusExecuteFailed
-				break
-			}
-		}
 	}
 
 	a.entry.WithField("task_status", taskStatus).Infof("execution is complated, err:%v", err)
@@ -409,14 +956,20 @@ outerLoop:
 
 // execSQL execute SQL and update SQL's executed status to storage.
 func (a *action) execSQL(executeSQL *model.ExecuteSQL) error {
+	if a.ctx.Err() != nil {
+		return ErrActionCancelled
+	}
 	st := model.GetStorage()
 
 	if err := st.UpdateExecuteSqlStatus(&executeSQL.BaseSQL, model.SQLExecuteStatusDoing, ""); err != nil {
 		return err
 	}
 
-	_, err := a.driver.Exec(context.TODO(), executeSQL.Content)
-	if err != nil {
+	_, err := a.driver.Exec(a.execContext([]*model.ExecuteSQL{executeSQL}), executeSQL.Content)
+	if err != nil && a.ctx.Err() != nil {
+		executeSQL.ExecStatus = model.SQLExecuteStatusCancelled
+		executeSQL.ExecResult = err.Error()
+	} else if err != nil {
 		executeSQL.ExecStatus = model.SQLExecuteStatusFailed
 		executeSQL.ExecResult = err.Error()
 	} else {
@@ -429,6 +982,65 @@ func (a *action) execSQL(executeSQL *model.ExecuteSQL) error {
 	return nil
 }
 
+// execContext returns the context passed to driver to execute executeSQLs, the progress
+// and the rollback reported by driver are saved.
+func (a *action) execContext(executeSQLs []*model.ExecuteSQL) context.Context {
+	ctx := driver.WithProgress(a.ctx, a.saveProgress(executeSQLs))
+	return driver.WithExecRollback(ctx, a.saveExecRollback(executeSQLs))
+}
+
+// saveExecRollback returns the function which saves the rollback of the executed SQL
+// reported by driver, the data kept for rollback is cleaned by Sqled.cleanLoop after
+// the retention.
+func (a *action) saveExecRollback(executeSQLs []*model.ExecuteSQL) driver.ExecRollbackFunc {
+	return func(index int, rollback *driver.ExecRollback) {
+		if index < 0 || index >= len(executeSQLs) || rollback == nil {
+			return
+		}
+		executeSQL := executeSQLs[index]
+		// the binlog range is saved with the execute result.
+		if rollback.StartBinlog != nil && rollback.EndBinlog != nil {
+			executeSQL.StartBinlogFile = rollback.StartBinlog.File
+			executeSQL.StartBinlogPos = rollback.StartBinlog.Pos
+			executeSQL.EndBinlogFile = rollback.EndBinlog.File
+			executeSQL.EndBinlogPos = rollback.EndBinlog.Pos
+		}
+		var retention *model.RollbackRetention
+		if rollback.CleanSQL != "" {
+			retention = &model.RollbackRetention{
+				InstanceId: a.task.InstanceId,
+				Schema:     a.task.Schema,
+				CleanSQL:   rollback.CleanSQL,
+				ExpiredAt:  time.Now().Add(rollback.Retention),
+
+				RecycleBinObject: rollback.RecycleBinObject,
+			}
+		}
+		if err := model.GetStorage().SaveExecRollback(executeSQL, rollback.RollbackSQL, retention); err != nil {
+			a.entry.Errorf("save rollback of SQL %d error: %v", executeSQL.Number, err)
+			return
+		}
+		a.entry.Infof("rollback of SQL %d is replaced by driver: %s", executeSQL.Number, rollback.RollbackSQL)
+	}
+}
+
+// saveProgress returns the function which saves the progress of the executing SQL
+// reported by driver, the index is the index of SQL in executeSQLs.
+func (a *action) saveProgress(executeSQLs []*model.ExecuteSQL) driver.ProgressFunc {
+	return func(index int, progress string) {
+		if index < 0 || index >= len(executeSQLs) {
+			return
+		}
+		executeSQL := executeSQLs[index]
+		executeSQL.ExecProgress = progress
+		err := model.GetStorage().UpdateExecuteSQLById(fmt.Sprintf("%v", executeSQL.ID),
+			map[string]interface{}{"exec_progress": progress})
+		if err != nil {
+			a.entry.Errorf("save progress of SQL %d error: %v", executeSQL.Number, err)
+		}
+	}
+}
+
 // execSQLs execute SQLs and update SQLs' executed status to storage.
 func (a *action) execSQLs(executeSQLs []*model.ExecuteSQL) error {
 	st := model.GetStorage()
@@ -445,8 +1057,14 @@ func (a *action) execSQLs(executeSQLs []*model.ExecuteSQL) error {
 		qs = append(qs, executeSQL.Content)
 	}
 
-	results, txErr := a.driver.Tx(context.TODO(), qs...)
+	ctx := a.execContext(executeSQLs)
+	results, txErr := a.driver.Tx(ctx, qs...)
 	for idx, executeSQL := range executeSQLs {
+		if txErr != nil && a.ctx.Err() != nil {
+			executeSQL.ExecStatus = model.SQLExecuteStatusCancelled
+			executeSQL.ExecResult = txErr.Error()
+			continue
+		}
 		if txErr != nil {
 			executeSQL.ExecStatus = model.SQLExecuteStatusFailed
 			executeSQL.ExecResult = txErr.Error()
@@ -461,53 +1079,149 @@ func (a *action) execSQLs(executeSQLs []*model.ExecuteSQL) error {
 	return st.UpdateExecuteSQLs(executeSQLs)
 }
 
+// rollback executes the rollback SQLs in one transaction if all of them are DML.
+// The DDL can't be rollbacked by transaction, so the rollback SQLs are executed
+// one by one if there is DDL, and the DMLs in one rollback SQL are still executed
+// in transaction.
 func (a *action) rollback() (err error) {
-	task := a.task
 	a.entry.Info("start rollback SQL")
 
-	var execErr error
-	st := model.GetStorage()
-ExecSQLs:
-	for _, rollbackSQL := range task.RollbackSQLs {
-		if rollbackSQL.Content == "" {
-			continue
-		}
-		if err = st.UpdateRollbackSqlStatus(&rollbackSQL.BaseSQL, model.SQLExecuteStatusDoing, ""); err != nil {
-			return err
-		}
-
-		nodes, err := a.driver.Parse(context.TODO(), rollbackSQL.Content)
+	rollbackSQLs, err := a.getRollbackSQLs(a.task)
+	if err != nil {
+		return err
+	}
+	allNodes := make([][]driver.Node, 0, len(rollbackSQLs))
+	inTx := true
+	for _, rollbackSQL := range rollbackSQLs {
+		nodes, err := a.driver.Parse(a.ctx, rollbackSQL.Content)
 		if err != nil {
 			return err
 		}
-		// todo: execute in transaction
 		for _, node := range nodes {
-			currentSQL := model.RollbackSQL{BaseSQL: model.BaseSQL{
-				TaskId:  rollbackSQL.TaskId,
-				Content: node.Text,
-			}, ExecuteSQLId: rollbackSQL.ExecuteSQLId}
-			_, execErr := a.driver.Exec(context.TODO(), node.Text)
-			if execErr != nil {
-				currentSQL.ExecStatus = model.SQLExecuteStatusFailed
-				currentSQL.ExecResult = execErr.Error()
-			} else {
-				currentSQL.ExecStatus = model.SQLExecuteStatusSucceeded
-				currentSQL.ExecResult = model.TaskExecResultOK
+			if node.Type != driver.SQLTypeDML {
+				inTx = false
+			}
+		}
+		allNodes = append(allNodes, nodes)
+	}
+
+	if inTx {
+		err = a.rollbackSQLs(rollbackSQLs, allNodes)
+	} else {
+		for idx, rollbackSQL := range rollbackSQLs {
+			if a.ctx.Err() != nil {
+				err = ErrActionCancelled
+				break
 			}
-			if execErr := st.Save(currentSQL); execErr != nil {
-				break ExecSQLs
+			if err = a.rollbackSQLs([]*model.RollbackSQL{rollbackSQL}, allNodes[idx:idx+1]); err != nil {
+				break
 			}
 		}
 	}
 
-	if execErr != nil {
-		a.entry.Errorf("rollback SQL error:%v", execErr)
+	if err != nil {
+		a.entry.Errorf("rollback SQL error:%v", err)
 	} else {
-		a.entry.Error("rollback SQL finished")
+		a.entry.Info("rollback SQL finished")
+	}
+	return err
+}
+
+// rollbackSQLs executes the rollback SQLs, the nodes is the SQLs split from the rollback SQL
+// at the same index. The nodes are executed in one transaction if they are all DML.
+func (a *action) rollbackSQLs(rollbackSQLs []*model.RollbackSQL, nodes [][]driver.Node) error {
+	st := model.GetStorage()
+
+	for _, rollbackSQL := range rollbackSQLs {
+		rollbackSQL.ExecStatus = model.SQLExecuteStatusDoing
+	}
+	if err := st.UpdateRollbackSQLs(rollbackSQLs); err != nil {
+		return err
+	}
+
+	inTx := true
+	qs := []string{}
+	for _, sqlNodes := range nodes {
+		for _, node := range sqlNodes {
+			if node.Type != driver.SQLTypeDML {
+				inTx = false
+			}
+			qs = append(qs, node.Text)
+		}
+	}
+
+	// the rollback of rollback SQLs is not kept, e.g. the tables dropped by the rollback
+	// of CREATE TABLE are not moved to recycle bin.
+	ctx := driver.WithRawExec(a.ctx)
+	var execErr error
+	if inTx {
+		_, execErr = a.driver.Tx(ctx, qs...)
+	} else {
+		for _, query := range qs {
+			if _, execErr = a.driver.Exec(ctx, query); execErr != nil {
+				break
+			}
+		}
+	}
+	for _, rollbackSQL := range rollbackSQLs {
+		if execErr != nil && a.ctx.Err() != nil {
+			rollbackSQL.ExecStatus = model.SQLExecuteStatusCancelled
+			rollbackSQL.ExecResult = execErr.Error()
+		} else if execErr != nil {
+			rollbackSQL.ExecStatus = model.SQLExecuteStatusFailed
+			rollbackSQL.ExecResult = execErr.Error()
+		} else {
+			rollbackSQL.ExecStatus = model.SQLExecuteStatusSucceeded
+			rollbackSQL.ExecResult = model.TaskExecResultOK
+		}
+	}
+	if err := st.UpdateRollbackSQLs(rollbackSQLs); err != nil {
+		return err
 	}
 	return execErr
 }
 
+// genRollbackSQL generates and saves the rollback SQL of executeSQL, and returns the
+// reason why it can't be rollbacked. The batches of rollback SQL are saved once they
+// are generated, so the rollback SQL of large DML is not kept in memory. The rollback
+// SQLs saved by the previous audit are deleted before the first batch is saved.
+func (a *action) genRollbackSQL(d driver.Driver, executeSQL *model.ExecuteSQL) (string, error) {
+	st := model.GetStorage()
+	batches := 0
+	var saveErr error
+	saveRollbackSQL := func(content string) {
+		if saveErr != nil {
+			return
+		}
+		if batches == 0 {
+			if saveErr = st.DeleteRollbackSQLsByExecuteSQLId(executeSQL.ID); saveErr != nil {
+				return
+			}
+		}
+		batches++
+		saveErr = st.UpdateRollbackSQLs([]*model.RollbackSQL{{
+			BaseSQL: model.BaseSQL{
+				TaskId:  executeSQL.TaskId,
+				Content: content,
+			},
+			ExecuteSQLId: executeSQL.ID,
+		}})
+	}
+	ctx := driver.WithRollbackBatch(a.ctx, saveRollbackSQL)
+	rollbackSQL, reason, err := d.GenRollbackSQL(ctx, executeSQL.Content)
+	if err != nil {
+		return "", err
+	}
+	if rollbackSQL != "" || batches == 0 {
+		saveRollbackSQL(rollbackSQL)
+	}
+	if saveErr != nil {
+		a.entry.Errorf("save rollback SQLs error:%v", saveErr)
+		return "", saveErr
+	}
+	return reason, nil
+}
+
 func newDriverWithAudit(l *logrus.Entry, inst *model.Instance, database string, dbType string) (driver.Driver, error) {
 	if inst == nil && dbType == "" {
 		return nil, xerrors.Errorf("instance is nil and dbType is nil")
@@ -6,9 +6,12 @@ import (
 	"errors"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/actiontech/sqle/sqle/config"
 	"github.com/actiontech/sqle/sqle/driver"
 	_ "github.com/actiontech/sqle/sqle/driver/mysql"
 	"github.com/actiontech/sqle/sqle/log"
@@ -30,11 +33,14 @@ func getAction(sqls []string, typ int, d driver.Driver) *action {
 	}
 
 	entry := log.NewEntry().WithField("task_id", task.ID)
+	ctx, cancel := context.WithCancel(context.Background())
 	return &action{
 		task:   task,
 		driver: d,
 		typ:    typ,
 		entry:  entry,
+		ctx:    ctx,
+		cancel: cancel,
 		done:   make(chan struct{}),
 	}
 }
@@ -75,10 +81,54 @@ func (d *mockDriver) Audit(ctx context.Context, sql string) (*driver.AuditResult
 	return nil, nil
 }
 
+func (d *mockDriver) AuditBatch(ctx context.Context, sqls []string) ([]*driver.AuditResult, error) {
+	results := make([]*driver.AuditResult, 0, len(sqls))
+	for range sqls {
+		results = append(results, driver.NewInspectResults())
+	}
+	return results, nil
+}
+
 func (d *mockDriver) GenRollbackSQL(ctx context.Context, sql string) (string, string, error) {
 	return "", "", nil
 }
 
+// cancelDriver cancels the action when it's executing SQLs in transaction.
+type cancelDriver struct {
+	mockDriver
+	cancel context.CancelFunc
+}
+
+func (d *cancelDriver) Tx(ctx context.Context, queries ...string) ([]_driver.Result, error) {
+	d.cancel()
+	return nil, ctx.Err()
+}
+
+func (d *cancelDriver) Parse(ctx context.Context, sqlText string) ([]driver.Node, error) {
+	typ := driver.SQLTypeDML
+	if strings.HasPrefix(sqlText, "create") {
+		typ = driver.SQLTypeDDL
+	}
+	return []driver.Node{{Text: sqlText, Type: typ}}, nil
+}
+
+// rollbackDriver records the SQLs executed in transaction and the SQLs executed alone.
+type rollbackDriver struct {
+	cancelDriver
+	txs   [][]string
+	execs []string
+}
+
+func (d *rollbackDriver) Exec(ctx context.Context, query string) (_driver.Result, error) {
+	d.execs = append(d.execs, query)
+	return nil, nil
+}
+
+func (d *rollbackDriver) Tx(ctx context.Context, queries ...string) ([]_driver.Result, error) {
+	d.txs = append(d.txs, queries)
+	return nil, nil
+}
+
 func TestAction_validation(t *testing.T) {
 	actions := map[int]*action{
 		ActionTypeAudit:    {typ: ActionTypeAudit},
@@ -131,6 +181,135 @@ func TestAction_validation(t *testing.T) {
 	assert.EqualError(t, actions[ActionTypeRollback].validation(noExecutedTask), ErrActionRollbackOnNonExecutedTask.Error())
 }
 
+func TestAction_resumeExecute(t *testing.T) {
+	resume := &action{typ: ActionTypeResumeExecute}
+	retry := &action{typ: ActionTypeRetryExecute}
+
+	executedFailTask := &model.Task{
+		Status: model.TaskStatusExecuteFailed,
+		ExecuteSQLs: []*model.ExecuteSQL{
+			{BaseSQL: model.BaseSQL{Number: 1, ExecStatus: model.SQLExecuteStatusSucceeded}},
+			{BaseSQL: model.BaseSQL{Number: 2, ExecStatus: model.SQLExecuteStatusFailed}},
+			{BaseSQL: model.BaseSQL{Number: 3, ExecStatus: model.SQLExecuteStatusInitialized}},
+		},
+	}
+	assert.Nil(t, resume.validation(executedFailTask))
+	assert.Nil(t, retry.validation(executedFailTask))
+	assert.Equal(t, []*model.ExecuteSQL{executedFailTask.ExecuteSQLs[2]}, resume.getExecuteSQLs(executedFailTask))
+	assert.Equal(t, executedFailTask.ExecuteSQLs[1:], retry.getExecuteSQLs(executedFailTask))
+
+	// the interrupted SQL may have been executed, it's not retried.
+	interruptedTask := &model.Task{
+		Status: model.TaskStatusExecuteInterrupted,
+		ExecuteSQLs: []*model.ExecuteSQL{
+			{BaseSQL: model.BaseSQL{Number: 1, ExecStatus: model.SQLExecuteStatusCancelled}},
+			{BaseSQL: model.BaseSQL{Number: 2, ExecStatus: model.SQLExecuteStatusInterrupted}},
+			{BaseSQL: model.BaseSQL{Number: 3, ExecStatus: model.SQLExecuteStatusInitialized}},
+		},
+	}
+	assert.Equal(t, []*model.ExecuteSQL{interruptedTask.ExecuteSQLs[0], interruptedTask.ExecuteSQLs[2]},
+		retry.getExecuteSQLs(interruptedTask))
+
+	// the last SQL is failed, there is nothing to resume, but it can be retried.
+	lastFailTask := &model.Task{
+		Status: model.TaskStatusExecuteFailed,
+		ExecuteSQLs: []*model.ExecuteSQL{
+			{BaseSQL: model.BaseSQL{ExecStatus: model.SQLExecuteStatusSucceeded}},
+			{BaseSQL: model.BaseSQL{ExecStatus: model.SQLExecuteStatusFailed}},
+		},
+	}
+	assert.EqualError(t, resume.validation(lastFailTask), ErrActionResumeWithoutSQL.Error())
+	assert.Nil(t, retry.validation(lastFailTask))
+
+	executedTask := &model.Task{
+		Status: model.TaskStatusExecuteSucceeded,
+		ExecuteSQLs: []*model.ExecuteSQL{
+			{BaseSQL: model.BaseSQL{ExecStatus: model.SQLExecuteStatusSucceeded}},
+		},
+	}
+	assert.EqualError(t, retry.validation(executedTask), ErrActionResumeOnNonFailedTask.Error())
+
+	rollbackedTask := &model.Task{
+		Status: model.TaskStatusExecuteCancelled,
+		ExecuteSQLs: []*model.ExecuteSQL{
+			{BaseSQL: model.BaseSQL{ExecStatus: model.SQLExecuteStatusCancelled}},
+		},
+		RollbackSQLs: []*model.RollbackSQL{
+			{BaseSQL: model.BaseSQL{ExecStatus: model.SQLExecuteStatusSucceeded}},
+		},
+	}
+	assert.EqualError(t, retry.validation(rollbackedTask), ErrActionResumeOnRollbackedTask.Error())
+}
+
+func TestAction_getRollbackSQLs(t *testing.T) {
+	executedTask := &model.Task{
+		ExecuteSQLs: []*model.ExecuteSQL{
+			{BaseSQL: model.BaseSQL{ExecStatus: model.SQLExecuteStatusSucceeded}},
+		},
+		RollbackSQLs: []*model.RollbackSQL{
+			{BaseSQL: model.BaseSQL{Model: model.Model{ID: 1}, Content: "delete from t1 where id=1", ExecStatus: model.SQLExecuteStatusSucceeded}},
+			{BaseSQL: model.BaseSQL{Model: model.Model{ID: 2}, Content: "delete from t1 where id=2", ExecStatus: model.SQLExecuteStatusInitialized}},
+			{BaseSQL: model.BaseSQL{Model: model.Model{ID: 3}, Content: "", ExecStatus: model.SQLExecuteStatusInitialized}},
+			{BaseSQL: model.BaseSQL{Model: model.Model{ID: 4}, Content: "delete from t1 where id=4", ExecStatus: model.SQLExecuteStatusInitialized}},
+		},
+	}
+
+	// the rollback SQLs are in reverse order, and the empty rollback SQL is skipped.
+	chosen := &action{typ: ActionTypeRollback, rollbackSQLIds: []uint{2, 3, 4}}
+	assert.Nil(t, chosen.validation(executedTask))
+	rollbackSQLs, err := chosen.getRollbackSQLs(executedTask)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.RollbackSQL{executedTask.RollbackSQLs[3], executedTask.RollbackSQLs[1]}, rollbackSQLs)
+
+	rollbacked := &action{typ: ActionTypeRollback, rollbackSQLIds: []uint{1, 2}}
+	assert.EqualError(t, rollbacked.validation(executedTask), ErrActionRollbackOnRollbackedTask.Error())
+
+	notExist := &action{typ: ActionTypeRollback, rollbackSQLIds: []uint{2, 5}}
+	assert.EqualError(t, notExist.validation(executedTask), ErrActionRollbackSQLNotExist.Error())
+
+	empty := &action{typ: ActionTypeRollback, rollbackSQLIds: []uint{3}}
+	assert.EqualError(t, empty.validation(executedTask), ErrActionRollbackWithoutSQL.Error())
+
+	// all rollback SQLs are rollbacked only once.
+	all := &action{typ: ActionTypeRollback}
+	assert.EqualError(t, all.validation(executedTask), ErrActionRollbackOnRollbackedTask.Error())
+}
+
+func Test_action_rollback(t *testing.T) {
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(&model.Storage{}), "UpdateRollbackSQLs", func(_ *model.Storage, _ []*model.RollbackSQL) error {
+		return nil
+	})
+	defer patches.Reset()
+
+	newRollbackAction := func(d driver.Driver, rollbackSQLs ...string) *action {
+		a := getAction(nil, ActionTypeRollback, d)
+		for i, sql := range rollbackSQLs {
+			a.task.RollbackSQLs = append(a.task.RollbackSQLs, &model.RollbackSQL{
+				BaseSQL: model.BaseSQL{Model: model.Model{ID: uint(i + 1)}, Content: sql, ExecStatus: model.SQLExecuteStatusInitialized},
+			})
+		}
+		return a
+	}
+
+	// all rollback SQLs are DML, they are executed in one transaction.
+	d := &rollbackDriver{}
+	a := newRollbackAction(d, "delete from t1 where id=1", "update t1 set a=1 where id=2", "insert into t1 values(3)")
+	a.rollbackSQLIds = []uint{2, 3}
+	assert.NoError(t, a.rollback())
+	assert.Equal(t, [][]string{{"insert into t1 values(3)", "update t1 set a=1 where id=2"}}, d.txs)
+	assert.Len(t, d.execs, 0)
+	assert.Equal(t, model.SQLExecuteStatusInitialized, a.task.RollbackSQLs[0].ExecStatus)
+	assert.Equal(t, model.SQLExecuteStatusSucceeded, a.task.RollbackSQLs[1].ExecStatus)
+	assert.Equal(t, model.SQLExecuteStatusSucceeded, a.task.RollbackSQLs[2].ExecStatus)
+
+	// DDL can't be rollbacked by transaction, the rollback SQLs are executed one by one.
+	d = &rollbackDriver{}
+	a = newRollbackAction(d, "delete from t1 where id=1", "create table t2(id int)")
+	assert.NoError(t, a.rollback())
+	assert.Equal(t, []string{"create table t2(id int)"}, d.execs)
+	assert.Equal(t, [][]string{{"delete from t1 where id=1"}}, d.txs)
+}
+
 func Test_action_audit_UpdateTask(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -142,6 +321,11 @@ func Test_action_audit_UpdateTask(t *testing.T) {
 	}
 	act := getAction([]string{"select * from t1"}, ActionTypeAudit, &mockDriver{})
 
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `tasks`")).
+		WithArgs(0, model.TaskStatusAuditing, act.task.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `sql_whitelist`")).
 		WillReturnRows(sqlmock.NewRows([]string{"value", "match_type"}).AddRow(whitelist.Value, whitelist.MatchType))
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `sql_whitelist`")).
@@ -149,13 +333,13 @@ func Test_action_audit_UpdateTask(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `execute_sql_detail`")).
-		WithArgs(model.MockTime, model.MockTime, nil, 0, 0, act.task.ExecuteSQLs[0].Content, "", 0, "", 0, 0, "", model.SQLAuditStatusFinished, "[normal]白名单", "2882fdbb7d5bcda7b49ea0803493467e", "normal").
+		WithArgs(model.MockTime, model.MockTime, nil, 0, 0, act.task.ExecuteSQLs[0].Content, "", 0, "", 0, 0, "", model.SQLAuditStatusFinished, "[normal]白名单", "2882fdbb7d5bcda7b49ea0803493467e", "normal", `[{"rule_name":"","category":"","level":"normal","message":"白名单"}]`, "").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta("UPDATE `tasks`")).
-		WithArgs(float64(1), model.TaskStatusAudited, act.task.ID).
+		WithArgs(1, float64(1), model.TaskStatusAudited, act.task.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
@@ -163,6 +347,121 @@ func Test_action_audit_UpdateTask(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, model.TaskStatusAudited, act.task.Status)
 	assert.Equal(t, float64(1), act.task.PassRate)
+	assert.Equal(t, uint(1), act.task.AuditedSQLCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// rollbackBatchDriver reports the rollback SQL in batches.
+type rollbackBatchDriver struct {
+	mockDriver
+	batches []string
+}
+
+func (d *rollbackBatchDriver) GenRollbackSQL(ctx context.Context, sql string) (string, string, error) {
+	for _, batch := range d.batches {
+		driver.GetRollbackBatch(ctx)(batch)
+	}
+	return "", "", nil
+}
+
+func Test_action_genRollbackSQL(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	model.InitMockStorage(mockDB)
+
+	d := &rollbackBatchDriver{batches: []string{"INSERT INTO t1 VALUES (1);", "INSERT INTO t1 VALUES (2);"}}
+	act := getAction([]string{"delete from t1"}, ActionTypeAudit, d)
+	act.task.ExecuteSQLs[0].ID = 2
+	act.task.ExecuteSQLs[0].TaskId = act.task.ID
+
+	// the rollback SQLs of the previous audit are deleted before the first batch is saved.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `rollback_sql_detail` SET `deleted_at`=?")).
+		WithArgs(sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	for _, batch := range d.batches {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `rollback_sql_detail`")).
+			WithArgs(model.MockTime, model.MockTime, nil, act.task.ID, 0, batch, "", 0, "", 0, 0, "", 2).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+
+	reason, err := act.genRollbackSQL(d, act.task.ExecuteSQLs[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "", reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// auditBatchDriver records the SQLs of each AuditBatch call.
+type auditBatchDriver struct {
+	mockDriver
+	batches [][]string
+}
+
+func (d *auditBatchDriver) AuditBatch(ctx context.Context, sqls []string) ([]*driver.AuditResult, error) {
+	d.batches = append(d.batches, sqls)
+	results := make([]*driver.AuditResult, 0, len(sqls))
+	for _, sql := range sqls {
+		result := driver.NewInspectResults()
+		result.Add(driver.RuleLevelWarn, sql)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func Test_action_auditBatch(t *testing.T) {
+	whitelist := []model.SqlWhitelist{{
+		CapitalizedValue: "SELECT * FROM T2",
+		MatchType:        model.SQLWhitelistExactMatch,
+	}}
+	d := &auditBatchDriver{}
+	act := getAction([]string{"select * from t1", "select * from t2", "select * from t3"}, ActionTypeAudit, d)
+
+	results, err := act.auditBatch(whitelist, act.task.ExecuteSQLs)
+	assert.NoError(t, err)
+	// the SQL matched by whitelist is not sent to driver.
+	assert.Equal(t, [][]string{{"select * from t1", "select * from t3"}}, d.batches)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "[warn]select * from t1", act.task.ExecuteSQLs[0].AuditResult)
+	assert.Equal(t, "[normal]白名单", act.task.ExecuteSQLs[1].AuditResult)
+	assert.Equal(t, "[warn]select * from t3", act.task.ExecuteSQLs[2].AuditResult)
+	for _, executeSQL := range act.task.ExecuteSQLs {
+		assert.Equal(t, model.SQLAuditStatusFinished, executeSQL.AuditStatus)
+	}
+
+	// all SQLs are matched by whitelist.
+	d = &auditBatchDriver{}
+	act = getAction([]string{"select * from t2"}, ActionTypeAudit, d)
+	_, err = act.auditBatch(whitelist, act.task.ExecuteSQLs)
+	assert.NoError(t, err)
+	assert.Len(t, d.batches, 0)
+}
+
+func Test_auditBatchEnd(t *testing.T) {
+	newExecuteSQLs := func(sizes ...int) []*model.ExecuteSQL {
+		executeSQLs := []*model.ExecuteSQL{}
+		for _, size := range sizes {
+			executeSQLs = append(executeSQLs, &model.ExecuteSQL{
+				BaseSQL: model.BaseSQL{Content: strings.Repeat("x", size)},
+			})
+		}
+		return executeSQLs
+	}
+
+	// the batch is limited by the count of SQLs.
+	executeSQLs := newExecuteSQLs(make([]int, auditBatchSize+1)...)
+	assert.Equal(t, auditBatchSize, auditBatchEnd(executeSQLs, 0))
+	assert.Equal(t, auditBatchSize+1, auditBatchEnd(executeSQLs, auditBatchSize))
+
+	// the batch is limited by the size of SQLs.
+	executeSQLs = newExecuteSQLs(auditBatchMaxBytes/2, auditBatchMaxBytes/2, 1, auditBatchMaxBytes+1, 1)
+	assert.Equal(t, 2, auditBatchEnd(executeSQLs, 0))
+	assert.Equal(t, 3, auditBatchEnd(executeSQLs, 2))
+	// the SQL exceeding the limit is audited alone.
+	assert.Equal(t, 4, auditBatchEnd(executeSQLs, 3))
+	assert.Equal(t, 5, auditBatchEnd(executeSQLs, 4))
 }
 
 func Test_action_execute(t *testing.T) {
@@ -255,3 +554,198 @@ func Test_action_execute(t *testing.T) {
 		})
 	}
 }
+
+func TestSqled_recoverActions(t *testing.T) {
+	s := &Sqled{
+		currentTask: map[string]*action{},
+		queue:       make(chan *action, 10),
+	}
+	records := []*model.TaskAction{
+		{Model: model.Model{ID: 1}, TaskId: 1, Type: ActionTypeExecute, Status: model.TaskActionStatusDoing},
+		{Model: model.Model{ID: 2}, TaskId: 2, Type: ActionTypeAudit, Status: model.TaskActionStatusDoing},
+		// the action is running by this sqled.
+		{Model: model.Model{ID: 4}, TaskId: 4, Type: ActionTypeExecute, Status: model.TaskActionStatusDoing},
+	}
+	s.currentTask["4"] = &action{}
+	interruptedTasks := []uint{}
+	actionStatus := map[uint]string{}
+
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(&model.Storage{}), "GetUnfinishedTaskActions", func(_ *model.Storage) ([]*model.TaskAction, error) {
+		return records, nil
+	})
+	defer patches.Reset()
+	patches.ApplyMethod(reflect.TypeOf(&model.Storage{}), "InterruptTaskExecution", func(_ *model.Storage, taskId uint, _ string) error {
+		interruptedTasks = append(interruptedTasks, taskId)
+		return nil
+	})
+	patches.ApplyMethod(reflect.TypeOf(&model.Storage{}), "UpdateTaskActionStatus", func(_ *model.Storage, record *model.TaskAction, status, _ string) error {
+		record.Status = status
+		actionStatus[record.ID] = status
+		return nil
+	})
+	patches.ApplyMethod(reflect.TypeOf(&model.Storage{}), "GetTaskDetailById", func(_ *model.Storage, _ string) (*model.Task, bool, error) {
+		return nil, false, nil
+	})
+	patches.ApplyMethod(reflect.TypeOf(&model.Storage{}), "GetTasksByStatus", func(_ *model.Storage, _ string) ([]*model.Task, error) {
+		return []*model.Task{{Model: model.Model{ID: 3}}}, nil
+	})
+
+	s.recoverActions()
+
+	// the running execution is interrupted, the audit is redone but the task has been deleted.
+	assert.Equal(t, []uint{1, 3}, interruptedTasks)
+	assert.Equal(t, model.TaskActionStatusInterrupted, actionStatus[1])
+	assert.Equal(t, model.TaskActionStatusFinished, actionStatus[2])
+	assert.NotContains(t, actionStatus, uint(4))
+	assert.Len(t, s.queue, 0)
+	assert.False(t, s.HasTask("2"))
+}
+
+func TestSqled_schedule(t *testing.T) {
+	s := newSqled(nil, config.SqleConfig{MaxTaskWorkers: 3, MaxAuditTaskWorkers: 1})
+	newAction := func(typ int, instanceId uint) *action {
+		return &action{typ: typ, task: &model.Task{InstanceId: instanceId}}
+	}
+	audit1 := newAction(ActionTypeAudit, 1)
+	audit2 := newAction(ActionTypeAudit, 1)
+	exec1 := newAction(ActionTypeExecute, 1)
+	rollback1 := newAction(ActionTypeRollback, 1)
+	exec2 := newAction(ActionTypeExecute, 2)
+	exec3 := newAction(ActionTypeExecute, 3)
+	s.pending = []*action{audit1, audit2, exec1, rollback1, exec2, exec3}
+
+	// audit2 waits for audit quota, rollback1 waits for exec1 on the same instance,
+	// exec3 waits for worker.
+	assert.Equal(t, []*action{audit1, exec1, exec2}, s.schedule())
+	assert.Equal(t, []*action{audit2, rollback1, exec3}, s.pending)
+
+	status := s.QueueStatus()
+	assert.Equal(t, 1, status.PendingAuditNum)
+	assert.Equal(t, 1, status.RunningAuditNum)
+	assert.Equal(t, 2, status.PendingExecuteNum)
+	assert.Equal(t, 2, status.RunningExecuteNum)
+
+	delete(s.running, exec1)
+	assert.Equal(t, []*action{rollback1}, s.schedule())
+
+	delete(s.running, audit1)
+	assert.Equal(t, []*action{audit2}, s.schedule())
+	assert.Equal(t, []*action{exec3}, s.pending)
+}
+
+func Test_action_execute_cancelled(t *testing.T) {
+	var taskStatus string
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(&model.Storage{}), "UpdateTaskStatusById", func(_ *model.Storage, _ uint, status string) error {
+		taskStatus = status
+		return nil
+	})
+	defer patches.Reset()
+	patches.ApplyMethod(reflect.TypeOf(&model.Storage{}), "UpdateExecuteSQLs", func(_ *model.Storage, _ []*model.ExecuteSQL) error {
+		return nil
+	})
+
+	d := &cancelDriver{}
+	a := getAction([]string{"update t1 set a=1", "create table t2(id int)"}, ActionTypeExecute, d)
+	d.cancel = a.cancel
+
+	assert.NoError(t, a.execute())
+	assert.Equal(t, model.TaskStatusExecuteCancelled, taskStatus)
+	assert.Equal(t, model.SQLExecuteStatusCancelled, a.task.ExecuteSQLs[0].ExecStatus)
+	// the SQL after the cancelled SQL is not executed.
+	assert.Equal(t, "", a.task.ExecuteSQLs[1].ExecStatus)
+}
+
+func TestSqled_CancelTask(t *testing.T) {
+	s := newSqled(nil, config.SqleConfig{})
+	var actionErr string
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(&model.Storage{}), "ClaimTaskAction", func(_ *model.Storage, _ *model.TaskAction) (bool, error) {
+		return true, nil
+	})
+	defer patches.Reset()
+	patches.ApplyMethod(reflect.TypeOf(&model.Storage{}), "UpdateTaskActionStatus", func(_ *model.Storage, _ *model.TaskAction, _, errMsg string) error {
+		actionErr = errMsg
+		return nil
+	})
+
+	pending := getAction(nil, ActionTypeExecute, &mockDriver{})
+	pending.record = &model.TaskAction{}
+	running := getAction(nil, ActionTypeExecute, &mockDriver{})
+	running.task.ID = 2
+	s.currentTask["1"] = pending
+	s.currentTask["2"] = running
+	s.pending = []*action{pending}
+	s.running[running] = struct{}{}
+
+	// the pending action is finished as cancelled without running.
+	assert.NoError(t, s.CancelTask("1"))
+	assert.Equal(t, pending, <-s.finished)
+	assert.Len(t, s.pending, 0)
+	assert.Equal(t, ErrActionCancelled, pending.err)
+	assert.Equal(t, ErrActionCancelled.Error(), actionErr)
+	assert.False(t, s.HasTask("1"))
+
+	// the running action is notified by context.
+	assert.NoError(t, s.CancelTask("2"))
+	assert.Equal(t, context.Canceled, running.ctx.Err())
+	assert.True(t, s.HasTask("2"))
+
+	assert.Error(t, s.CancelTask("3"))
+}
+
+func TestWorkflowScheduler_execute(t *testing.T) {
+	origin := sqled
+	sqled = newSqled(nil, config.SqleConfig{})
+	defer func() { sqled = origin }()
+
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(&model.Storage{}), "GetTaskById", func(_ *model.Storage, _ string) (*model.Task, bool, error) {
+		return &model.Task{Instance: &model.Instance{
+			Name:               "inst_1",
+			MaintenancePeriods: model.Periods{{StartHour: 22, EndHour: 2}},
+		}}, true, nil
+	})
+	defer patches.Reset()
+
+	ws := &WorkflowScheduler{logger: log.NewEntry()}
+	// the execution is deferred out of maintenance periods.
+	noon := time.Date(2021, 9, 1, 12, 0, 0, 0, time.Local)
+	assert.NoError(t, ws.execute(&model.WorkflowRecord{TaskId: 1}, noon))
+	assert.False(t, sqled.HasTask("1"))
+	assert.Len(t, sqled.queue, 0)
+}
+
+func TestWorkflowScheduler_run_failed(t *testing.T) {
+	origin := sqled
+	sqled = newSqled(nil, config.SqleConfig{})
+	defer func() { sqled = origin }()
+
+	record := &model.WorkflowRecord{TaskId: 1, ScheduleFailures: 2}
+	patches := gomonkey.ApplyMethod(reflect.TypeOf(&model.Storage{}), "GetNeedScheduledWorkflowRecords", func(_ *model.Storage, _ time.Time) ([]*model.WorkflowRecord, error) {
+		return []*model.WorkflowRecord{record}, nil
+	})
+	defer patches.Reset()
+	patches.ApplyMethod(reflect.TypeOf(&model.Storage{}), "GetTaskById", func(_ *model.Storage, _ string) (*model.Task, bool, error) {
+		return &model.Task{}, true, nil
+	})
+	var scheduleErr error
+	var retryAt time.Time
+	patches.ApplyMethod(reflect.TypeOf(&model.Storage{}), "UpdateWorkflowRecordScheduleFailure", func(_ *model.Storage, _ *model.WorkflowRecord, err error, at time.Time) error {
+		scheduleErr, retryAt = err, at
+		return nil
+	})
+
+	// the failed attempt is recorded and backed off.
+	ws := &WorkflowScheduler{persist: &model.Storage{}, logger: log.NewEntry()}
+	before := time.Now()
+	ws.run()
+	assert.EqualError(t, scheduleErr, "instance is not exist")
+	assert.True(t, !retryAt.Before(before.Add(4*time.Minute)))
+	assert.False(t, sqled.HasTask("1"))
+}
+
+func TestScheduleRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, scheduleRetryDelay(0))
+	assert.Equal(t, 2*time.Minute, scheduleRetryDelay(1))
+	assert.Equal(t, 32*time.Minute, scheduleRetryDelay(5))
+	assert.Equal(t, time.Hour, scheduleRetryDelay(6))
+	assert.Equal(t, time.Hour, scheduleRetryDelay(100))
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// workflowScheduleSpec is the interval to check the scheduled workflows.
+const workflowScheduleSpec = "@every 1m"
+
+// scheduleRetryMaxDelay is the max delay of the next attempt after the scheduled task
+// is failed to execute, the delay is doubled after each failure.
+const scheduleRetryMaxDelay = time.Hour
+
+func scheduleRetryDelay(failures uint) time.Duration {
+	// the delay exceeds the max delay after 6 failures.
+	if failures >= 6 {
+		return scheduleRetryMaxDelay
+	}
+	return time.Minute << failures
+}
+
+// WorkflowScheduler executes the tasks of the approved workflows at the scheduled time.
+// The scheduled time is persisted in workflow record and the task is not executed
+// until it's fired, so the schedule survives restarts.
+type WorkflowScheduler struct {
+	cron *cron.Cron
+
+	// persist is a database handle which store workflow.
+	persist *model.Storage
+
+	logger *logrus.Entry
+}
+
+func InitWorkflowScheduler(s *model.Storage) chan struct{} {
+	scheduler := &WorkflowScheduler{
+		cron:    cron.New(),
+		persist: s,
+		logger:  log.NewEntry().WithField("type", "workflow_scheduler"),
+	}
+	if _, err := scheduler.cron.AddFunc(workflowScheduleSpec, scheduler.run); err != nil {
+		panic(err)
+	}
+	scheduler.cron.Start()
+	scheduler.logger.Infoln("workflow scheduler started")
+
+	exitCh := make(chan struct{})
+	go func() {
+		<-exitCh
+		ctx := scheduler.cron.Stop()
+		<-ctx.Done()
+		scheduler.logger.Infoln("workflow scheduler stopped")
+	}()
+	return exitCh
+}
+
+func (ws *WorkflowScheduler) run() {
+	now := time.Now()
+	records, err := ws.persist.GetNeedScheduledWorkflowRecords(now)
+	if err != nil {
+		ws.logger.Errorf("get scheduled workflows error: %v", err)
+		return
+	}
+	for _, record := range records {
+		err := ws.execute(record, now)
+		if err == nil {
+			continue
+		}
+		// the failed attempt is backed off and recorded on the workflow, so it's
+		// not retried every minute silently.
+		entry := ws.logger.WithField("task_id", record.TaskId)
+		retryAt := now.Add(scheduleRetryDelay(record.ScheduleFailures))
+		entry.Errorf("execute scheduled task error, retry at %v: %v", retryAt, err)
+		if err := ws.persist.UpdateWorkflowRecordScheduleFailure(record, err, retryAt); err != nil {
+			entry.Errorf("update schedule failure error: %v", err)
+		}
+	}
+}
+
+// execute executes the task of the scheduled workflow record. The execution is deferred
+// to the next check if the instance is not in maintenance periods, and it's failed if
+// the instance is not connectable.
+func (ws *WorkflowScheduler) execute(record *model.WorkflowRecord, now time.Time) error {
+	entry := ws.logger.WithField("task_id", record.TaskId)
+	taskId := fmt.Sprintf("%d", record.TaskId)
+	if GetSqled().HasTask(taskId) {
+		return nil
+	}
+
+	task, exist, err := ws.persist.GetTaskById(taskId)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("task is not exist")
+	}
+	if task.Instance == nil {
+		return fmt.Errorf("instance is not exist")
+	}
+
+	if !task.Instance.MaintenancePeriods.IsWithinScope(now) {
+		entry.Infof("instance %s is not in maintenance periods %s, execution is deferred",
+			task.Instance.Name, task.Instance.MaintenancePeriods)
+		return nil
+	}
+
+	// commit action unable to retry, so don't to exec it if instance is not connectable.
+	d, err := newDriverWithAudit(entry, task.Instance, "", task.Instance.DbType)
+	if err != nil {
+		return err
+	}
+	defer d.Close(context.TODO())
+	if err := d.Ping(context.TODO()); err != nil {
+		return fmt.Errorf("instance %s is not connectable: %v", task.Instance.Name, err)
+	}
+
+	entry.Infof("execute scheduled task, scheduled at %v", record.ScheduledAt)
+	return GetSqled().AddTask(taskId, ActionTypeExecute)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 
 	"github.com/actiontech/sqle/sqle/api"
@@ -24,9 +25,10 @@ func Run(config *config.Config) error {
 
 	log.Logger().Infoln("starting sqled server")
 
-	if err := driver.InitPlugins(config.Server.SqleCnf.PluginPath); err != nil {
+	if err := driver.InitPlugins(config.Server.SqleCnf.PluginPath, config.Server.SqleCnf.PluginProcesses); err != nil {
 		return fmt.Errorf("init plugins error: %v", err)
 	}
+	defer driver.ClosePlugins()
 
 	dbConfig := config.Server.DBCnf.MysqlCnf
 	s, err := model.NewStorage(dbConfig.User, dbConfig.Password,
@@ -55,8 +57,10 @@ func Run(config *config.Config) error {
 	}
 
 	exitChan := make(chan struct{}, 0)
-	server.InitSqled(exitChan)
+	server.InitSqled(exitChan, config.Server.SqleCnf)
 	auditPlanMgrQuitCh := auditplan.InitManager(model.GetStorage())
+	workflowSchedulerQuitCh := server.InitWorkflowScheduler(model.GetStorage())
+	pluginWatcherQuitCh := server.InitPluginWatcher()
 
 	net := &gracenet.Net{}
 	go api.StartApi(net, exitChan, config.Server.SqleCnf)
@@ -66,10 +70,13 @@ func Run(config *config.Config) error {
 	select {
 	case <-exitChan:
 		auditPlanMgrQuitCh <- struct{}{}
+		workflowSchedulerQuitCh <- struct{}{}
+		pluginWatcherQuitCh <- struct{}{}
 		log.Logger().Infoln("sqled server will exit")
 	case sig := <-killChan:
 		switch sig {
 		case syscall.SIGUSR2:
+			os.Setenv(server.EnvGracefulRestartPid, strconv.Itoa(os.Getpid()))
 			if pid, err := net.StartProcess(); nil != err {
 				log.Logger().Infof("Graceful restarted by signal SIGUSR2, but failed: %v", err)
 				return err